@@ -6,7 +6,10 @@ import (
 	log "github.com/hillguo/sanlog"
 	"github.com/hillguo/sanrpc/client/discovery"
 	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/client/router"
 	"github.com/hillguo/sanrpc/codec"
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/metadata"
 	"github.com/hillguo/sanrpc/protocol/sanrpc"
 	"net"
 	"time"
@@ -14,7 +17,9 @@ import (
 import "github.com/hillguo/sanrpc/client/selector"
 
 type Client struct {
-	opts *Options
+	opts      *Options
+	discovery *discovery.Cache
+	stopWatch func() // 停止检查 ConfigFile
 }
 
 func NewClient(opt ...Option) *Client{
@@ -22,68 +27,176 @@ func NewClient(opt ...Option) *Client{
 		Network: "tcp",
 		Discovery: "ip",
 		Selector: "random",
+		SerializeType: codec.ProtoBuffer,
 		ConnectTimeout:0,
 		ReadTimeout: 0,
 		WriteTimeout:0,
+		RetryBackoff: 10 * time.Millisecond,
 	}
 
 	for _,o :=range opt {
 		o(opts)
 	}
-	return &Client{
-		opts: opts,
+
+	dis := discovery.Get(opts.Discovery)
+	if dis == nil {
+		dis = discovery.DefaultDiscovery
+		log.Debugf("can't find assign discovery [%s]. use default [%s]", opts.Discovery, dis.Name())
+	}
+	c := &Client{
+		opts:      opts,
+		discovery: discovery.NewCache(dis),
+	}
+	c.discovery.OnDelete(c.remove)
+	setHealthy(opts.Routers)
+	if opts.ConfigFile != "" {
+		rr, _ := router.NewRuleRouter(nil)
+		if err := rr.LoadFile(opts.ConfigFile); err != nil {
+			log.Errorf("load client config %s fail: %v", opts.ConfigFile, err)
+		}
+		// 复制一份，避免修改调用方传入的 Routers
+		opts.Routers = append(append(router.Chain{}, opts.Routers...), rr)
+		c.stopWatch = rr.WatchFile(opts.ConfigFile, router.DefaultRuleCheckInterval)
+	}
+	return c
+}
+
+// healthChecker 可以判断节点是否健康的路由，如 health.Checker
+type healthChecker interface {
+	Healthy(n *node.Node) bool
+}
+
+// setHealthy 没有指定 Healthy 的 SetRouter 使用 routers 中的第一个 healthChecker
+func setHealthy(routers router.Chain) {
+	var hc healthChecker
+	for _, rt := range routers {
+		if h, ok := rt.(healthChecker); ok {
+			hc = h
+			break
+		}
+	}
+	if hc == nil {
+		return
+	}
+	for _, rt := range routers {
+		if sr, ok := rt.(*router.SetRouter); ok && sr.Healthy == nil {
+			sr.Healthy = hc.Healthy
+		}
+	}
+}
+
+// Close 关闭客户端，停止服务发现的 watch
+func (c *Client) Close() error {
+	c.discovery.Close()
+	if c.stopWatch != nil {
+		c.stopWatch()
 	}
+	return nil
 }
 
 func (c *Client) Invoke(ctx context.Context, req interface{}, resp interface{}) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var sent bool
+		sent, err = c.invoke(ctx, req, resp)
+		if err == nil || attempt >= c.opts.MaxRetries || !c.retryable(sent, err) {
+			return err
+		}
+		log.Warnf("invoke %s.%s fail, retry %d: %v", c.opts.ServiceName, c.opts.MethodName, attempt+1, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(c.opts.RetryBackoff << uint(attempt)):
+		}
+	}
+}
+
+// retryable 请求已经发出后，只有服务端返回的可重试错误才重试，
+// 连接中断等传输错误无法确定服务端是否已经处理了请求
+func (c *Client) retryable(sent bool, err error) bool {
+	if !errs.IsRetryable(err) {
+		return false
+	}
+	if !sent {
+		return true
+	}
+	var e *errs.Error
+	return errors.As(err, &e)
+}
+
+// invoke 调用一次，sent 表示请求是否已经发出
+func (c *Client) invoke(ctx context.Context, req interface{}, resp interface{}) (sent bool, err error) {
 	// 1. select
-	node, err := c.selectNode()
+	node, err := c.selectNode(ctx)
 	if err != nil {
 		log.Error("select node error", err)
-		return err
+		return false, err
 	}
-	node.Network = c.opts.Network
+	start := time.Now()
+	defer func() {
+		c.report(node, time.Since(start), err)
+	}()
 	log.Debugf("select node success. node: %+v", node)
 
 	conn ,err := c.Connect(node)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer func() {
 		conn.Close()
 	}()
+	// ctx 结束时关闭连接，打断阻塞中的读写，如注册中心的长轮询
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
 
+	serializeType := c.opts.SerializeType
+	if t, ok := SerializeTypeFromContext(ctx); ok {
+		serializeType = t
+	}
 	reqmsg := &sanrpc.MessageProtocol{}
 	reqmsg.Header = &sanrpc.HeaderMsg{
 		Version: 0,
 		CallType: uint32(sanrpc.SanrpcMsgType_SANRPC_REQUEST_MSG),
 		ServiceName:c.opts.ServiceName,
 		MethodName: c.opts.MethodName,
-		EncodeType: uint32(codec.ProtoBuffer),
+		EncodeType: uint32(serializeType),
 		CompressType: uint32(codec.CompressNone),
 		MetaData: nil,
 	}
-	cc := codec.Codecs[codec.ProtoBuffer]
+	if md, ok := metadata.FromContext(ctx); ok {
+		reqmsg.Header.MetaData = md
+	}
+	cc := codec.Codecs[serializeType]
 	if cc == nil {
-		return errors.New("no codec")
+		return false, errors.New("no codec")
 	}
 	data, err := cc.Encode(req)
 	if err != nil {
-		return err
+		return false, err
 	}
 	reqmsg.Data = data
 	log.Debugf("req msg %+v", req)
 	msgProtocol := &sanrpc.SanRPCProtocol{}
 	d, err := msgProtocol.EncodeMessage(reqmsg)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if c.opts.WriteTimeout != 0 {
 		_ = conn.SetWriteDeadline(time.Now().Add(time.Duration(c.opts.WriteTimeout)))
 	}
 	_, err = conn.Write(d)
 	if err != nil {
-		return err
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return false, err
 	}
 	if c.opts.ReadTimeout != 0 {
 		_ = conn.SetReadDeadline(time.Now().Add(time.Duration(c.opts.ReadTimeout)))
@@ -91,29 +204,48 @@ func (c *Client) Invoke(ctx context.Context, req interface{}, resp interface{})
 	respmsg, err := msgProtocol.DecodeMessage(conn)
 	if err != nil {
 		log.Debug("DecodeMessage", err)
-		return err
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+		return true, err
 	}
 	res, _ := respmsg.(*sanrpc.MessageProtocol)
 	log.Debugf("resp msg %+v", res)
 	if res.Header == nil {
-		return errors.New("resp header nil")
+		return true, errors.New("resp header nil")
+	}
+	// 服务端返回的错误转换为 *errs.Error，调用方可以用 errors.Is/As 判断
+	if err := sanrpc.ToError(res.Err); err != nil {
+		return true, err
 	}
-	cc = codec.Codecs[codec.SerializeType(res.Header.EncodeType)]
+	cc = codec.GetCodec(res.Header.EncodeType)
 	if cc == nil {
-		return errors.New("resp codec not support")
+		return true, errors.New("resp codec not support")
 	}
-	err = cc.Decode(res.Data, resp)
+	compressor := codec.GetCompressor(res.Header.CompressType)
+	if compressor == nil {
+		return true, errors.New("resp compressor not support")
+	}
+	respData, err := compressor.Unzip(res.Data)
+	if err != nil {
+		return true, err
+	}
+	err = cc.Decode(respData, resp)
 	if err != nil {
 		log.Error("data decode fail")
-		return errors.New("data decode fail")
+		return true, errors.New("data decode fail")
 	}
-	return nil
+	return true, nil
 }
 
 func (c *Client) Connect(node *node.Node) ( net.Conn ,error){
 	var conn net.Conn
 	var err error
-	conn, err = net.DialTimeout(node.Network, node.Address, time.Duration(c.opts.ConnectTimeout))
+	network := c.opts.Network
+	if network == "" {
+		network = node.Network
+	}
+	conn, err = net.DialTimeout(network, node.Address, time.Duration(c.opts.ConnectTimeout))
 	if err != nil {
 		log.Warnf("failed to dial server: %v", err)
 		return nil, err
@@ -125,28 +257,55 @@ func (c *Client) Connect(node *node.Node) ( net.Conn ,error){
 	return conn, nil
 }
 
-func (c *Client) selectNode() (*node.Node,error) {
-
-	dis := discovery.Get(c.opts.Discovery)
-	if dis == nil {
-		dis = discovery.DefaultDiscovery
-		log.Debugf("can't find assign discovery [%s]. use default [%s]",c.opts.Discovery, dis.Name())
-	}
+func (c *Client) selectNode(ctx context.Context) (*node.Node,error) {
 
 	address := c.opts.Address
-	nodes,err := dis.List(address)
+	nodes,err := c.discovery.List(address)
 	if err != nil {
 		return nil,err
 	}
-
-	sec:= selector.GetSelector(c.opts.Selector)
-	if sec == nil {
-		sec=selector.DefaultSelector
-		log.Debugf("can't find assign selector [%s]. use default [%s]",c.opts.Selector, sec.Name())
+	nodes, err = c.opts.Routers.Filter(ctx, nodes)
+	if err != nil {
+		return nil, err
 	}
-	node,err := sec.Select(nodes)
+
+	sec := c.getSelector()
+	node,err := sec.Select(ctx, nodes)
 	if err != nil {
 		return nil,err
 	}
 	return node, nil
 }
+
+func (c *Client) getSelector() selector.Selector {
+	sec := selector.GetSelector(c.opts.Selector)
+	if sec == nil {
+		sec = selector.DefaultSelector
+		log.Debugf("can't find assign selector [%s]. use default [%s]", c.opts.Selector, sec.Name())
+	}
+	return sec
+}
+
+// report 把调用结果回报给 selector 和实现了 selector.Reporter 的 router，如 health.Checker
+func (c *Client) report(node *node.Node, cost time.Duration, err error) {
+	if r, ok := c.getSelector().(selector.Reporter); ok {
+		r.Report(node, cost, err)
+	}
+	for _, rt := range c.opts.Routers {
+		if r, ok := rt.(selector.Reporter); ok {
+			r.Report(node, cost, err)
+		}
+	}
+}
+
+// remove 服务发现删除节点时，通知实现了 selector.Remover 的 selector 和 router 清除节点的状态
+func (c *Client) remove(node *node.Node) {
+	if r, ok := c.getSelector().(selector.Remover); ok {
+		r.Remove(node)
+	}
+	for _, rt := range c.opts.Routers {
+		if r, ok := rt.(selector.Remover); ok {
+			r.Remove(node)
+		}
+	}
+}
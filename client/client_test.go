@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/client/router"
+	"github.com/hillguo/sanrpc/codec"
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+)
+
+// downRouter 不过滤节点，把 down 中的节点报告为不健康
+type downRouter struct {
+	down map[string]bool
+}
+
+func (r *downRouter) Filter(ctx context.Context, list []*node.Node) ([]*node.Node, error) {
+	return list, nil
+}
+
+func (r *downRouter) Name() string {
+	return "down"
+}
+
+func (r *downRouter) Healthy(n *node.Node) bool {
+	return !r.down[n.Address]
+}
+
+func TestWithSetRouter(t *testing.T) {
+	hc := &downRouter{down: map[string]bool{"a": true}}
+	c := NewClient(WithRouters(hc), WithSetRouter("Production", "formal", "sz.1", ""))
+	defer c.Close()
+
+	if len(c.opts.Routers) != 2 {
+		t.Fatalf("Routers = %v", c.opts.Routers)
+	}
+	sr, ok := c.opts.Routers[1].(*router.SetRouter)
+	if !ok {
+		t.Fatalf("Routers[1] = %T, want *router.SetRouter", c.opts.Routers[1])
+	}
+	if sr.Namespace != "Production" || sr.EnvName != "formal" || sr.SetName != "sz.1" || sr.Threshold != router.DefaultLocalThreshold {
+		t.Errorf("SetRouter = %+v", sr)
+	}
+	if sr.Healthy == nil {
+		t.Fatal("SetRouter.Healthy not wired to the health router")
+	}
+
+	meta := map[string]string{"namespace": "Production", "env": "formal", "set": "sz.1"}
+	list := []*node.Node{
+		{Address: "a", Metadata: meta},
+		{Address: "b", Metadata: meta},
+		{Address: "c", Metadata: map[string]string{"namespace": "Development", "env": "formal", "set": "sz.1"}},
+	}
+	got, err := c.opts.Routers.Filter(context.Background(), list)
+	if err != nil || len(got) != 1 || got[0].Address != "b" {
+		t.Errorf("Filter() = %v, %v, want [b]", got, err)
+	}
+}
+
+// fakeServer 按 reply 的返回值应答第 n 次（从 0 开始）请求：
+// 返回 nil 时成功，返回 errHang 时不应答，返回 errDrop 时读到请求后直接关闭连接
+type fakeServer struct {
+	ln    net.Listener
+	calls int32
+	reply func(n int) error
+}
+
+var (
+	errHang = errors.New("hang")
+	errDrop = errors.New("drop")
+)
+
+func newFakeServer(t *testing.T, reply func(n int) error) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{ln: ln, reply: reply}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	p := &sanrpc.SanRPCProtocol{}
+	msg, err := p.DecodeMessage(conn)
+	if err != nil {
+		return
+	}
+	req := msg.(*sanrpc.MessageProtocol)
+	resp := &sanrpc.MessageProtocol{
+		Header: &sanrpc.HeaderMsg{
+			CallType:   uint32(sanrpc.SanrpcMsgType_SANRPC_RESPONSE_MSG),
+			EncodeType: req.Header.EncodeType,
+		},
+		Err: &sanrpc.ErrMsg{Type: errs.ErrorTypeFramework, Msg: "success"},
+	}
+	switch err := s.reply(int(atomic.AddInt32(&s.calls, 1) - 1)); err {
+	case errHang:
+		// 等客户端关闭连接
+		conn.Read(make([]byte, 1))
+		return
+	case errDrop:
+		return
+	case nil:
+		resp.Data = req.Data
+	default:
+		e, _ := errs.FromError(err)
+		resp.Err = &sanrpc.ErrMsg{Type: e.Type, Code: e.Code, Msg: e.Msg}
+	}
+	data, _ := p.EncodeMessage(resp)
+	conn.Write(data)
+}
+
+func (s *fakeServer) Calls() int {
+	return int(atomic.LoadInt32(&s.calls))
+}
+
+func (s *fakeServer) Close() {
+	s.ln.Close()
+}
+
+func (s *fakeServer) client(opt ...Option) *Client {
+	opts := append([]Option{WithAddress(s.ln.Addr().String()), WithSerializeType(codec.JSON),
+		WithServiceName("greeter"), WithMethodName("Hello")}, opt...)
+	return NewClient(opts...)
+}
+
+type hello struct {
+	Name string `json:"name"`
+}
+
+func TestInvokeContextDone(t *testing.T) {
+	s := newFakeServer(t, func(n int) error { return errHang })
+	defer s.Close()
+	c := s.client()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := c.Invoke(ctx, &hello{Name: "tom"}, &hello{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Invoke() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if cost := time.Since(start); cost > time.Second {
+		t.Errorf("Invoke() returned after %v, not interrupted by ctx", cost)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := c.Invoke(ctx, &hello{Name: "tom"}, &hello{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Invoke() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	s := newFakeServer(t, func(n int) error { return nil })
+	defer s.Close()
+	c := s.client()
+	defer c.Close()
+
+	resp := &hello{}
+	if err := c.Invoke(context.Background(), &hello{Name: "tom"}, resp); err != nil || resp.Name != "tom" {
+		t.Errorf("Invoke() = %+v, %v", resp, err)
+	}
+}
+
+func TestInvokeRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		reply      func(n int) error
+		ok         bool
+		wantErr    error // 不为 nil 时返回的错误需要匹配
+		wantCalls  int
+	}{
+		{
+			name:       "retry until success",
+			maxRetries: 3,
+			reply: func(n int) error {
+				if n < 2 {
+					return errs.ErrServerOverload
+				}
+				return nil
+			},
+			ok:        true,
+			wantCalls: 3,
+		},
+		{
+			name:       "stop after MaxRetries",
+			maxRetries: 2,
+			reply:      func(n int) error { return errs.NewStatusError(errs.StatusUnavailable, "down") },
+			wantErr:    errs.NewStatusError(errs.StatusUnavailable, ""),
+			wantCalls:  3,
+		},
+		{
+			name:       "no retries by default",
+			maxRetries: 0,
+			reply:      func(n int) error { return errs.ErrServerOverload },
+			wantErr:    errs.ErrServerOverload,
+			wantCalls:  1,
+		},
+		{
+			name:       "non retryable status",
+			maxRetries: 3,
+			reply:      func(n int) error { return errs.NewStatusError(errs.StatusInvalidArgument, "bad") },
+			wantErr:    errs.NewStatusError(errs.StatusInvalidArgument, ""),
+			wantCalls:  1,
+		},
+		{
+			name:       "deadline exceeded may have run",
+			maxRetries: 3,
+			reply:      func(n int) error { return errs.ErrServerTimeout },
+			wantErr:    errs.ErrServerTimeout,
+			wantCalls:  1,
+		},
+		{
+			name:       "business error",
+			maxRetries: 3,
+			reply:      func(n int) error { return errs.New(int(errs.StatusUnavailable), "no stock") },
+			wantErr:    errs.New(int(errs.StatusUnavailable), ""),
+			wantCalls:  1,
+		},
+		{
+			// 请求已经发出后连接中断，无法确定服务端是否处理了请求
+			name:       "transport error after sent",
+			maxRetries: 3,
+			reply:      func(n int) error { return errDrop },
+			wantCalls:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeServer(t, tt.reply)
+			defer s.Close()
+			c := s.client(WithMaxRetries(tt.maxRetries), WithRetryBackoff(time.Millisecond))
+			defer c.Close()
+
+			err := c.Invoke(context.Background(), &hello{Name: "tom"}, &hello{})
+			if (err == nil) != tt.ok || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("Invoke() error = %v, want ok %v, error %v", err, tt.ok, tt.wantErr)
+			}
+			if s.Calls() != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", s.Calls(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestInvokeRetryBackoff(t *testing.T) {
+	// 连接失败时请求没有发出，可以重试；重试前等待 RetryBackoff<<attempt
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	const backoff = 20 * time.Millisecond
+	c := NewClient(WithAddress(addr), WithMaxRetries(3), WithRetryBackoff(backoff))
+	defer c.Close()
+	start := time.Now()
+	err = c.Invoke(context.Background(), &hello{}, &hello{})
+	if errs.Status(err) != errs.StatusUnavailable {
+		t.Errorf("Invoke() error = %v, status %v, want Unavailable", err, errs.Status(err))
+	}
+	// 20ms + 40ms + 80ms
+	if cost, want := time.Since(start), backoff*7; cost < want || cost > want+time.Second {
+		t.Errorf("Invoke() took %v, want about %v", cost, want)
+	}
+}
+
+func TestInvokeRetryContextDone(t *testing.T) {
+	s := newFakeServer(t, func(n int) error { return errs.ErrServerOverload })
+	defer s.Close()
+	c := s.client(WithMaxRetries(3), WithRetryBackoff(time.Hour))
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := c.Invoke(ctx, &hello{}, &hello{})
+	if !errors.Is(err, errs.ErrServerOverload) {
+		t.Errorf("Invoke() error = %v, want the last error %v", err, errs.ErrServerOverload)
+	}
+	if cost := time.Since(start); cost > time.Second {
+		t.Errorf("Invoke() returned after %v, backoff not interrupted by ctx", cost)
+	}
+	if s.Calls() != 1 {
+		t.Errorf("server called %d times, want 1", s.Calls())
+	}
+}
@@ -0,0 +1,162 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/client/node"
+)
+
+// Cache 在内存中缓存服务节点列表。
+// 被包装的 discovery 实现了 Watcher 时，首次 List 后通过 Watch 增量更新缓存；
+// 否则每次 List 都直接调用被包装的 discovery。
+type Cache struct {
+	d Discovery
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.RWMutex
+	services map[string][]*node.Node // serviceName => nodes，只整体替换不修改
+	loading  map[string]*load        // 正在首次加载的服务
+
+	onDelete func(n *node.Node) // Watch 推送删除事件时调用
+}
+
+// load 一个服务的首次加载，同一服务的并发 List 等待同一次加载
+type load struct {
+	done  chan struct{}
+	nodes []*node.Node
+	err   error
+}
+
+// NewCache 创建 Cache
+func NewCache(d Discovery) *Cache {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Cache{
+		d:        d,
+		ctx:      ctx,
+		cancel:   cancel,
+		services: make(map[string][]*node.Node),
+		loading:  make(map[string]*load),
+	}
+}
+
+// OnDelete 设置 Watch 推送节点删除事件时的回调，用于清除 selector 等保存的节点状态。
+// 需要在第一次 List 之前调用
+func (c *Cache) OnDelete(f func(n *node.Node)) {
+	c.onDelete = f
+}
+
+// List 返回缓存的节点列表，返回的列表不能修改
+func (c *Cache) List(serviceName string) ([]*node.Node, error) {
+	w, ok := c.d.(Watcher)
+	if !ok {
+		return c.d.List(serviceName)
+	}
+
+	c.mu.RLock()
+	nodes, ok := c.services[serviceName]
+	c.mu.RUnlock()
+	if ok {
+		return nodes, nil
+	}
+
+	if c.ctx.Err() != nil {
+		return c.d.List(serviceName)
+	}
+
+	// Watch 和 List 都是网络调用，不持有 c.mu，只在发布结果时加锁，
+	// 避免一个慢服务的首次加载阻塞其他服务的 List
+	c.mu.Lock()
+	if nodes, ok := c.services[serviceName]; ok {
+		c.mu.Unlock()
+		return nodes, nil
+	}
+	l, ok := c.loading[serviceName]
+	if ok {
+		c.mu.Unlock()
+		<-l.done
+		return l.nodes, l.err
+	}
+	l = &load{done: make(chan struct{})}
+	c.loading[serviceName] = l
+	c.mu.Unlock()
+
+	l.nodes, l.err = c.load(w, serviceName)
+	close(l.done)
+	return l.nodes, l.err
+}
+
+// load 首次加载服务的节点并开始 Watch
+func (c *Cache) load(w Watcher, serviceName string) ([]*node.Node, error) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	// 先 Watch 再 List，避免丢失两者之间的变更
+	ch, err := w.Watch(ctx, serviceName)
+	if err == nil {
+		var nodes []*node.Node
+		nodes, err = c.d.List(serviceName)
+		if err == nil {
+			c.mu.Lock()
+			c.services[serviceName] = nodes
+			delete(c.loading, serviceName)
+			c.mu.Unlock()
+			go c.watch(serviceName, ch, cancel)
+			return nodes, nil
+		}
+	}
+	cancel()
+	c.mu.Lock()
+	delete(c.loading, serviceName)
+	c.mu.Unlock()
+	return nil, err
+}
+
+func (c *Cache) watch(serviceName string, ch <-chan *Event, cancel context.CancelFunc) {
+	defer cancel()
+	for ev := range ch {
+		c.mu.Lock()
+		c.services[serviceName] = apply(c.services[serviceName], ev)
+		c.mu.Unlock()
+		if ev.Type == EventDelete && c.onDelete != nil {
+			c.onDelete(ev.Node)
+		}
+	}
+	log.Debugf("discovery: watch of service %s stopped", serviceName)
+
+	// Watch 异常结束时清掉缓存，下次 List 重新加载
+	c.mu.Lock()
+	delete(c.services, serviceName)
+	c.mu.Unlock()
+}
+
+// apply 返回应用变更后的新列表
+func apply(list []*node.Node, ev *Event) []*node.Node {
+	nodes := make([]*node.Node, 0, len(list)+1)
+	found := false
+	for _, n := range list {
+		if n.Address != ev.Node.Address {
+			nodes = append(nodes, n)
+			continue
+		}
+		found = true
+		if ev.Type != EventDelete {
+			nodes = append(nodes, ev.Node)
+		}
+	}
+	if !found && ev.Type != EventDelete {
+		nodes = append(nodes, ev.Node)
+	}
+	return nodes
+}
+
+// Name 返回被包装的 discovery 的名字
+func (c *Cache) Name() string {
+	return c.d.Name()
+}
+
+// Close 停止所有 Watch
+func (c *Cache) Close() {
+	c.cancel()
+}
@@ -0,0 +1,215 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/client/node"
+)
+
+func addrs(nodes []*node.Node) string {
+	ss := make([]string, len(nodes))
+	for i, n := range nodes {
+		ss[i] = n.Address
+	}
+	sort.Strings(ss)
+	return strings.Join(ss, ",")
+}
+
+func TestDiff(t *testing.T) {
+	a := &node.Node{Address: "a", Weight: 1}
+	b := &node.Node{Address: "b", Weight: 1}
+	b2 := &node.Node{Address: "b", Weight: 2}
+	bTagged := &node.Node{Address: "b", Weight: 1, Metadata: map[string]string{"set": "s1"}}
+	c := &node.Node{Address: "c", Weight: 1}
+
+	tests := []struct {
+		name string
+		old  []*node.Node
+		cur  []*node.Node
+		want []string // type:address
+	}{
+		{name: "unchanged", old: []*node.Node{a, b}, cur: []*node.Node{b, a}},
+		{name: "add", old: []*node.Node{a}, cur: []*node.Node{a, b}, want: []string{"add:b"}},
+		{name: "delete", old: []*node.Node{a, b}, cur: []*node.Node{a}, want: []string{"delete:b"}},
+		{name: "weight", old: []*node.Node{a, b}, cur: []*node.Node{a, b2}, want: []string{"update:b"}},
+		{name: "metadata", old: []*node.Node{b}, cur: []*node.Node{bTagged}, want: []string{"update:b"}},
+		{name: "mixed", old: []*node.Node{a, b}, cur: []*node.Node{b2, c}, want: []string{"update:b", "add:c", "delete:a"}},
+		{name: "from empty", cur: []*node.Node{a}, want: []string{"add:a"}},
+		{name: "to empty", old: []*node.Node{a}, want: []string{"delete:a"}},
+	}
+	types := map[EventType]string{EventAdd: "add", EventUpdate: "update", EventDelete: "delete"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, ev := range Diff(tt.old, tt.cur) {
+				got = append(got, types[ev.Type]+":"+ev.Node.Address)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeDiscovery 测试用 discovery，fakeWatchDiscovery 另外实现 Watcher，事件由测试通过 events 推送
+type fakeDiscovery struct {
+	mu     sync.Mutex
+	nodes  []*node.Node
+	events chan *Event
+	err    error
+	lists  int32 // List 调用次数
+	delay  time.Duration
+}
+
+func (d *fakeDiscovery) List(serviceName string) ([]*node.Node, error) {
+	atomic.AddInt32(&d.lists, 1)
+	time.Sleep(d.delay)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.nodes, d.err
+}
+
+func (d *fakeDiscovery) Name() string {
+	return "fake"
+}
+
+type fakeWatchDiscovery struct {
+	fakeDiscovery
+}
+
+func (d *fakeWatchDiscovery) Watch(ctx context.Context, serviceName string) (<-chan *Event, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return nil, d.err
+	}
+	d.events = make(chan *Event)
+	return d.events, nil
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCacheWithoutWatcher(t *testing.T) {
+	d := &fakeDiscovery{nodes: []*node.Node{{Address: "a"}}}
+	c := NewCache(d)
+	defer c.Close()
+	for i := 0; i < 3; i++ {
+		if nodes, err := c.List("svc"); err != nil || addrs(nodes) != "a" {
+			t.Fatalf("List() = %v, %v", addrs(nodes), err)
+		}
+	}
+	if n := atomic.LoadInt32(&d.lists); n != 3 {
+		t.Errorf("discovery listed %d times, want every List to pass through", n)
+	}
+}
+
+func TestCacheWatch(t *testing.T) {
+	d := &fakeWatchDiscovery{fakeDiscovery{nodes: []*node.Node{{Address: "a"}, {Address: "b"}}}}
+	c := NewCache(d)
+	defer c.Close()
+	var deleted []string
+	var mu sync.Mutex
+	c.OnDelete(func(n *node.Node) {
+		mu.Lock()
+		deleted = append(deleted, n.Address)
+		mu.Unlock()
+	})
+
+	if nodes, err := c.List("svc"); err != nil || addrs(nodes) != "a,b" {
+		t.Fatalf("List() = %v, %v", addrs(nodes), err)
+	}
+	d.events <- &Event{Type: EventAdd, Node: &node.Node{Address: "c"}}
+	d.events <- &Event{Type: EventDelete, Node: &node.Node{Address: "a"}}
+	d.events <- &Event{Type: EventUpdate, Node: &node.Node{Address: "b", Weight: 5}}
+	waitFor(t, "events applied", func() bool {
+		nodes, _ := c.List("svc")
+		for _, n := range nodes {
+			if n.Address == "b" && n.Weight == 5 {
+				return addrs(nodes) == "b,c"
+			}
+		}
+		return false
+	})
+	if n := atomic.LoadInt32(&d.lists); n != 1 {
+		t.Errorf("discovery listed %d times, want only the first load", n)
+	}
+	mu.Lock()
+	if strings.Join(deleted, ",") != "a" {
+		t.Errorf("OnDelete called for %v, want [a]", deleted)
+	}
+	mu.Unlock()
+
+	// 推送结束后清掉缓存，下次 List 重新加载
+	close(d.events)
+	waitFor(t, "cache dropped", func() bool {
+		c.List("svc")
+		return atomic.LoadInt32(&d.lists) > 1
+	})
+}
+
+func TestCacheConcurrentFirstLoad(t *testing.T) {
+	d := &fakeWatchDiscovery{fakeDiscovery{nodes: []*node.Node{{Address: "a"}}, delay: 20 * time.Millisecond}}
+	c := NewCache(d)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if nodes, err := c.List("svc"); err != nil || addrs(nodes) != "a" {
+				t.Errorf("List() = %v, %v", addrs(nodes), err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&d.lists); n != 1 {
+		t.Errorf("discovery listed %d times, want one shared load", n)
+	}
+}
+
+func TestCacheLoadError(t *testing.T) {
+	errList := errors.New("list fail")
+	d := &fakeWatchDiscovery{fakeDiscovery{err: errList}}
+	c := NewCache(d)
+	defer c.Close()
+	if _, err := c.List("svc"); err != errList {
+		t.Fatalf("List() error = %v, want %v", err, errList)
+	}
+
+	// 失败不缓存，恢复后重新加载
+	d.mu.Lock()
+	d.err = nil
+	d.nodes = []*node.Node{{Address: "a"}}
+	d.mu.Unlock()
+	if nodes, err := c.List("svc"); err != nil || addrs(nodes) != "a" {
+		t.Fatalf("List() = %v, %v", addrs(nodes), err)
+	}
+}
+
+func TestCacheClosed(t *testing.T) {
+	d := &fakeWatchDiscovery{fakeDiscovery{nodes: []*node.Node{{Address: "a"}}}}
+	c := NewCache(d)
+	c.Close()
+	c.List("svc")
+	c.List("svc")
+	if n := atomic.LoadInt32(&d.lists); n != 2 {
+		t.Errorf("discovery listed %d times after Close, want every List to pass through", n)
+	}
+}
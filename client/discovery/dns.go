@@ -0,0 +1,174 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/valyala/fastrand"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// 只做 dns discovery 需要的 A/AAAA/SRV 查询，报文编解码使用 dnsmessage
+
+const dnsMaxUDPLen = 4096
+
+var errDNSMismatch = errors.New("dns: response does not match the query")
+
+// dnsRR 一条应答记录
+type dnsRR struct {
+	Name string
+	Type dnsmessage.Type
+	TTL  uint32
+
+	IP net.IP // A/AAAA
+
+	Priority uint16 // SRV
+	Weight   uint16
+	Port     uint16
+	Target   string
+}
+
+// dnsMsg 应答中的记录
+type dnsMsg struct {
+	Answers    []*dnsRR
+	Additional []*dnsRR
+}
+
+// dnsExchange 向 resolver 发送一次查询，UDP 应答被截断时改用 TCP 重试
+func dnsExchange(resolver string, name string, qtype dnsmessage.Type, timeout time.Duration) (*dnsMsg, error) {
+	id := uint16(fastrand.Uint32n(1 << 16))
+	query, err := dnsQuery(id, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := dnsExchangeUDP(resolver, query, timeout)
+	if err != nil {
+		return nil, err
+	}
+	m, err := dnsParse(resp, id)
+	if err == nil && m.Truncated {
+		if resp, err = dnsExchangeTCP(resolver, query, timeout); err == nil {
+			m, err = dnsParse(resp, id)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dnsMsg{Answers: dnsRecords(m.Answers), Additional: dnsRecords(m.Additionals)}, nil
+}
+
+func dnsExchangeUDP(resolver string, query []byte, timeout time.Duration) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", resolver, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, dnsMaxUDPLen)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func dnsExchangeTCP(resolver string, query []byte, timeout time.Duration) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", resolver, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	msg := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(msg, uint16(len(query)))
+	copy(msg[2:], query)
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(head))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// dnsQuery 构造一个开启递归查询的请求
+func dnsQuery(id uint16, name string, qtype dnsmessage.Type) ([]byte, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	n, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, fmt.Errorf("dns: invalid name %s: %v", name, err)
+	}
+	m := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: n, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	return m.Pack()
+}
+
+// dnsParse 解析应答并检查是否对应 id 的查询以及 rcode
+func dnsParse(resp []byte, id uint16) (*dnsmessage.Message, error) {
+	m := &dnsmessage.Message{}
+	if err := m.Unpack(resp); err != nil {
+		return nil, fmt.Errorf("dns: %v", err)
+	}
+	if m.ID != id || !m.Response {
+		return nil, errDNSMismatch
+	}
+	if m.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("dns: %v", m.RCode)
+	}
+	return m, nil
+}
+
+// dnsRecords 转换 A/AAAA/SRV 记录，忽略其他类型
+func dnsRecords(rs []dnsmessage.Resource) []*dnsRR {
+	rrs := make([]*dnsRR, 0, len(rs))
+	for _, r := range rs {
+		rr := &dnsRR{Name: r.Header.Name.String(), Type: r.Header.Type, TTL: r.Header.TTL}
+		switch b := r.Body.(type) {
+		case *dnsmessage.AResource:
+			rr.IP = net.IP(b.A[:])
+		case *dnsmessage.AAAAResource:
+			rr.IP = net.IP(b.AAAA[:])
+		case *dnsmessage.SRVResource:
+			rr.Priority, rr.Weight, rr.Port = b.Priority, b.Weight, b.Port
+			rr.Target = b.Target.String()
+		default:
+			continue
+		}
+		rrs = append(rrs, rr)
+	}
+	return rrs
+}
+
+// systemResolver 返回 /etc/resolv.conf 中的第一个 nameserver
+func systemResolver() string {
+	data, err := ioutil.ReadFile("/etc/resolv.conf")
+	if err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			f := strings.Fields(line)
+			if len(f) >= 2 && f[0] == "nameserver" {
+				return net.JoinHostPort(f[1], "53")
+			}
+		}
+	}
+	return "127.0.0.1:53"
+}
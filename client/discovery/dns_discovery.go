@@ -0,0 +1,275 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/client/node"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func init() {
+	Register("dns", NewDNSDiscovery("", 0))
+}
+
+const (
+	// DefaultDNSTimeout 默认的单次查询超时
+	DefaultDNSTimeout = 3 * time.Second
+	// DefaultDNSMinTTL 默认的最短重新解析间隔
+	DefaultDNSMinTTL = time.Second
+	// DefaultDNSMaxTTL 默认的最长重新解析间隔
+	DefaultDNSMaxTTL = 5 * time.Minute
+)
+
+// DNSDiscovery 通过 DNS 解析服务节点。
+// 以 "_" 开头的服务名查询 SRV 记录，端口和权重取自记录，只使用优先级最高的一组；
+// 其他服务名查询 A/AAAA 记录，服务名为 host:port 时使用其中的端口，否则使用 DefaultPort。
+// 解析结果按记录的 TTL 缓存，Watch 在 TTL 到期后重新解析并推送变更。
+type DNSDiscovery struct {
+	Resolver    string // dns 服务器地址 ip:port，为空时使用 /etc/resolv.conf 中的第一个
+	DefaultPort int
+	Timeout     time.Duration
+	MinTTL      time.Duration
+	MaxTTL      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*dnsEntry
+}
+
+type dnsEntry struct {
+	nodes  []*node.Node
+	expire time.Time
+}
+
+// NewDNSDiscovery 创建 DNSDiscovery
+func NewDNSDiscovery(resolver string, defaultPort int) *DNSDiscovery {
+	return &DNSDiscovery{
+		Resolver:    resolver,
+		DefaultPort: defaultPort,
+		Timeout:     DefaultDNSTimeout,
+		MinTTL:      DefaultDNSMinTTL,
+		MaxTTL:      DefaultDNSMaxTTL,
+		cache:       make(map[string]*dnsEntry),
+	}
+}
+
+// List 返回服务的节点列表，TTL 内直接返回缓存
+func (d *DNSDiscovery) List(serviceName string) ([]*node.Node, error) {
+	d.mu.Lock()
+	e := d.cache[serviceName]
+	d.mu.Unlock()
+	if e != nil && time.Now().Before(e.expire) {
+		return e.nodes, nil
+	}
+
+	e, err := d.refresh(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return e.nodes, nil
+}
+
+// Watch TTL 到期后重新解析并推送节点变更，解析失败时保留原节点并在 MinTTL 后重试
+func (d *DNSDiscovery) Watch(ctx context.Context, serviceName string) (<-chan *Event, error) {
+	e, err := d.refresh(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *Event, 16)
+	go func() {
+		defer close(ch)
+		last := e.nodes
+		timer := time.NewTimer(time.Until(e.expire))
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			e, err := d.refresh(serviceName)
+			if err != nil {
+				log.Errorf("discovery: resolve %s fail, keep last nodes: %v", serviceName, err)
+				timer.Reset(d.MinTTL)
+				continue
+			}
+			for _, ev := range Diff(last, e.nodes) {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = e.nodes
+			timer.Reset(time.Until(e.expire))
+		}
+	}()
+	return ch, nil
+}
+
+func (d *DNSDiscovery) Name() string {
+	return "dns"
+}
+
+func (d *DNSDiscovery) refresh(serviceName string) (*dnsEntry, error) {
+	nodes, ttl, err := d.resolve(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	if ttl < d.MinTTL {
+		ttl = d.MinTTL
+	}
+	if d.MaxTTL > 0 && ttl > d.MaxTTL {
+		ttl = d.MaxTTL
+	}
+	e := &dnsEntry{nodes: nodes, expire: time.Now().Add(ttl)}
+
+	d.mu.Lock()
+	if d.cache == nil {
+		d.cache = make(map[string]*dnsEntry)
+	}
+	d.cache[serviceName] = e
+	d.mu.Unlock()
+	return e, nil
+}
+
+func (d *DNSDiscovery) resolver() string {
+	if d.Resolver != "" {
+		return d.Resolver
+	}
+	return systemResolver()
+}
+
+// resolve 返回节点列表和其中最小的 TTL
+func (d *DNSDiscovery) resolve(serviceName string) ([]*node.Node, time.Duration, error) {
+	if strings.HasPrefix(serviceName, "_") {
+		return d.resolveSRV(serviceName)
+	}
+
+	host, port := serviceName, d.DefaultPort
+	if h, p, err := net.SplitHostPort(serviceName); err == nil {
+		if port, err = strconv.Atoi(p); err != nil {
+			return nil, 0, fmt.Errorf("discovery: invalid port in %s", serviceName)
+		}
+		host = h
+	}
+	if port <= 0 {
+		return nil, 0, fmt.Errorf("discovery: no port for %s", serviceName)
+	}
+
+	ips, ttl, err := d.resolveIP(host, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	nodes := make([]*node.Node, 0, len(ips))
+	for _, ip := range ips {
+		nodes = append(nodes, &node.Node{
+			ServiceName: serviceName,
+			Address:     net.JoinHostPort(ip.String(), strconv.Itoa(port)),
+		})
+	}
+	return nodes, ttl, nil
+}
+
+func (d *DNSDiscovery) resolveSRV(serviceName string) ([]*node.Node, time.Duration, error) {
+	m, err := dnsExchange(d.resolver(), serviceName, dnsmessage.TypeSRV, d.Timeout)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var srvs []*dnsRR
+	for _, rr := range m.Answers {
+		if rr.Type != dnsmessage.TypeSRV {
+			continue
+		}
+		if len(srvs) > 0 && rr.Priority > srvs[0].Priority {
+			continue
+		}
+		if len(srvs) > 0 && rr.Priority < srvs[0].Priority {
+			srvs = srvs[:0]
+		}
+		srvs = append(srvs, rr)
+	}
+	if len(srvs) == 0 {
+		return nil, 0, fmt.Errorf("discovery: no SRV record for %s", serviceName)
+	}
+
+	var nodes []*node.Node
+	ttl := time.Duration(srvs[0].TTL) * time.Second
+	for _, srv := range srvs {
+		if t := time.Duration(srv.TTL) * time.Second; t < ttl {
+			ttl = t
+		}
+		ips, t, err := d.resolveIP(srv.Target, m.Additional)
+		if err != nil {
+			log.Errorf("discovery: resolve SRV target %s of %s fail: %v", srv.Target, serviceName, err)
+			continue
+		}
+		if t < ttl {
+			ttl = t
+		}
+		for _, ip := range ips {
+			nodes = append(nodes, &node.Node{
+				ServiceName: serviceName,
+				Address:     net.JoinHostPort(ip.String(), strconv.Itoa(int(srv.Port))),
+				Weight:      int(srv.Weight),
+			})
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, 0, fmt.Errorf("discovery: no address for SRV targets of %s", serviceName)
+	}
+	return nodes, ttl, nil
+}
+
+// resolveIP 解析 host 的 A/AAAA 记录，优先使用 additional 中已有的记录
+func (d *DNSDiscovery) resolveIP(host string, additional []*dnsRR) ([]net.IP, time.Duration, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, d.MaxTTL, nil
+	}
+
+	var ips []net.IP
+	var ttl time.Duration
+	add := func(rrs []*dnsRR, match bool) {
+		for _, rr := range rrs {
+			if rr.IP == nil || (match && !strings.EqualFold(strings.TrimSuffix(rr.Name, "."), strings.TrimSuffix(host, "."))) {
+				continue
+			}
+			if t := time.Duration(rr.TTL) * time.Second; len(ips) == 0 || t < ttl {
+				ttl = t
+			}
+			ips = append(ips, rr.IP)
+		}
+	}
+
+	add(additional, true)
+	if len(ips) > 0 {
+		return ips, ttl, nil
+	}
+
+	var errs []string
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		m, err := dnsExchange(d.resolver(), host, qtype, d.Timeout)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		add(m.Answers, false)
+	}
+	if len(ips) == 0 {
+		if len(errs) > 0 {
+			return nil, 0, errors.New(strings.Join(errs, "; "))
+		}
+		return nil, 0, fmt.Errorf("discovery: no A/AAAA record for %s", host)
+	}
+	return ips, ttl, nil
+}
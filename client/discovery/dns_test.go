@@ -0,0 +1,382 @@
+package discovery
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsStandIn 本地 DNS 替身，在 127.0.0.1 同一端口上监听 UDP 和 TCP，用 handler 构造应答
+type dnsStandIn struct {
+	addr string
+	udp  net.PacketConn
+	tcp  net.Listener
+
+	queries int32 // 收到的查询数
+	tcpUsed int32 // 通过 TCP 收到的查询数
+
+	mu      sync.Mutex
+	handler func(q *testQuery, tcp bool) []byte
+}
+
+type testQuery struct {
+	raw   []byte
+	id    uint16
+	name  string
+	qtype dnsmessage.Type
+}
+
+func startDNSStandIn(t *testing.T, handler func(q *testQuery, tcp bool) []byte) *dnsStandIn {
+	t.Helper()
+	s := &dnsStandIn{handler: handler}
+	var err error
+	for i := 0; i < 10; i++ {
+		if s.udp, err = net.ListenPacket("udp", "127.0.0.1:0"); err != nil {
+			t.Fatal(err)
+		}
+		s.addr = s.udp.LocalAddr().String()
+		if s.tcp, err = net.Listen("tcp", s.addr); err == nil {
+			break
+		}
+		s.udp.Close()
+	}
+	if err != nil {
+		t.Fatalf("listen stand-in: %v", err)
+	}
+	go s.serveUDP()
+	go s.serveTCP()
+	return s
+}
+
+func (s *dnsStandIn) Close() {
+	s.udp.Close()
+	s.tcp.Close()
+}
+
+func (s *dnsStandIn) answer(raw []byte, tcp bool) []byte {
+	atomic.AddInt32(&s.queries, 1)
+	if tcp {
+		atomic.AddInt32(&s.tcpUsed, 1)
+	}
+	var p dnsmessage.Parser
+	h, err := p.Start(raw)
+	if err != nil {
+		return nil
+	}
+	question, err := p.Question()
+	if err != nil {
+		return nil
+	}
+	q := &testQuery{raw: raw, id: h.ID, name: strings.TrimSuffix(question.Name.String(), "."), qtype: question.Type}
+	s.mu.Lock()
+	handler := s.handler
+	s.mu.Unlock()
+	return handler(q, tcp)
+}
+
+func (s *dnsStandIn) serveUDP() {
+	buf := make([]byte, dnsMaxUDPLen)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.answer(append([]byte(nil), buf[:n]...), false); resp != nil {
+			s.udp.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *dnsStandIn) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			head := make([]byte, 2)
+			if _, err := io.ReadFull(conn, head); err != nil {
+				return
+			}
+			raw := make([]byte, binary.BigEndian.Uint16(head))
+			if _, err := io.ReadFull(conn, raw); err != nil {
+				return
+			}
+			resp := s.answer(raw, true)
+			if resp == nil {
+				return
+			}
+			binary.BigEndian.PutUint16(head, uint16(len(resp)))
+			conn.Write(append(head, resp...))
+		}()
+	}
+}
+
+// buildResp 构造对查询 q 的应答，打开名字压缩，question 段与查询相同
+func buildResp(q *testQuery, rcode dnsmessage.RCode, truncated bool, answers, additional []dnsmessage.Resource) []byte {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: q.id, Response: true, RCode: rcode, Truncated: truncated, RecursionAvailable: true})
+	b.EnableCompression()
+	b.StartQuestions()
+	b.Question(dnsmessage.Question{Name: mustName(q.name), Type: q.qtype, Class: dnsmessage.ClassINET})
+	b.StartAnswers()
+	for _, rr := range answers {
+		addRR(&b, rr)
+	}
+	b.StartAdditionals()
+	for _, rr := range additional {
+		addRR(&b, rr)
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+func addRR(b *dnsmessage.Builder, rr dnsmessage.Resource) {
+	var err error
+	switch body := rr.Body.(type) {
+	case *dnsmessage.AResource:
+		err = b.AResource(rr.Header, *body)
+	case *dnsmessage.AAAAResource:
+		err = b.AAAAResource(rr.Header, *body)
+	case *dnsmessage.SRVResource:
+		err = b.SRVResource(rr.Header, *body)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func mustName(name string) dnsmessage.Name {
+	return dnsmessage.MustNewName(strings.TrimSuffix(name, ".") + ".")
+}
+
+func rrHeader(name string, typ dnsmessage.Type, ttl uint32) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: mustName(name), Type: typ, Class: dnsmessage.ClassINET, TTL: ttl}
+}
+
+func aRR(name, ip string, ttl uint32) dnsmessage.Resource {
+	parsed := net.ParseIP(ip)
+	if v4 := parsed.To4(); v4 != nil {
+		body := &dnsmessage.AResource{}
+		copy(body.A[:], v4)
+		return dnsmessage.Resource{Header: rrHeader(name, dnsmessage.TypeA, ttl), Body: body}
+	}
+	body := &dnsmessage.AAAAResource{}
+	copy(body.AAAA[:], parsed.To16())
+	return dnsmessage.Resource{Header: rrHeader(name, dnsmessage.TypeAAAA, ttl), Body: body}
+}
+
+func srvRR(name string, ttl uint32, priority, weight, port uint16, target string) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: rrHeader(name, dnsmessage.TypeSRV, ttl),
+		Body:   &dnsmessage.SRVResource{Priority: priority, Weight: weight, Port: port, Target: mustName(target)},
+	}
+}
+
+func nodeSet(t *testing.T, d *DNSDiscovery, serviceName string) map[string]int {
+	t.Helper()
+	nodes, err := d.List(serviceName)
+	if err != nil {
+		t.Fatalf("List(%s): %v", serviceName, err)
+	}
+	set := make(map[string]int)
+	for _, n := range nodes {
+		set[n.Address] = n.Weight
+	}
+	return set
+}
+
+func equalSet(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDNSDiscoverySRV(t *testing.T) {
+	s := startDNSStandIn(t, func(q *testQuery, tcp bool) []byte {
+		switch {
+		case q.name == "_sanrpc._tcp.example" && q.qtype == dnsmessage.TypeSRV:
+			return buildResp(q, dnsmessage.RCodeSuccess, false, []dnsmessage.Resource{
+				srvRR(q.name, 60, 10, 5, 8001, "a.example"),
+				srvRR(q.name, 60, 10, 1, 8002, "b.example"),
+				// 优先级低的一组不使用
+				srvRR(q.name, 60, 20, 9, 8003, "c.example"),
+			}, []dnsmessage.Resource{
+				aRR("a.example", "10.0.0.1", 60),
+			})
+		case q.name == "b.example" && q.qtype == dnsmessage.TypeA:
+			return buildResp(q, dnsmessage.RCodeSuccess, false, []dnsmessage.Resource{aRR(q.name, "10.0.0.2", 60)}, nil)
+		}
+		return buildResp(q, dnsmessage.RCodeSuccess, false, nil, nil)
+	})
+	defer s.Close()
+
+	d := NewDNSDiscovery(s.addr, 0)
+	want := map[string]int{"10.0.0.1:8001": 5, "10.0.0.2:8002": 1}
+	if got := nodeSet(t, d, "_sanrpc._tcp.example"); !equalSet(got, want) {
+		t.Fatalf("nodes = %v, want %v", got, want)
+	}
+}
+
+func TestDNSDiscoveryAddress(t *testing.T) {
+	s := startDNSStandIn(t, func(q *testQuery, tcp bool) []byte {
+		switch q.qtype {
+		case dnsmessage.TypeA:
+			return buildResp(q, dnsmessage.RCodeSuccess, false, []dnsmessage.Resource{aRR(q.name, "10.0.0.1", 60), aRR(q.name, "10.0.0.2", 60)}, nil)
+		case dnsmessage.TypeAAAA:
+			return buildResp(q, dnsmessage.RCodeSuccess, false, []dnsmessage.Resource{aRR(q.name, "fd00::1", 60)}, nil)
+		}
+		return buildResp(q, dnsmessage.RCodeSuccess, false, nil, nil)
+	})
+	defer s.Close()
+
+	d := NewDNSDiscovery(s.addr, 9000)
+	tests := []struct {
+		service string
+		want    map[string]int
+	}{
+		{"svc.example", map[string]int{"10.0.0.1:9000": 0, "10.0.0.2:9000": 0, "[fd00::1]:9000": 0}},
+		{"svc.example:7000", map[string]int{"10.0.0.1:7000": 0, "10.0.0.2:7000": 0, "[fd00::1]:7000": 0}},
+	}
+	for _, tt := range tests {
+		if got := nodeSet(t, d, tt.service); !equalSet(got, tt.want) {
+			t.Errorf("%s: nodes = %v, want %v", tt.service, got, tt.want)
+		}
+	}
+
+	if _, err := NewDNSDiscovery(s.addr, 0).List("svc.example"); err == nil {
+		t.Errorf("List without port: want error")
+	}
+}
+
+func TestDNSDiscoveryTTL(t *testing.T) {
+	var ip atomic.Value
+	ip.Store("10.0.0.1")
+	var ttl uint32 = 60
+	s := startDNSStandIn(t, func(q *testQuery, tcp bool) []byte {
+		if q.qtype != dnsmessage.TypeA {
+			return buildResp(q, dnsmessage.RCodeSuccess, false, nil, nil)
+		}
+		return buildResp(q, dnsmessage.RCodeSuccess, false, []dnsmessage.Resource{aRR(q.name, ip.Load().(string), atomic.LoadUint32(&ttl))}, nil)
+	})
+	defer s.Close()
+
+	// TTL 内的 List 使用缓存
+	d := NewDNSDiscovery(s.addr, 9000)
+	nodeSet(t, d, "cached.example")
+	n := atomic.LoadInt32(&s.queries)
+	nodeSet(t, d, "cached.example")
+	if got := atomic.LoadInt32(&s.queries); got != n {
+		t.Fatalf("List within TTL sent %d queries, want 0", got-n)
+	}
+
+	// TTL 到期后 Watch 重新解析并推送变更
+	atomic.StoreUint32(&ttl, 0)
+	d = NewDNSDiscovery(s.addr, 9000)
+	d.MinTTL = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := d.Watch(ctx, "watch.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip.Store("10.0.0.2")
+
+	var got []string
+	timeout := time.After(5 * time.Second)
+	for len(got) < 2 {
+		select {
+		case ev := <-ch:
+			got = append(got, map[EventType]string{EventAdd: "add ", EventDelete: "delete "}[ev.Type]+ev.Node.Address)
+		case <-timeout:
+			t.Fatalf("events = %v, want add and delete", got)
+		}
+	}
+	sort.Strings(got)
+	if want := []string{"add 10.0.0.2:9000", "delete 10.0.0.1:9000"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+}
+
+func TestDNSDiscoveryTruncated(t *testing.T) {
+	s := startDNSStandIn(t, func(q *testQuery, tcp bool) []byte {
+		if q.qtype != dnsmessage.TypeA {
+			return buildResp(q, dnsmessage.RCodeSuccess, false, nil, nil)
+		}
+		if !tcp {
+			return buildResp(q, dnsmessage.RCodeSuccess, true, nil, nil)
+		}
+		return buildResp(q, dnsmessage.RCodeSuccess, false, []dnsmessage.Resource{aRR(q.name, "10.0.0.1", 60)}, nil)
+	})
+	defer s.Close()
+
+	d := NewDNSDiscovery(s.addr, 9000)
+	if got, want := nodeSet(t, d, "big.example"), map[string]int{"10.0.0.1:9000": 0}; !equalSet(got, want) {
+		t.Fatalf("nodes = %v, want %v", got, want)
+	}
+	if atomic.LoadInt32(&s.tcpUsed) == 0 {
+		t.Fatalf("truncated response did not fall back to TCP")
+	}
+}
+
+func TestDNSDiscoveryMalformed(t *testing.T) {
+	// A 记录的名字压缩指向 question，位于 question 段之后
+	answer := func(q *testQuery) ([]byte, int) {
+		return buildResp(q, dnsmessage.RCodeSuccess, false, []dnsmessage.Resource{aRR(q.name, "10.0.0.1", 60)}, nil), len(q.raw)
+	}
+	tests := []struct {
+		name string
+		resp func(q *testQuery) []byte
+	}{
+		{"short header", func(q *testQuery) []byte { return q.raw[:6] }},
+		{"id mismatch", func(q *testQuery) []byte {
+			resp, _ := answer(q)
+			resp[0]++
+			return resp
+		}},
+		{"nxdomain", func(q *testQuery) []byte { return buildResp(q, dnsmessage.RCodeNameError, false, nil, nil) }},
+		{"rdata past end", func(q *testQuery) []byte {
+			resp, _ := answer(q)
+			return resp[:len(resp)-2]
+		}},
+		{"bad A length", func(q *testQuery) []byte {
+			resp, off := answer(q)
+			// name(2) type(2) class(2) ttl(4) rdlength(2)
+			binary.BigEndian.PutUint16(resp[off+10:], 3)
+			return resp[:len(resp)-1]
+		}},
+		{"compression loop", func(q *testQuery) []byte {
+			resp, off := answer(q)
+			resp[off], resp[off+1] = 0xc0|byte(off>>8), byte(off)
+			return resp
+		}},
+	}
+	for _, tt := range tests {
+		resp := tt.resp
+		s := startDNSStandIn(t, func(q *testQuery, tcp bool) []byte { return resp(q) })
+		d := NewDNSDiscovery(s.addr, 9000)
+		d.Timeout = time.Second
+		if nodes, err := d.List("bad.example"); err == nil {
+			t.Errorf("%s: List = %v, want error", tt.name, nodes)
+		}
+		s.Close()
+	}
+}
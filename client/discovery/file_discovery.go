@@ -0,0 +1,236 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/naming"
+)
+
+// DefaultFileCheckInterval 默认的文件变更检查间隔
+const DefaultFileCheckInterval = time.Second
+
+// FileConfig 节点列表文件格式，按扩展名使用 json 或 toml 解析
+type FileConfig struct {
+	Services []FileService `json:"services" toml:"services"`
+}
+
+// FileService 一个服务的节点列表
+type FileService struct {
+	Name  string     `json:"name" toml:"name"`
+	Nodes []FileNode `json:"nodes" toml:"nodes"`
+}
+
+// FileNode 一个节点
+type FileNode struct {
+	Address string            `json:"address" toml:"address"`
+	Network string            `json:"network" toml:"network"`
+	Weight  int               `json:"weight" toml:"weight"`
+	Zone    string            `json:"zone" toml:"zone"`
+	Tags    map[string]string `json:"tags" toml:"tags"`
+}
+
+// FileDiscovery 从 json/toml 文件读取服务节点列表，文件变化后自动重新加载并推送变更。
+// 文件内容不合法时保留上一次正确的节点列表。
+type FileDiscovery struct {
+	path string
+
+	mu       sync.RWMutex
+	services map[string][]*node.Node
+	changed  chan struct{} // 每次重新加载后关闭并替换
+
+	done chan struct{}
+	once sync.Once
+}
+
+// NewFileDiscovery 加载 path 并每隔 interval 检查一次文件变化
+func NewFileDiscovery(path string, interval time.Duration) (*FileDiscovery, error) {
+	if interval <= 0 {
+		interval = DefaultFileCheckInterval
+	}
+	d := &FileDiscovery{
+		path:    path,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	go d.check(fi.ModTime(), interval)
+	return d, nil
+}
+
+// RegisterFileDiscovery 创建 FileDiscovery 并以 "file" 注册
+func RegisterFileDiscovery(path string, interval time.Duration) (*FileDiscovery, error) {
+	d, err := NewFileDiscovery(path, interval)
+	if err != nil {
+		return nil, err
+	}
+	Register(d.Name(), d)
+	return d, nil
+}
+
+func (d *FileDiscovery) check(modTime time.Time, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+		}
+		fi, err := os.Stat(d.path)
+		if err != nil || fi.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = fi.ModTime()
+		if err := d.load(); err != nil {
+			log.Errorf("discovery: reload %s fail, keep last nodes: %v", d.path, err)
+		}
+	}
+}
+
+func (d *FileDiscovery) load() error {
+	data, err := ioutil.ReadFile(d.path)
+	if err != nil {
+		return err
+	}
+	c := &FileConfig{}
+	switch filepath.Ext(d.path) {
+	case ".json":
+		err = json.Unmarshal(data, c)
+	case ".toml":
+		_, err = toml.Decode(string(data), c)
+	default:
+		err = fmt.Errorf("unknown file type %s", filepath.Ext(d.path))
+	}
+	if err != nil {
+		return err
+	}
+	services, err := parseFileConfig(c)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.services = services
+	close(d.changed)
+	d.changed = make(chan struct{})
+	d.mu.Unlock()
+	log.Infof("discovery: load %s success, %d services", d.path, len(services))
+	return nil
+}
+
+func parseFileConfig(c *FileConfig) (map[string][]*node.Node, error) {
+	services := make(map[string][]*node.Node, len(c.Services))
+	for _, s := range c.Services {
+		if s.Name == "" {
+			return nil, errors.New("service name empty")
+		}
+		if _, ok := services[s.Name]; ok {
+			return nil, fmt.Errorf("service %s duplicated", s.Name)
+		}
+		nodes := make([]*node.Node, 0, len(s.Nodes))
+		addrs := make(map[string]bool, len(s.Nodes))
+		for _, n := range s.Nodes {
+			if n.Address == "" {
+				return nil, fmt.Errorf("service %s has node without address", s.Name)
+			}
+			if addrs[n.Address] {
+				return nil, fmt.Errorf("service %s node %s duplicated", s.Name, n.Address)
+			}
+			if n.Weight < 0 {
+				return nil, fmt.Errorf("service %s node %s weight %d invalid", s.Name, n.Address, n.Weight)
+			}
+			addrs[n.Address] = true
+
+			md := make(map[string]string, len(n.Tags)+1)
+			for k, v := range n.Tags {
+				md[k] = v
+			}
+			if n.Zone != "" {
+				md[naming.MetaZone] = n.Zone
+			}
+			nodes = append(nodes, &node.Node{
+				ServiceName: s.Name,
+				Network:     n.Network,
+				Address:     n.Address,
+				Weight:      n.Weight,
+				Metadata:    md,
+			})
+		}
+		services[s.Name] = nodes
+	}
+	return services, nil
+}
+
+// List 返回服务当前的节点列表
+func (d *FileDiscovery) List(serviceName string) ([]*node.Node, error) {
+	d.mu.RLock()
+	nodes, ok := d.services[serviceName]
+	d.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("discovery: service %s not found in %s", serviceName, d.path)
+	}
+	return nodes, nil
+}
+
+// Watch 推送文件重新加载后服务节点的变更
+func (d *FileDiscovery) Watch(ctx context.Context, serviceName string) (<-chan *Event, error) {
+	d.mu.RLock()
+	last := d.services[serviceName]
+	changed := d.changed
+	d.mu.RUnlock()
+
+	ch := make(chan *Event, 16)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.done:
+				return
+			case <-changed:
+			}
+
+			d.mu.RLock()
+			cur := d.services[serviceName]
+			changed = d.changed
+			d.mu.RUnlock()
+
+			for _, ev := range Diff(last, cur) {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = cur
+		}
+	}()
+	return ch, nil
+}
+
+func (d *FileDiscovery) Name() string {
+	return "file"
+}
+
+// Close 停止检查文件，结束所有 Watch
+func (d *FileDiscovery) Close() {
+	d.once.Do(func() { close(d.done) })
+}
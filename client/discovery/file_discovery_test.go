@@ -0,0 +1,186 @@
+package discovery
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/naming"
+)
+
+const fileJSON = `{"services": [
+	{"name": "greeter", "nodes": [
+		{"address": "127.0.0.1:8000", "weight": 2, "zone": "z1", "tags": {"version": "v1"}},
+		{"address": "127.0.0.1:8001", "network": "tcp"}
+	]},
+	{"name": "echo", "nodes": [{"address": "127.0.0.1:9000"}]}
+]}`
+
+const fileTOML = `
+[[services]]
+name = "greeter"
+  [[services.nodes]]
+  address = "127.0.0.1:8000"
+  weight = 2
+  zone = "z1"
+  [services.nodes.tags]
+  version = "v1"
+  [[services.nodes]]
+  address = "127.0.0.1:8001"
+  network = "tcp"
+
+[[services]]
+name = "echo"
+  [[services.nodes]]
+  address = "127.0.0.1:9000"
+`
+
+func writeFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "file_discovery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestFileDiscoveryLoad(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{"nodes.json": fileJSON, "nodes.toml": fileTOML} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			writeFile(t, path, content, time.Now())
+			d, err := NewFileDiscovery(path, time.Hour)
+			if err != nil {
+				t.Fatalf("NewFileDiscovery() error = %v", err)
+			}
+			defer d.Close()
+
+			nodes, err := d.List("greeter")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if addrs(nodes) != "127.0.0.1:8000,127.0.0.1:8001" {
+				t.Fatalf("List() = %s", addrs(nodes))
+			}
+			n := nodes[0]
+			if n.ServiceName != "greeter" || n.Weight != 2 || n.Meta(naming.MetaZone) != "z1" || n.Meta("version") != "v1" {
+				t.Errorf("node = %+v", n)
+			}
+			if nodes[1].Network != "tcp" {
+				t.Errorf("network = %q, want tcp", nodes[1].Network)
+			}
+			if _, err := d.List("missing"); err == nil {
+				t.Errorf("List() of a missing service succeeded")
+			}
+		})
+	}
+}
+
+func TestFileDiscoveryInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "syntax", file: "a.json", content: `{"services": [`},
+		{name: "extension", file: "a.yaml", content: `services: []`},
+		{name: "no service name", file: "a.json", content: `{"services": [{"nodes": []}]}`},
+		{name: "duplicated service", file: "a.json", content: `{"services": [{"name": "s"}, {"name": "s"}]}`},
+		{name: "no address", file: "a.json", content: `{"services": [{"name": "s", "nodes": [{"weight": 1}]}]}`},
+		{name: "duplicated node", file: "a.json", content: `{"services": [{"name": "s", "nodes": [{"address": "a"}, {"address": "a"}]}]}`},
+		{name: "negative weight", file: "a.json", content: `{"services": [{"name": "s", "nodes": [{"address": "a", "weight": -1}]}]}`},
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			writeFile(t, path, tt.content, time.Now())
+			if d, err := NewFileDiscovery(path, time.Hour); err == nil {
+				d.Close()
+				t.Fatalf("NewFileDiscovery() succeeded")
+			}
+		})
+	}
+	if _, err := NewFileDiscovery(filepath.Join(dir, "missing.json"), time.Hour); err == nil {
+		t.Errorf("NewFileDiscovery() of a missing file succeeded")
+	}
+}
+
+func TestFileDiscoveryReload(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nodes.json")
+	start := time.Now().Add(-time.Hour)
+	writeFile(t, path, fileJSON, start)
+
+	d, err := NewFileDiscovery(path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewFileDiscovery() error = %v", err)
+	}
+	defer d.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := d.Watch(ctx, "greeter")
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// 8000 删除，8001 权重变化，新增 8002；echo 的变化不推送给 greeter
+	writeFile(t, path, `{"services": [
+		{"name": "greeter", "nodes": [{"address": "127.0.0.1:8001", "weight": 3}, {"address": "127.0.0.1:8002"}]},
+		{"name": "echo", "nodes": []}
+	]}`, start.Add(time.Minute))
+
+	got := make(map[string]EventType)
+	for len(got) < 3 {
+		select {
+		case ev := <-ch:
+			got[ev.Node.Address] = ev.Type
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for events, got %v", got)
+		}
+	}
+	want := map[string]EventType{"127.0.0.1:8000": EventDelete, "127.0.0.1:8001": EventUpdate, "127.0.0.1:8002": EventAdd}
+	for addr, typ := range want {
+		if got[addr] != typ {
+			t.Errorf("event of %s = %v, want %v", addr, got[addr], typ)
+		}
+	}
+	if nodes, _ := d.List("greeter"); addrs(nodes) != "127.0.0.1:8001,127.0.0.1:8002" {
+		t.Errorf("List() after reload = %s", addrs(nodes))
+	}
+
+	// 内容不合法时保留上一次的节点
+	writeFile(t, path, `{"services": [`, start.Add(2*time.Minute))
+	time.Sleep(30 * time.Millisecond)
+	if nodes, _ := d.List("greeter"); addrs(nodes) != "127.0.0.1:8001,127.0.0.1:8002" {
+		t.Errorf("List() after an invalid reload = %s", addrs(nodes))
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		for ok {
+			_, ok = <-ch
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Watch channel not closed after ctx done")
+	}
+}
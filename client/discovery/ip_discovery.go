@@ -13,7 +13,8 @@ func init() {
 // IPDiscovery  ip列表服务发现
 type IPDiscovery struct{}
 
-// List 返回原始ipport
+// List 返回原始ipport。节点的 ServiceName 为传入的地址列表，
+// 按服务名保存状态的 selector 和 router 因此不会把不同的 ip 服务混在一起
 func (*IPDiscovery) List(serviceName string) ([]*node.Node, error) {
 	ips := strings.Split(serviceName,",")
 	if len(ips) ==0 {
@@ -21,7 +22,7 @@ func (*IPDiscovery) List(serviceName string) ([]*node.Node, error) {
 	}
 	nodes := make([]*node.Node,0,len(ips))
 	for _,ip := range ips {
-		nodes = append(nodes, &node.Node{Address: ip})
+		nodes = append(nodes, &node.Node{ServiceName: serviceName, Address: ip})
 	}
 	return nodes, nil
 }
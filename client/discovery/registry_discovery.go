@@ -0,0 +1,116 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/naming/registry"
+)
+
+// DefaultRegistryPollInterval 默认的注册中心轮询间隔
+const DefaultRegistryPollInterval = 5 * time.Second
+
+// RegistryDiscovery 从 registry.NewHTTPHandler 提供的 http 注册中心读取服务实例，
+// Watch 每隔 Interval 轮询一次并推送变更
+type RegistryDiscovery struct {
+	Addr     string // 注册中心地址 http://host:port
+	Interval time.Duration
+	Client   *http.Client
+}
+
+// NewRegistryDiscovery 创建 RegistryDiscovery
+func NewRegistryDiscovery(addr string) *RegistryDiscovery {
+	return &RegistryDiscovery{
+		Addr:     strings.TrimSuffix(addr, "/"),
+		Interval: DefaultRegistryPollInterval,
+		Client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// RegisterRegistryDiscovery 创建 RegistryDiscovery 并以 "registry" 注册
+func RegisterRegistryDiscovery(addr string) *RegistryDiscovery {
+	d := NewRegistryDiscovery(addr)
+	Register(d.Name(), d)
+	return d
+}
+
+func (d *RegistryDiscovery) List(serviceName string) ([]*node.Node, error) {
+	resp, err := d.Client.Get(d.Addr + registry.HTTPPathPrefix + url.PathEscape(serviceName))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery: list %s from registry: %s", serviceName, resp.Status)
+	}
+	var insts []*registry.Instance
+	if err := json.NewDecoder(resp.Body).Decode(&insts); err != nil {
+		return nil, err
+	}
+	return InstancesToNodes(insts), nil
+}
+
+func (d *RegistryDiscovery) Watch(ctx context.Context, serviceName string) (<-chan *Event, error) {
+	last, err := d.List(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultRegistryPollInterval
+	}
+
+	ch := make(chan *Event, 16)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			cur, err := d.List(serviceName)
+			if err != nil {
+				log.Errorf("discovery: poll registry for %s fail, keep last nodes: %v", serviceName, err)
+				continue
+			}
+			for _, ev := range Diff(last, cur) {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = cur
+		}
+	}()
+	return ch, nil
+}
+
+func (d *RegistryDiscovery) Name() string {
+	return "registry"
+}
+
+// InstancesToNodes 把注册中心的实例转换为节点
+func InstancesToNodes(insts []*registry.Instance) []*node.Node {
+	nodes := make([]*node.Node, 0, len(insts))
+	for _, inst := range insts {
+		nodes = append(nodes, &node.Node{
+			ServiceName: inst.ServiceName,
+			Network:     inst.Network,
+			Address:     inst.Address,
+			Weight:      inst.Weight,
+			Metadata:    inst.Metadata,
+		})
+	}
+	return nodes
+}
@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/hillguo/sanrpc/client/node"
+)
+
+// EventType 节点变更类型
+type EventType int
+
+const (
+	// EventAdd 新增节点
+	EventAdd EventType = iota
+	// EventUpdate 节点权重、元数据等变化
+	EventUpdate
+	// EventDelete 删除节点
+	EventDelete
+)
+
+// Event 一个节点的增量变更，节点以 Address 区分
+type Event struct {
+	Type EventType
+	Node *node.Node
+}
+
+// Watcher discovery 可选实现的接口，推送服务节点的增量变更。
+// ctx 结束后实现方需要停止推送并关闭返回的 channel。
+type Watcher interface {
+	Watch(ctx context.Context, serviceName string) (<-chan *Event, error)
+}
+
+// Diff 计算两个节点列表之间的增量变更，节点以 Address 区分
+func Diff(old, cur []*node.Node) []*Event {
+	olds := make(map[string]*node.Node, len(old))
+	for _, n := range old {
+		olds[n.Address] = n
+	}
+
+	var events []*Event
+	for _, n := range cur {
+		o, ok := olds[n.Address]
+		delete(olds, n.Address)
+		switch {
+		case !ok:
+			events = append(events, &Event{Type: EventAdd, Node: n})
+		case !sameNode(o, n):
+			events = append(events, &Event{Type: EventUpdate, Node: n})
+		}
+	}
+	for _, n := range old {
+		if _, ok := olds[n.Address]; ok {
+			events = append(events, &Event{Type: EventDelete, Node: n})
+		}
+	}
+	return events
+}
+
+func sameNode(a, b *node.Node) bool {
+	if a.Network != b.Network || a.Weight != b.Weight || len(a.Metadata) != len(b.Metadata) {
+		return false
+	}
+	for k, v := range a.Metadata {
+		if bv, ok := b.Metadata[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
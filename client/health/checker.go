@@ -0,0 +1,212 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/errs"
+)
+
+// Checker 客户端节点健康检查，同时作为 router 使用。
+// 节点的调用结果（Report）和主动探测结果都计入连续失败次数，
+// 达到 FailureThreshold 后摘除节点，摘除时长从 BaseEjection 起每次翻倍直到 MaxEjection。
+// 摘除的节点数不超过 MaxEjectionPercent，节点全部不健康时不做过滤。
+type Checker struct {
+	opts *Options
+
+	mu    sync.Mutex
+	nodes map[string]*state // 服务名/address => state，同一地址上的不同服务分别统计
+
+	done chan struct{}
+	once sync.Once
+}
+
+// state 单个节点的健康状态
+type state struct {
+	node      *node.Node
+	seen      time.Time // 最近一次出现在 Filter 中的时间
+	failures  int       // 连续失败次数
+	ejections int       // 连续被摘除的次数，决定下次摘除时长
+	until     time.Time // 摘除到期时间
+}
+
+func (st *state) ejected(now time.Time) bool {
+	return now.Before(st.until)
+}
+
+// NewChecker 创建 Checker，Interval>0 时启动主动探测
+func NewChecker(opt ...Option) *Checker {
+	opts := &Options{
+		Interval:           DefaultInterval,
+		Timeout:            DefaultTimeout,
+		Prober:             DialProber,
+		FailureThreshold:   DefaultFailureThreshold,
+		BaseEjection:       DefaultBaseEjection,
+		MaxEjection:        DefaultMaxEjection,
+		MaxEjectionPercent: DefaultMaxEjectionPercent,
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+	c := &Checker{
+		opts:  opts,
+		nodes: make(map[string]*state),
+		done:  make(chan struct{}),
+	}
+	if opts.Interval > 0 && opts.Prober != nil {
+		go c.probeLoop()
+	}
+	return c
+}
+
+// Filter 去掉被摘除的节点。摘除比例超过 MaxEjectionPercent 时按摘除到期先后放回部分节点
+func (c *Checker) Filter(ctx context.Context, list []*node.Node) ([]*node.Node, error) {
+	now := time.Now()
+	healthy := make([]*node.Node, 0, len(list))
+	var ejected []*state
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, n := range list {
+		st := c.state(n)
+		st.node = n
+		st.seen = now
+		if st.ejected(now) {
+			ejected = append(ejected, st)
+			continue
+		}
+		healthy = append(healthy, n)
+	}
+
+	if maxEjected := len(list) * c.opts.MaxEjectionPercent / 100; len(ejected) > maxEjected {
+		sort.Slice(ejected, func(i, j int) bool { return ejected[i].until.Before(ejected[j].until) })
+		for _, st := range ejected[:len(ejected)-maxEjected] {
+			healthy = append(healthy, st.node)
+		}
+	}
+	if len(healthy) == 0 {
+		return list, nil
+	}
+	return healthy, nil
+}
+
+func (c *Checker) Name() string {
+	return "health"
+}
+
+// Healthy 节点当前是否未被摘除。与 client.WithSetRouter 一起使用时作为 router.SetRouter 的 Healthy
+func (c *Checker) Healthy(n *node.Node) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.nodes[stateKey(n)]
+	return !ok || !st.ejected(time.Now())
+}
+
+// Report 回报一次真实调用的结果，业务错误说明节点可以正常处理请求，不计为失败
+func (c *Checker) Report(n *node.Node, cost time.Duration, err error) {
+	if e, ok := errs.FromError(err); ok && e != nil && e.Type == errs.ErrorTypeBusiness {
+		err = nil
+	}
+	c.record(n, err)
+}
+
+// Remove 服务发现删除节点时清除节点的健康状态
+func (c *Checker) Remove(n *node.Node) {
+	c.mu.Lock()
+	delete(c.nodes, stateKey(n))
+	c.mu.Unlock()
+}
+
+// Close 停止主动探测
+func (c *Checker) Close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// state 调用方需持有 c.mu
+func (c *Checker) state(n *node.Node) *state {
+	key := stateKey(n)
+	st, ok := c.nodes[key]
+	if !ok {
+		st = &state{node: n, seen: time.Now()}
+		c.nodes[key] = st
+	}
+	return st
+}
+
+func stateKey(n *node.Node) string {
+	return n.ServiceName + "/" + n.Address
+}
+
+func (c *Checker) record(n *node.Node, err error) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.state(n)
+	if err == nil {
+		st.failures = 0
+		// 成功说明节点已恢复，立即放回，下次摘除时长减半
+		if st.ejected(now) {
+			log.Infof("health: %s %s recovered", n.ServiceName, n.Address)
+		}
+		st.until = time.Time{}
+		if st.ejections > 0 {
+			st.ejections--
+		}
+		return
+	}
+
+	st.failures++
+	if st.failures < c.opts.FailureThreshold || st.ejected(now) {
+		return
+	}
+	st.failures = 0
+	backoff := c.opts.BaseEjection << uint(st.ejections)
+	if backoff <= 0 || backoff > c.opts.MaxEjection {
+		backoff = c.opts.MaxEjection
+	} else {
+		st.ejections++
+	}
+	st.until = now.Add(backoff)
+	log.Warnf("health: eject %s %s for %v: %v", n.ServiceName, n.Address, backoff, err)
+}
+
+// probeLoop 每隔 Interval 探测一次最近出现过的节点，长时间未出现的节点不再跟踪
+func (c *Checker) probeLoop() {
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		stale := time.Now().Add(-10 * c.opts.Interval)
+		var nodes []*node.Node
+		c.mu.Lock()
+		for key, st := range c.nodes {
+			if st.seen.Before(stale) && !st.ejected(time.Now()) {
+				delete(c.nodes, key)
+				continue
+			}
+			nodes = append(nodes, st.node)
+		}
+		c.mu.Unlock()
+
+		var wg sync.WaitGroup
+		for _, n := range nodes {
+			wg.Add(1)
+			go func(n *node.Node) {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+				defer cancel()
+				c.record(n, c.opts.Prober.Probe(ctx, n))
+			}(n)
+		}
+		wg.Wait()
+	}
+}
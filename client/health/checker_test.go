@@ -0,0 +1,170 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/errs"
+)
+
+func newNodes(service string, n int) []*node.Node {
+	list := make([]*node.Node, n)
+	for i := range list {
+		list[i] = &node.Node{ServiceName: service, Network: "tcp", Address: "127.0.0.1:" + strconv.Itoa(8000+i)}
+	}
+	return list
+}
+
+func addrs(nodes []*node.Node) map[string]bool {
+	m := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		m[n.Address] = true
+	}
+	return m
+}
+
+func TestCheckerReport(t *testing.T) {
+	errFail := errors.New("fail")
+	tests := []struct {
+		name    string
+		reports []error
+		healthy bool
+	}{
+		{name: "below threshold", reports: []error{errFail, errFail}, healthy: true},
+		{name: "threshold", reports: []error{errFail, errFail, errFail}, healthy: false},
+		{name: "success resets failures", reports: []error{errFail, errFail, nil, errFail, errFail}, healthy: true},
+		{name: "recovered", reports: []error{errFail, errFail, errFail, nil}, healthy: true},
+		{name: "business errors", reports: []error{errs.New(1, "a"), errs.New(1, "b"), errs.New(1, "c")}, healthy: true},
+		{name: "framework errors", reports: []error{errs.ErrServerTimeout, errs.ErrServerTimeout, errs.ErrServerTimeout}, healthy: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(WithInterval(0), WithFailureThreshold(3), WithMaxEjectionPercent(100))
+			defer c.Close()
+			list := newNodes("svc", 2)
+			for _, err := range tt.reports {
+				c.Report(list[0], time.Millisecond, err)
+			}
+			if got := c.Healthy(list[0]); got != tt.healthy {
+				t.Errorf("Healthy() = %v, want %v", got, tt.healthy)
+			}
+			got, _ := c.Filter(context.Background(), list)
+			if addrs(got)[list[0].Address] != tt.healthy || !addrs(got)[list[1].Address] {
+				t.Errorf("Filter() = %v", addrs(got))
+			}
+		})
+	}
+}
+
+func TestCheckerEjectionBackoff(t *testing.T) {
+	c := NewChecker(WithInterval(0), WithFailureThreshold(1), WithEjection(20*time.Millisecond, time.Hour))
+	defer c.Close()
+	n := newNodes("svc", 1)[0]
+
+	c.Report(n, 0, errors.New("fail"))
+	if c.Healthy(n) {
+		t.Fatalf("node not ejected")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !c.Healthy(n) {
+		t.Fatalf("node still ejected after the first ejection expired")
+	}
+	// 第二次摘除时长翻倍
+	c.Report(n, 0, errors.New("fail"))
+	time.Sleep(30 * time.Millisecond)
+	if c.Healthy(n) {
+		t.Errorf("second ejection not doubled")
+	}
+}
+
+func TestCheckerMaxEjectionPercent(t *testing.T) {
+	tests := []struct {
+		percent int
+		failing int
+		want    int // Filter 返回的节点数
+	}{
+		{percent: 50, failing: 1, want: 3},
+		{percent: 50, failing: 2, want: 2},
+		{percent: 50, failing: 3, want: 2},
+		{percent: 0, failing: 2, want: 4},
+		{percent: 100, failing: 4, want: 4}, // 全部不健康时不过滤
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.percent)+"/"+strconv.Itoa(tt.failing), func(t *testing.T) {
+			c := NewChecker(WithInterval(0), WithFailureThreshold(1), WithMaxEjectionPercent(tt.percent))
+			defer c.Close()
+			list := newNodes("svc", 4)
+			for _, n := range list[:tt.failing] {
+				c.Report(n, 0, errors.New("fail"))
+			}
+			if got, _ := c.Filter(context.Background(), list); len(got) != tt.want {
+				t.Errorf("Filter() returned %d nodes, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckerKeyedByService(t *testing.T) {
+	c := NewChecker(WithInterval(0), WithFailureThreshold(1))
+	defer c.Close()
+	a := &node.Node{ServiceName: "a", Address: "127.0.0.1:8000"}
+	b := &node.Node{ServiceName: "b", Address: "127.0.0.1:8000"}
+
+	c.Report(a, 0, errors.New("fail"))
+	if c.Healthy(a) {
+		t.Errorf("failing service a not ejected")
+	}
+	if !c.Healthy(b) {
+		t.Errorf("service b on the same address ejected with a")
+	}
+
+	c.Remove(a)
+	if !c.Healthy(a) {
+		t.Errorf("state of removed node kept")
+	}
+}
+
+func TestCheckerProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	// http 服务注册的节点 Network 为 "http"，探测时要按 tcp 连接
+	up := &node.Node{ServiceName: "svc", Network: "http", Address: ln.Addr().String()}
+	down := &node.Node{ServiceName: "svc", Network: "http", Address: closed.Addr().String()}
+	c := NewChecker(WithInterval(5*time.Millisecond), WithFailureThreshold(2), WithMaxEjectionPercent(100))
+	defer c.Close()
+	c.Filter(context.Background(), []*node.Node{up, down})
+
+	deadline := time.Now().Add(time.Second)
+	for c.Healthy(down) {
+		if time.Now().After(deadline) {
+			t.Fatalf("unreachable node not ejected by probes")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !c.Healthy(up) {
+		t.Errorf("reachable http node ejected by probes")
+	}
+}
+
+func TestDialNetwork(t *testing.T) {
+	tests := map[string]string{"": "tcp", "tcp": "tcp", "http": "tcp", "https": "tcp", "tcp4": "tcp4", "unix": "unix"}
+	for network, want := range tests {
+		if got := dialNetwork(&node.Node{Network: network}); got != want {
+			t.Errorf("dialNetwork(%q) = %q, want %q", network, got, want)
+		}
+	}
+}
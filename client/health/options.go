@@ -0,0 +1,69 @@
+package health
+
+import "time"
+
+const (
+	// DefaultInterval 默认的主动探测间隔
+	DefaultInterval = 5 * time.Second
+	// DefaultTimeout 默认的单次探测超时
+	DefaultTimeout = time.Second
+	// DefaultFailureThreshold 默认连续失败多少次后摘除节点
+	DefaultFailureThreshold = 5
+	// DefaultBaseEjection 默认的首次摘除时长，之后每次摘除翻倍
+	DefaultBaseEjection = 10 * time.Second
+	// DefaultMaxEjection 默认的最长摘除时长
+	DefaultMaxEjection = 5 * time.Minute
+	// DefaultMaxEjectionPercent 默认最多摘除节点的百分比
+	DefaultMaxEjectionPercent = 50
+)
+
+// Options 健康检查参数
+type Options struct {
+	Interval           time.Duration // 主动探测间隔，<=0 时不主动探测
+	Timeout            time.Duration // 单次探测超时
+	Prober             Prober        // 主动探测方式
+	FailureThreshold   int           // 连续失败多少次后摘除节点
+	BaseEjection       time.Duration // 首次摘除时长
+	MaxEjection        time.Duration // 最长摘除时长
+	MaxEjectionPercent int           // 最多摘除节点的百分比，[0, 100]
+}
+
+// Option 健康检查参数工具函数
+type Option func(*Options)
+
+func WithInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = interval
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+func WithProber(p Prober) Option {
+	return func(o *Options) {
+		o.Prober = p
+	}
+}
+
+func WithFailureThreshold(n int) Option {
+	return func(o *Options) {
+		o.FailureThreshold = n
+	}
+}
+
+func WithEjection(base, max time.Duration) Option {
+	return func(o *Options) {
+		o.BaseEjection = base
+		o.MaxEjection = max
+	}
+}
+
+func WithMaxEjectionPercent(percent int) Option {
+	return func(o *Options) {
+		o.MaxEjectionPercent = percent
+	}
+}
@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"net"
+
+	"github.com/hillguo/sanrpc/client/node"
+)
+
+// Prober 主动探测一个节点，返回 nil 表示健康
+type Prober interface {
+	Probe(ctx context.Context, n *node.Node) error
+}
+
+// ProberFunc 把函数适配为 Prober
+type ProberFunc func(ctx context.Context, n *node.Node) error
+
+func (f ProberFunc) Probe(ctx context.Context, n *node.Node) error {
+	return f(ctx, n)
+}
+
+// DialProber 能建立连接即认为健康
+var DialProber = ProberFunc(func(ctx context.Context, n *node.Node) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, dialNetwork(n), n.Address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+})
+
+// dialNetwork 返回连接节点使用的网络。
+// http 服务注册的 Network 是 "http"，它们同样监听在 tcp 上
+func dialNetwork(n *node.Node) string {
+	switch n.Network {
+	case "", "http", "https":
+		return "tcp"
+	}
+	return n.Network
+}
+
+// isHTTP 节点是否是 http 服务，这类节点不支持 sanrpc 协议的调用
+func isHTTP(n *node.Node) bool {
+	return n.Network == "http" || n.Network == "https"
+}
@@ -0,0 +1,44 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hillguo/sanrpc/client"
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/service/healthpb"
+)
+
+// RPCProber 调用节点上的 health.Check，返回 SERVING 才认为健康。
+// service 为空时检查整个进程。health.Check 只能通过 sanrpc 协议调用，http 服务的节点改用 DialProber
+func RPCProber(service string) Prober {
+	return ProberFunc(func(ctx context.Context, n *node.Node) error {
+		if isHTTP(n) {
+			return DialProber.Probe(ctx, n)
+		}
+		timeout := DefaultTimeout
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		c := client.NewClient(
+			client.WithNetwork(dialNetwork(n)),
+			client.WithAddress(n.Address),
+			client.WithServiceName("health"),
+			client.WithMethodName("check"),
+			client.WithConnectTimeout(uint64(timeout)),
+			client.WithWriteTimeout(uint64(timeout)),
+			client.WithReadTimeout(uint64(timeout)),
+		)
+		defer c.Close()
+
+		resp := &healthpb.CheckResp{}
+		if err := c.Invoke(ctx, &healthpb.CheckReq{Service: service}, resp); err != nil {
+			return err
+		}
+		if resp.GetStatus() != healthpb.ServingStatus_SERVING {
+			return fmt.Errorf("health: %s %s is %s", n.Address, service, resp.GetStatus())
+		}
+		return nil
+	})
+}
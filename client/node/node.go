@@ -1,7 +1,17 @@
 package node
 
 type Node struct {
-	ServiceName   string        // 服务名
-	Network       string
-	Address       string        // 目标地址 ip:port
+	ServiceName string // 服务名
+	Network     string
+	Address     string            // 目标地址 ip:port
+	Weight      int               // 权重，<=0 时按 1 处理
+	Metadata    map[string]string // 节点元数据
+}
+
+// Meta 返回元数据 key 对应的值
+func (n *Node) Meta(key string) string {
+	if n == nil || n.Metadata == nil {
+		return ""
+	}
+	return n.Metadata[key]
 }
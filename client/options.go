@@ -1,5 +1,13 @@
 package client
 
+import (
+	"context"
+	"time"
+
+	"github.com/hillguo/sanrpc/client/router"
+	"github.com/hillguo/sanrpc/codec"
+)
+
 // Options 客户端调用参数
 type Options struct {
 	ServiceName string // 调用服务名
@@ -10,10 +18,19 @@ type Options struct {
 	Discovery string
 	Selector string
 	Address string
+	Routers router.Chain // selector 之前执行的路由规则
+	// ConfigFile 客户端配置文件，其中的 routes 规则会加载为 RuleRouter 并在文件变化时热更新
+	ConfigFile string
+	SerializeType codec.SerializeType // 请求编码，默认 protobuf，可以通过 SerializeTypeContext 按次指定
 
 	ConnectTimeout uint64
 	ReadTimeout    uint64
 	WriteTimeout   uint64
+
+	// MaxRetries 可重试错误（见 errs.IsRetryable）的最大重试次数，默认不重试
+	MaxRetries int
+	// RetryBackoff 第一次重试前的等待时间，之后每次翻倍
+	RetryBackoff time.Duration
 }
 
 // Option 调用参数工具函数
@@ -71,4 +88,55 @@ func WithReadTimeout(ReadTimeout uint64) Option{
 	return func(o *Options){
 		o.ReadTimeout = ReadTimeout
 	}
-}
\ No newline at end of file
+}
+func WithRouters(routers ...router.Router) Option{
+	return func(o *Options){
+		o.Routers = append(o.Routers, routers...)
+	}
+}
+
+// WithSetRouter 按调用方的 namespace/env/set/zone 就近路由（见 router.SetRouter）。
+// Routers 中有 health.Checker 等提供 Healthy 的路由时，用它判断节点是否健康
+func WithSetRouter(namespace, envName, setName, zone string) Option{
+	return func(o *Options){
+		o.Routers = append(o.Routers, router.NewSetRouter(namespace, envName, setName, zone))
+	}
+}
+
+func WithConfigFile(path string) Option{
+	return func(o *Options){
+		o.ConfigFile = path
+	}
+}
+
+func WithSerializeType(t codec.SerializeType) Option{
+	return func(o *Options){
+		o.SerializeType = t
+	}
+}
+
+func WithMaxRetries(n int) Option{
+	return func(o *Options){
+		o.MaxRetries = n
+	}
+}
+
+func WithRetryBackoff(d time.Duration) Option{
+	return func(o *Options){
+		o.RetryBackoff = d
+	}
+}
+
+type serializeTypeCtxKey struct{}
+
+// SerializeTypeContext 指定本次调用的请求编码，优先于 WithSerializeType。
+// codec.SerializeNone 时请求为 []byte，响应为 *[]byte。
+func SerializeTypeContext(ctx context.Context, t codec.SerializeType) context.Context {
+	return context.WithValue(ctx, serializeTypeCtxKey{}, t)
+}
+
+// SerializeTypeFromContext 获取本次调用指定的请求编码
+func SerializeTypeFromContext(ctx context.Context) (codec.SerializeType, bool) {
+	t, ok := ctx.Value(serializeTypeCtxKey{}).(codec.SerializeType)
+	return t, ok
+}
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hillguo/sanrpc/client/node"
+)
+
+// Router 路由组件接口，在 selector 之前执行，过滤出本次调用可选的节点
+type Router interface {
+	Filter(ctx context.Context, list []*node.Node) ([]*node.Node, error)
+	Name() string
+}
+
+// ErrNoNodeMatched 过滤后没有可选节点
+var ErrNoNodeMatched = errors.New("router: no node matched")
+
+// Chain 依次执行的一组 Router
+type Chain []Router
+
+// Filter 依次执行每个 Router
+func (c Chain) Filter(ctx context.Context, list []*node.Node) ([]*node.Node, error) {
+	var err error
+	for _, r := range c {
+		list, err = r.Filter(ctx, list)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,216 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/config"
+	"github.com/hillguo/sanrpc/metadata"
+	"github.com/valyala/fastrand"
+)
+
+// DefaultRuleCheckInterval 默认的规则文件变更检查间隔
+const DefaultRuleCheckInterval = time.Second
+
+// RuleRouter 按请求元数据把流量路由到打了标签的节点，用于灰度和按用户分流。
+// 规则按顺序匹配：命中规则且落入 Percent 的请求发往匹配 Labels 的节点，
+// 没有落入比例或没有匹配 Labels 的节点时继续尝试下一条命中的规则；
+// 没有规则生效的请求只发往不匹配任何规则 Labels 的节点。
+// 规则可以通过 Update、LoadFile 或 WatchFile 热更新。
+type RuleRouter struct {
+	rules atomic.Value // []config.RouteConfig
+
+	mu      sync.Mutex
+	modTime map[string]time.Time // path => LoadFile 读取时文件的修改时间
+}
+
+// NewRuleRouter 创建 RuleRouter
+func NewRuleRouter(rules []config.RouteConfig) (*RuleRouter, error) {
+	r := &RuleRouter{}
+	if err := r.Update(rules); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update 替换全部规则，规则不合法时保留原规则
+func (r *RuleRouter) Update(rules []config.RouteConfig) error {
+	for _, rule := range rules {
+		// 配置文件中省略 percent 时为 0，规则永远不会生效，按配置错误处理
+		if rule.Percent <= 0 || rule.Percent > 100 {
+			return fmt.Errorf("router: rule %s percent %d out of range (0, 100]", rule.Name, rule.Percent)
+		}
+		if len(rule.Labels) == 0 {
+			return fmt.Errorf("router: rule %s has no labels", rule.Name)
+		}
+	}
+	r.rules.Store(rules)
+	return nil
+}
+
+// WatchFile 每隔 interval 检查一次配置文件，文件变化后重新加载规则。
+// 已经通过 LoadFile 加载过的文件从加载时的修改时间开始检查，不会立即重复加载。
+// 调用返回的函数停止检查。
+func (r *RuleRouter) WatchFile(path string, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if fi, err := os.Stat(path); err == nil && !fi.ModTime().Equal(r.loadedModTime(path)) {
+				if err := r.LoadFile(path); err != nil {
+					log.Errorf("router: reload %s fail, keep last rules: %v", path, err)
+				}
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// LoadFile 从客户端配置文件加载规则。
+// 加载失败时同样记下文件的修改时间，WatchFile 等文件再次变化后才重试
+func (r *RuleRouter) LoadFile(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	if r.modTime == nil {
+		r.modTime = make(map[string]time.Time)
+	}
+	r.modTime[path] = fi.ModTime()
+	r.mu.Unlock()
+
+	c, err := config.LoadClientConfig(path)
+	if err != nil {
+		return err
+	}
+	return r.Update(c.Routes)
+}
+
+func (r *RuleRouter) loadedModTime(path string) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.modTime[path]
+}
+
+func (r *RuleRouter) Filter(ctx context.Context, list []*node.Node) ([]*node.Node, error) {
+	rules, _ := r.rules.Load().([]config.RouteConfig)
+	if len(rules) == 0 {
+		return list, nil
+	}
+	md, _ := metadata.FromContext(ctx)
+
+	for _, rule := range rules {
+		if !matchRequest(rule, md) {
+			continue
+		}
+		if !inPercent(rule, md) {
+			continue
+		}
+		if nodes := filterNodes(list, func(n *node.Node) bool { return matchLabels(n, rule.Labels) }); len(nodes) > 0 {
+			return nodes, nil
+		}
+	}
+
+	// 不走灰度的请求避开所有打了规则标签的节点
+	nodes := filterNodes(list, func(n *node.Node) bool {
+		for _, rule := range rules {
+			if matchLabels(n, rule.Labels) {
+				return false
+			}
+		}
+		return true
+	})
+	if len(nodes) == 0 {
+		return list, nil
+	}
+	return nodes, nil
+}
+
+func (r *RuleRouter) Name() string {
+	return "rule"
+}
+
+func matchRequest(rule config.RouteConfig, md metadata.MD) bool {
+	for _, m := range rule.Match {
+		v, ok := md[m.Key]
+		if !ok {
+			return false
+		}
+		if m.Value != "" {
+			if v != m.Value {
+				return false
+			}
+			continue
+		}
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || i < m.Min || i > m.Max {
+			return false
+		}
+	}
+	return true
+}
+
+// inPercent 请求是否落入规则的 Percent。按 HashKey（为空时为 Match 的各 key）的值哈希，
+// 同一个值的结果固定；请求中没有这些值时随机决定
+func inPercent(rule config.RouteConfig, md metadata.MD) bool {
+	if rule.Percent >= 100 {
+		return true
+	}
+	if rule.Percent <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(rule.Name))
+	found := false
+	if rule.HashKey != "" {
+		if v, ok := md[rule.HashKey]; ok {
+			h.Write([]byte{0})
+			h.Write([]byte(v))
+			found = true
+		}
+	} else {
+		for _, m := range rule.Match {
+			h.Write([]byte{0})
+			h.Write([]byte(md[m.Key]))
+			found = true
+		}
+	}
+	if !found {
+		return int(fastrand.Uint32n(100)) < rule.Percent
+	}
+	return int(h.Sum32()%100) < rule.Percent
+}
+
+func matchLabels(n *node.Node, labels map[string]string) bool {
+	for k, v := range labels {
+		if n.Meta(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func filterNodes(list []*node.Node, keep func(n *node.Node) bool) []*node.Node {
+	nodes := make([]*node.Node, 0, len(list))
+	for _, n := range list {
+		if keep(n) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,238 @@
+package router
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/config"
+	"github.com/hillguo/sanrpc/metadata"
+)
+
+const ruleFile = `
+[[routes]]
+name = "canary"
+percent = 10
+[routes.labels]
+version = "v2"
+`
+
+func ruleNames(r *RuleRouter) []string {
+	rules, _ := r.rules.Load().([]config.RouteConfig)
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = rule.Name
+	}
+	return names
+}
+
+func TestWatchFileStartsFromLoadedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rule_router")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "client.toml")
+	if err := ioutil.WriteFile(path, []byte(ruleFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ := NewRuleRouter(nil)
+	if err := r.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	// rules set after the load must survive until the file changes
+	if err := r.Update([]config.RouteConfig{{Name: "manual", Percent: 100, Labels: map[string]string{"k": "v"}}}); err != nil {
+		t.Fatal(err)
+	}
+	stop := r.WatchFile(path, 5*time.Millisecond)
+	defer stop()
+
+	time.Sleep(30 * time.Millisecond)
+	if names := ruleNames(r); len(names) != 1 || names[0] != "manual" {
+		t.Fatalf("rules = %v, file reloaded although unchanged", names)
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if names := ruleNames(r); len(names) == 1 && names[0] == "canary" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rules = %v, changed file not reloaded", ruleNames(r))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func versionNode(addr, version string) *node.Node {
+	n := &node.Node{Address: addr}
+	if version != "" {
+		n.Metadata = map[string]string{"version": version}
+	}
+	return n
+}
+
+func TestRuleRouterFilter(t *testing.T) {
+	v2 := map[string]string{"version": "v2"}
+	v3 := map[string]string{"version": "v3"}
+	canary := config.RouteConfig{Name: "canary", Percent: 100, Labels: v2,
+		Match: []config.MatchConfig{{Key: "x-canary", Value: "true"}}}
+	uids := config.RouteConfig{Name: "uids", Percent: 100, Labels: v3,
+		Match: []config.MatchConfig{{Key: "uid", Min: 100, Max: 199}}}
+	list := []*node.Node{versionNode("v1", ""), versionNode("v2", "v2"), versionNode("v3", "v3")}
+	tests := []struct {
+		name  string
+		rules []config.RouteConfig
+		list  []*node.Node
+		md    metadata.MD
+		want  []string
+	}{
+		{name: "no rules", list: list, want: []string{"v1", "v2", "v3"}},
+		{name: "value match", rules: []config.RouteConfig{canary}, md: metadata.MD{"x-canary": "true"}, want: []string{"v2"}},
+		{name: "value mismatch", rules: []config.RouteConfig{canary}, md: metadata.MD{"x-canary": "false"}, want: []string{"v1", "v3"}},
+		{name: "key missing", rules: []config.RouteConfig{canary}, want: []string{"v1", "v3"}},
+		{name: "range match", rules: []config.RouteConfig{uids}, md: metadata.MD{"uid": "150"}, want: []string{"v3"}},
+		{name: "range bounds", rules: []config.RouteConfig{uids}, md: metadata.MD{"uid": "199"}, want: []string{"v3"}},
+		{name: "range miss", rules: []config.RouteConfig{uids}, md: metadata.MD{"uid": "200"}, want: []string{"v1", "v2"}},
+		{name: "range not a number", rules: []config.RouteConfig{uids}, md: metadata.MD{"uid": "x"}, want: []string{"v1", "v2"}},
+		{
+			name:  "all matches required",
+			rules: []config.RouteConfig{{Name: "both", Percent: 100, Labels: v2, Match: append(canary.Match, uids.Match...)}},
+			md:    metadata.MD{"x-canary": "true", "uid": "1"},
+			want:  []string{"v1", "v3"},
+		},
+		{
+			name:  "first matching rule wins",
+			rules: []config.RouteConfig{canary, uids},
+			md:    metadata.MD{"x-canary": "true", "uid": "150"},
+			want:  []string{"v2"},
+		},
+		{
+			name:  "rule order",
+			rules: []config.RouteConfig{uids, canary},
+			md:    metadata.MD{"x-canary": "true", "uid": "150"},
+			want:  []string{"v3"},
+		},
+		{
+			name:  "rule without nodes falls through to the next rule",
+			rules: []config.RouteConfig{{Name: "v4", Percent: 100, Labels: map[string]string{"version": "v4"}}, uids},
+			md:    metadata.MD{"uid": "150"},
+			want:  []string{"v3"},
+		},
+		{
+			name:  "no rule with nodes uses unlabeled nodes",
+			rules: []config.RouteConfig{{Name: "v4", Percent: 100, Labels: map[string]string{"version": "v4"}}},
+			want:  []string{"v1", "v2", "v3"},
+		},
+		{
+			name:  "only labeled nodes left",
+			rules: []config.RouteConfig{canary},
+			list:  []*node.Node{versionNode("v2", "v2")},
+			want:  []string{"v2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRuleRouter(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in := tt.list
+			if in == nil {
+				in = list
+			}
+			ctx := metadata.NewContext(context.Background(), tt.md)
+			got, err := r.Filter(ctx, in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var addrs []string
+			for _, n := range got {
+				addrs = append(addrs, n.Address)
+			}
+			if !reflect.DeepEqual(addrs, tt.want) {
+				t.Errorf("Filter() = %v, want %v", addrs, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleRouterPercent(t *testing.T) {
+	r, err := NewRuleRouter([]config.RouteConfig{{Name: "rollout", Percent: 10, HashKey: "uid",
+		Labels: map[string]string{"version": "v2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := []*node.Node{versionNode("v1", ""), versionNode("v2", "v2")}
+	route := func(uid string) string {
+		ctx := metadata.NewContext(context.Background(), metadata.MD{"uid": uid})
+		got, err := r.Filter(ctx, list)
+		if err != nil || len(got) != 1 {
+			t.Fatalf("Filter() = %v, %v", got, err)
+		}
+		return got[0].Address
+	}
+
+	const users = 10000
+	canary := 0
+	for i := 0; i < users; i++ {
+		uid := strconv.Itoa(i)
+		addr := route(uid)
+		if addr == "v2" {
+			canary++
+		}
+		// 同一个 uid 的结果固定
+		if again := route(uid); again != addr {
+			t.Fatalf("uid %s routed to %s then %s", uid, addr, again)
+		}
+	}
+	if canary < users*8/100 || canary > users*12/100 {
+		t.Errorf("%d of %d users routed to v2, want about 10%%", canary, users)
+	}
+}
+
+func TestRuleRouterInvalidRules(t *testing.T) {
+	labels := map[string]string{"version": "v2"}
+	tests := []struct {
+		name string
+		rule config.RouteConfig
+	}{
+		{name: "percent omitted", rule: config.RouteConfig{Name: "r", Labels: labels}},
+		{name: "negative percent", rule: config.RouteConfig{Name: "r", Percent: -1, Labels: labels}},
+		{name: "percent over 100", rule: config.RouteConfig{Name: "r", Percent: 101, Labels: labels}},
+		{name: "no labels", rule: config.RouteConfig{Name: "r", Percent: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRuleRouter([]config.RouteConfig{tt.rule}); err == nil {
+				t.Errorf("NewRuleRouter(%+v) succeeded", tt.rule)
+			}
+		})
+	}
+
+	dir, err := ioutil.TempDir("", "rule_router")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "client.toml")
+	noPercent := "[[routes]]\nname = \"canary\"\n[routes.labels]\nversion = \"v2\"\n"
+	if err := ioutil.WriteFile(path, []byte(noPercent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := NewRuleRouter(nil)
+	if err := r.LoadFile(path); err == nil {
+		t.Errorf("LoadFile() of a rule without percent succeeded, rules %v", ruleNames(r))
+	}
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/naming"
+)
+
+// DefaultLocalThreshold 本地健康容量比例低于此值时，允许访问其他 set/zone
+const DefaultLocalThreshold = 0.5
+
+// SetRouter 按 Namespace/EnvName/SetName/Zone 就近路由：
+// 永远不跨 Namespace，EnvName 不为空时也不跨环境；优先同 set，其次同 zone，最后同环境下的所有节点。
+// 某一层健康节点的权重占比低于 Threshold 时降级到下一层。
+type SetRouter struct {
+	Namespace string // 调用方命名空间
+	EnvName   string // 调用方环境
+	SetName   string // 调用方set分组
+	Zone      string // 调用方可用区
+	Threshold float64
+
+	// Healthy 判断节点是否健康，为 nil 时认为节点都是健康的
+	Healthy func(n *node.Node) bool
+}
+
+// NewSetRouter 创建 SetRouter
+func NewSetRouter(namespace, envName, setName, zone string) *SetRouter {
+	return &SetRouter{
+		Namespace: namespace,
+		EnvName:   envName,
+		SetName:   setName,
+		Zone:      zone,
+		Threshold: DefaultLocalThreshold,
+	}
+}
+
+func (r *SetRouter) Filter(ctx context.Context, list []*node.Node) ([]*node.Node, error) {
+	nodes := filterNodes(list, func(n *node.Node) bool {
+		return n.Meta(naming.MetaNamespace) == r.Namespace &&
+			(r.EnvName == "" || n.Meta(naming.MetaEnvName) == r.EnvName)
+	})
+	if len(nodes) == 0 {
+		return nil, ErrNoNodeMatched
+	}
+
+	for _, tier := range []struct{ key, value string }{
+		{naming.MetaSetName, r.SetName},
+		{naming.MetaZone, r.Zone},
+	} {
+		if tier.value == "" {
+			continue
+		}
+		local := filterNodes(nodes, func(n *node.Node) bool {
+			return n.Meta(tier.key) == tier.value
+		})
+		if healthy := r.healthy(local); len(healthy) > 0 && r.enough(healthy, local) {
+			return healthy, nil
+		}
+	}
+
+	if healthy := r.healthy(nodes); len(healthy) > 0 {
+		return healthy, nil
+	}
+	return nodes, nil
+}
+
+// healthy 返回健康的节点
+func (r *SetRouter) healthy(list []*node.Node) []*node.Node {
+	if r.Healthy == nil {
+		return list
+	}
+	return filterNodes(list, r.Healthy)
+}
+
+// enough 健康节点的权重占比是否达到 Threshold
+func (r *SetRouter) enough(healthy, all []*node.Node) bool {
+	return float64(weight(healthy)) >= r.Threshold*float64(weight(all))
+}
+
+func (r *SetRouter) Name() string {
+	return "set"
+}
+
+func weight(list []*node.Node) int {
+	w := 0
+	for _, n := range list {
+		if n.Weight <= 0 {
+			w++
+		} else {
+			w += n.Weight
+		}
+	}
+	return w
+}
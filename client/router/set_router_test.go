@@ -0,0 +1,157 @@
+package router
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/hillguo/sanrpc/naming"
+)
+
+// setNode 创建地址为 addr 的节点，meta 依次为 namespace、env、set、zone
+func setNode(addr string, weight int, meta ...string) *node.Node {
+	keys := []string{naming.MetaNamespace, naming.MetaEnvName, naming.MetaSetName, naming.MetaZone}
+	md := make(map[string]string)
+	for i, v := range meta {
+		md[keys[i]] = v
+	}
+	return &node.Node{Address: addr, Weight: weight, Metadata: md}
+}
+
+func addrs(list []*node.Node) []string {
+	a := make([]string, len(list))
+	for i, n := range list {
+		a[i] = n.Address
+	}
+	return a
+}
+
+func TestSetRouterFilter(t *testing.T) {
+	list := []*node.Node{
+		setNode("set1", 0, "Production", "formal", "sz.1", "sz"),
+		setNode("set2", 0, "Production", "formal", "sz.1", "sz"),
+		setNode("zone", 0, "Production", "formal", "sz.2", "sz"),
+		setNode("other", 0, "Production", "formal", "sh.1", "sh"),
+		setNode("test", 0, "Production", "test", "sz.1", "sz"),
+		setNode("dev", 0, "Development", "formal", "sz.1", "sz"),
+	}
+	down := func(addrs ...string) func(n *node.Node) bool {
+		return func(n *node.Node) bool {
+			for _, a := range addrs {
+				if n.Address == a {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	tests := []struct {
+		name    string
+		router  *SetRouter
+		list    []*node.Node
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "same set",
+			router: NewSetRouter("Production", "formal", "sz.1", "sz"),
+			want:   []string{"set1", "set2"},
+		},
+		{
+			name:   "no set falls back to zone",
+			router: NewSetRouter("Production", "formal", "", "sz"),
+			want:   []string{"set1", "set2", "zone"},
+		},
+		{
+			name:   "unknown set falls back to zone",
+			router: NewSetRouter("Production", "formal", "sz.9", "sz"),
+			want:   []string{"set1", "set2", "zone"},
+		},
+		{
+			name:   "unknown zone falls back to env",
+			router: NewSetRouter("Production", "formal", "", "gz"),
+			want:   []string{"set1", "set2", "zone", "other"},
+		},
+		{
+			name:   "empty env crosses envs but not namespaces",
+			router: NewSetRouter("Production", "", "", ""),
+			want:   []string{"set1", "set2", "zone", "other", "test"},
+		},
+		{
+			name:   "namespace isolation",
+			router: NewSetRouter("Development", "", "sz.2", ""),
+			want:   []string{"dev"},
+		},
+		{
+			name:    "no node in env",
+			router:  NewSetRouter("Production", "pre", "sz.1", "sz"),
+			wantErr: ErrNoNodeMatched,
+		},
+		{
+			name:   "half of the set healthy is enough",
+			router: &SetRouter{Namespace: "Production", EnvName: "formal", SetName: "sz.1", Zone: "sz", Threshold: 0.5, Healthy: down("set1")},
+			want:   []string{"set2"},
+		},
+		{
+			name:   "set below threshold falls back to zone",
+			router: &SetRouter{Namespace: "Production", EnvName: "formal", SetName: "sz.1", Zone: "sz", Threshold: 0.6, Healthy: down("set1")},
+			want:   []string{"set2", "zone"},
+		},
+		{
+			name:   "set down falls back to healthy zone",
+			router: &SetRouter{Namespace: "Production", EnvName: "formal", SetName: "sz.1", Zone: "sz", Threshold: 0.3, Healthy: down("set1", "set2")},
+			want:   []string{"zone"},
+		},
+		{
+			name:   "zone below threshold falls back to env",
+			router: &SetRouter{Namespace: "Production", EnvName: "formal", SetName: "sz.1", Zone: "sz", Threshold: 0.5, Healthy: down("set1", "set2")},
+			want:   []string{"zone", "other"},
+		},
+		{
+			name:   "zone down falls back to healthy env",
+			router: &SetRouter{Namespace: "Production", EnvName: "formal", SetName: "sz.1", Zone: "sz", Threshold: 0.5, Healthy: down("set1", "set2", "zone")},
+			want:   []string{"other"},
+		},
+		{
+			name:   "all down returns all nodes in env",
+			router: &SetRouter{Namespace: "Production", EnvName: "formal", SetName: "sz.1", Zone: "sz", Threshold: 0.5, Healthy: down("set1", "set2", "zone", "other")},
+			want:   []string{"set1", "set2", "zone", "other"},
+		},
+		{
+			name:   "threshold by weight",
+			router: &SetRouter{Namespace: "Production", SetName: "sz.1", Threshold: 0.5, Healthy: down("light")},
+			list: []*node.Node{
+				setNode("heavy", 3, "Production", "formal", "sz.1"),
+				setNode("light", 1, "Production", "formal", "sz.1"),
+				setNode("zone", 1, "Production", "formal", "sz.2"),
+			},
+			want: []string{"heavy"},
+		},
+		{
+			name:   "heavy node down",
+			router: &SetRouter{Namespace: "Production", SetName: "sz.1", Threshold: 0.5, Healthy: down("heavy")},
+			list: []*node.Node{
+				setNode("heavy", 3, "Production", "formal", "sz.1"),
+				setNode("light", 1, "Production", "formal", "sz.1"),
+				setNode("zone", 1, "Production", "formal", "sz.2"),
+			},
+			want: []string{"light", "zone"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := tt.list
+			if in == nil {
+				in = list
+			}
+			got, err := tt.router.Filter(context.Background(), in)
+			if err != tt.wantErr {
+				t.Fatalf("Filter() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(addrs(got), tt.want) {
+				t.Errorf("Filter() = %v, want %v", addrs(got), tt.want)
+			}
+		})
+	}
+}
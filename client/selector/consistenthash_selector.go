@@ -0,0 +1,58 @@
+package selector
+
+import (
+	"context"
+	"hash/fnv"
+
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/valyala/fastrand"
+)
+
+func init() {
+	Register("consistenthash", &ConsistentHashSelector{})
+}
+
+// ConsistentHashSelector selects a node by the hash key of the call, see WithHashKey.
+// It is not a hash ring: it uses rendezvous (highest random weight) hashing, scoring every node
+// with hash(key, address) and taking the highest, so it needs no virtual nodes and only the keys
+// of an added or removed node move. Selecting costs O(n) in the number of nodes.
+// Node weights are ignored. Calls without a hash key select randomly.
+type ConsistentHashSelector struct{}
+
+func (s *ConsistentHashSelector) Select(ctx context.Context, list []*node.Node) (*node.Node, error) {
+	if len(list) == 0 {
+		return nil, ErrNodeListEmpty
+	}
+	key, ok := HashKey(ctx)
+	if !ok {
+		return list[fastrand.Uint32n(uint32(len(list)))], nil
+	}
+
+	var best *node.Node
+	var bestScore uint64
+	for _, n := range list {
+		score := hashString(key + "/" + n.Address)
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best, nil
+}
+
+func (s *ConsistentHashSelector) Name() string {
+	return "consistenthash"
+}
+
+// hashString get a hash value of a string.
+// fnv alone mixes the last bytes poorly, so the sum goes through a 64-bit finalizer.
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
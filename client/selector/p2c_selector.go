@@ -0,0 +1,207 @@
+package selector
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hillguo/sanrpc/client/node"
+	"github.com/valyala/fastrand"
+)
+
+func init() {
+	Register("p2c", &P2CSelector{})
+}
+
+const (
+	// p2cTau 延时 EWMA 的衰减时间常数
+	p2cTau = 10 * time.Second
+	// p2cPenalty 调用失败时按此延时计入 EWMA
+	p2cPenalty = time.Second
+)
+
+// p2cStat 单个节点的负载统计
+type p2cStat struct {
+	// 以下字段由 P2CSelector.mu 保护
+	inflight int64     // 进行中的请求数
+	used     time.Time // 最近一次出现在 Select 列表中的时间
+	removed  bool      // 已被服务发现删除，进行中的请求结束后清除
+
+	mu    sync.Mutex
+	lag   float64   // 延时 EWMA，单位 ns
+	stamp time.Time // 上次更新时间
+}
+
+// load 调用方需持有 P2CSelector.mu
+func (st *p2cStat) load() float64 {
+	st.mu.Lock()
+	lag := st.lag
+	st.mu.Unlock()
+	return lag * float64(st.inflight+1)
+}
+
+func (st *p2cStat) observe(cost time.Duration) {
+	now := time.Now()
+	st.mu.Lock()
+	if st.stamp.IsZero() {
+		st.lag = float64(cost)
+	} else {
+		w := math.Exp(-float64(now.Sub(st.stamp)) / float64(p2cTau))
+		st.lag = st.lag*w + float64(cost)*(1-w)
+	}
+	st.stamp = now
+	st.mu.Unlock()
+}
+
+// P2CSelector 随机选两个节点，取 延时EWMA*(进行中请求数+1) 较小的一个。
+// 依赖 client 在每次调用后通过 Report 回报结果。
+// 统计按服务保存，router 过滤掉的节点保留统计，只在服务发现删除节点（Remove）
+// 或节点超过 staleTTL 没有出现在 Select 列表中时清除，且不清除有进行中请求的节点，
+// 保证 Report 扣减的是 Select 时计数的同一份统计。
+// 新节点的延时以同服务已有节点的平均值为初值，避免没有样本的新节点承接全部流量。
+type P2CSelector struct {
+	mu    sync.Mutex
+	stats map[string]map[string]*p2cStat // service name => address => stat
+	swept time.Time
+}
+
+// prepare 返回列表中节点所在服务的统计，创建新节点的统计，调用方需持有 s.mu
+func (s *P2CSelector) prepare(list []*node.Node, now time.Time) map[string]*p2cStat {
+	if s.stats == nil {
+		s.stats = make(map[string]map[string]*p2cStat)
+	}
+	service := list[0].ServiceName
+	stats := s.stats[service]
+	if stats == nil {
+		stats = make(map[string]*p2cStat)
+		s.stats[service] = stats
+	}
+
+	var fresh []*p2cStat
+	for _, n := range list {
+		st, ok := stats[n.Address]
+		if !ok {
+			st = &p2cStat{}
+			stats[n.Address] = st
+			fresh = append(fresh, st)
+		}
+		st.used = now
+		st.removed = false
+	}
+	if len(fresh) > 0 {
+		s.seed(stats, fresh)
+	}
+	return stats
+}
+
+// sweep 清除超过 staleTTL 未出现且没有进行中请求的节点的统计，调用方需持有 s.mu
+func (s *P2CSelector) sweep(now time.Time) {
+	s.swept = now
+	for service, stats := range s.stats {
+		for addr, st := range stats {
+			if st.inflight == 0 && now.Sub(st.used) > staleTTL {
+				delete(stats, addr)
+			}
+		}
+		if len(stats) == 0 {
+			delete(s.stats, service)
+		}
+	}
+}
+
+// seed 以已有样本节点的平均延时作为新节点的初值
+func (s *P2CSelector) seed(stats map[string]*p2cStat, fresh []*p2cStat) {
+	var sum float64
+	var count int
+	for _, st := range stats {
+		st.mu.Lock()
+		if !st.stamp.IsZero() {
+			sum += st.lag
+			count++
+		}
+		st.mu.Unlock()
+	}
+	if count == 0 {
+		return
+	}
+	now := time.Now()
+	for _, st := range fresh {
+		st.mu.Lock()
+		st.lag = sum / float64(count)
+		st.stamp = now
+		st.mu.Unlock()
+	}
+}
+
+func (s *P2CSelector) Select(ctx context.Context, list []*node.Node) (*node.Node, error) {
+	if len(list) == 0 {
+		return nil, ErrNodeListEmpty
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.prepare(list, now)
+	best := list[0]
+	if len(list) > 1 {
+		i := fastrand.Uint32n(uint32(len(list)))
+		j := fastrand.Uint32n(uint32(len(list) - 1))
+		if j >= i {
+			j++
+		}
+		a, b := list[i], list[j]
+		best = a
+		if stats[b.Address].load() < stats[a.Address].load() {
+			best = b
+		}
+	}
+	stats[best.Address].inflight++
+
+	if now.Sub(s.swept) > staleTTL {
+		s.sweep(now)
+	}
+	return best, nil
+}
+
+// Report 回报一次调用的结果
+func (s *P2CSelector) Report(n *node.Node, cost time.Duration, err error) {
+	s.mu.Lock()
+	stats := s.stats[n.ServiceName]
+	st := stats[n.Address]
+	if st == nil || st.inflight == 0 {
+		// 不是经过本 selector 选出的节点
+		s.mu.Unlock()
+		return
+	}
+	st.inflight--
+	if st.removed && st.inflight == 0 {
+		delete(stats, n.Address)
+	}
+	s.mu.Unlock()
+
+	if err != nil && cost < p2cPenalty {
+		cost = p2cPenalty
+	}
+	st.observe(cost)
+}
+
+// Remove 服务发现删除节点时清除它的统计，有进行中的请求时等请求结束后清除
+func (s *P2CSelector) Remove(n *node.Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats[n.ServiceName]
+	st := stats[n.Address]
+	if st == nil {
+		return
+	}
+	if st.inflight > 0 {
+		st.removed = true
+		return
+	}
+	delete(stats, n.Address)
+}
+
+func (s *P2CSelector) Name() string {
+	return "p2c"
+}
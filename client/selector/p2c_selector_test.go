@@ -0,0 +1,170 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/client/node"
+)
+
+func TestP2CPrefersFasterNode(t *testing.T) {
+	s := &P2CSelector{}
+	list := newNodes("svc", 1, 1)
+	fast, slow := list[0], list[1]
+	cost := map[string]time.Duration{fast.Address: time.Millisecond, slow.Address: 100 * time.Millisecond}
+
+	for _, n := range list {
+		nd, _ := s.Select(context.Background(), []*node.Node{n})
+		s.Report(nd, cost[nd.Address], nil)
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		nd, _ := s.Select(context.Background(), list)
+		counts[nd.Address]++
+		s.Report(nd, cost[nd.Address], nil)
+	}
+	if counts[fast.Address] != 100 {
+		t.Errorf("fast node selected %d times, slow node %d times", counts[fast.Address], counts[slow.Address])
+	}
+}
+
+func TestP2CErrorPenalty(t *testing.T) {
+	s := &P2CSelector{}
+	list := newNodes("svc", 1)
+	nd, _ := s.Select(context.Background(), list)
+	s.Report(nd, time.Millisecond, errors.New("fail"))
+	if lag := s.stats["svc"][nd.Address].lag; lag != float64(p2cPenalty) {
+		t.Errorf("lag after a failed call = %v, want %v", time.Duration(lag), p2cPenalty)
+	}
+}
+
+func TestP2CSeedsNewNodes(t *testing.T) {
+	s := &P2CSelector{}
+	list := newNodes("svc", 1, 1, 1)
+	for _, n := range list[:2] {
+		nd, _ := s.Select(context.Background(), []*node.Node{n})
+		s.Report(nd, 10*time.Millisecond, nil)
+	}
+	s.Select(context.Background(), list)
+	if lag := s.stats["svc"][list[2].Address].lag; lag != float64(10*time.Millisecond) {
+		t.Errorf("lag of a new node = %v, want the average %v", time.Duration(lag), 10*time.Millisecond)
+	}
+}
+
+func TestP2CKeepsFilteredNodes(t *testing.T) {
+	s := &P2CSelector{}
+	list := newNodes("svc", 1, 1)
+	nd, _ := s.Select(context.Background(), list[:1])
+
+	// a router passing another subset must not drop the stat of the call in flight
+	s.Select(context.Background(), list[1:])
+	st := s.stats["svc"][nd.Address]
+	if st == nil || st.inflight != 1 {
+		t.Fatalf("stat of %s = %+v, want 1 call in flight", nd.Address, st)
+	}
+	s.Report(nd, time.Millisecond, nil)
+	if st.inflight != 0 || st.stamp.IsZero() {
+		t.Errorf("Report did not reach the stat counted by Select: %+v", st)
+	}
+}
+
+func TestP2CRemove(t *testing.T) {
+	s := &P2CSelector{}
+	list := newNodes("svc", 1, 1)
+	idle, busy := list[0], list[1]
+	s.Select(context.Background(), []*node.Node{idle})
+	s.Report(idle, time.Millisecond, nil)
+	s.Select(context.Background(), []*node.Node{busy})
+
+	s.Remove(idle)
+	s.Remove(busy)
+	if _, ok := s.stats["svc"][idle.Address]; ok {
+		t.Errorf("stat of removed idle node kept")
+	}
+	st, ok := s.stats["svc"][busy.Address]
+	if !ok {
+		t.Fatalf("stat of removed node dropped while a call is in flight")
+	}
+	s.Report(busy, time.Millisecond, nil)
+	if _, ok := s.stats["svc"][busy.Address]; ok {
+		t.Errorf("stat of removed node kept after its last call finished")
+	}
+	if st.inflight != 0 {
+		t.Errorf("inflight = %d after Report, want 0", st.inflight)
+	}
+
+	// a report without a matching Select is ignored
+	s.Report(busy, time.Millisecond, nil)
+	if _, ok := s.stats["svc"][busy.Address]; ok {
+		t.Errorf("Report recreated the stat of a removed node")
+	}
+}
+
+func TestP2CSweep(t *testing.T) {
+	s := &P2CSelector{}
+	list := newNodes("svc", 1, 1)
+	s.Select(context.Background(), list[:1])
+	nd, _ := s.Select(context.Background(), list[1:])
+	s.Report(nd, time.Millisecond, nil)
+
+	s.mu.Lock()
+	for _, st := range s.stats["svc"] {
+		st.used = time.Now().Add(-2 * staleTTL)
+	}
+	s.sweep(time.Now())
+	s.mu.Unlock()
+	if _, ok := s.stats["svc"][list[0].Address]; !ok {
+		t.Errorf("stale stat with a call in flight dropped")
+	}
+	if _, ok := s.stats["svc"][list[1].Address]; ok {
+		t.Errorf("stale idle stat kept")
+	}
+}
+
+func TestP2CConcurrentSelectReport(t *testing.T) {
+	s := &P2CSelector{}
+	all := newNodes("svc", 1, 1, 1, 1, 1, 1, 1, 1)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for i := 0; i < 2000; i++ {
+				// every call sees a different subset, as behind a router
+				lo := r.Intn(len(all))
+				hi := lo + 1 + r.Intn(len(all)-lo)
+				nd, err := s.Select(context.Background(), all[lo:hi])
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if r.Intn(4) == 0 {
+					s.Remove(all[r.Intn(len(all))])
+				}
+				var err2 error
+				if r.Intn(10) == 0 {
+					err2 = errors.New("fail")
+				}
+				s.Report(nd, time.Duration(r.Intn(1000))*time.Microsecond, err2)
+			}
+		}(int64(g))
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for addr, st := range s.stats["svc"] {
+		if st.inflight != 0 {
+			t.Errorf("%s: inflight = %d after all calls finished, want 0", addr, st.inflight)
+		}
+		if st.removed {
+			t.Errorf("%s: removed stat kept after all calls finished", addr)
+		}
+	}
+}
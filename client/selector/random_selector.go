@@ -1,7 +1,7 @@
 package selector
 
 import (
-	"errors"
+	"context"
 	"github.com/hillguo/sanrpc/client/node"
 
 	"github.com/valyala/fastrand"
@@ -16,9 +16,9 @@ type RandomSelector struct {
 
 }
 
-func (s *RandomSelector) Select(list []*node.Node) (*node.Node,error) {
+func (s *RandomSelector) Select(ctx context.Context, list []*node.Node) (*node.Node,error) {
 	if len(list) == 0 {
-		return nil,errors.New("node list empty")
+		return nil,ErrNodeListEmpty
 	}
 	i := fastrand.Uint32n(uint32(len(list)))
 	return list[i],nil
@@ -0,0 +1,34 @@
+package selector
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hillguo/sanrpc/client/node"
+)
+
+func init() {
+	Register("roundrobin", &RoundRobinSelector{})
+}
+
+// RoundRobinSelector selects nodes in turn, with one counter per service.
+type RoundRobinSelector struct {
+	counters sync.Map // service name => *uint32
+}
+
+func (s *RoundRobinSelector) Select(ctx context.Context, list []*node.Node) (*node.Node, error) {
+	if len(list) == 0 {
+		return nil, ErrNodeListEmpty
+	}
+	c, ok := s.counters.Load(list[0].ServiceName)
+	if !ok {
+		c, _ = s.counters.LoadOrStore(list[0].ServiceName, new(uint32))
+	}
+	i := atomic.AddUint32(c.(*uint32), 1) - 1
+	return list[i%uint32(len(list))], nil
+}
+
+func (s *RoundRobinSelector) Name() string {
+	return "roundrobin"
+}
@@ -1,19 +1,42 @@
 package selector
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/hillguo/sanrpc/client/node"
 )
 
 // Selector 路由组件接口
 type Selector interface {
-	Select(list []*node.Node) (node *node.Node, err error)
+	Select(ctx context.Context, list []*node.Node) (node *node.Node, err error)
 	Name() string
 }
 
+// Reporter selector 可选实现的接口，client 每次调用结束后回报耗时和错误，
+// 用于按负载选择节点的 selector
+type Reporter interface {
+	Report(node *node.Node, cost time.Duration, err error)
+}
+
+// Remover selector 可选实现的接口，服务发现删除节点时 client 调用 Remove 清除节点的状态。
+// router 每次过滤出的可能只是部分节点，selector 不能按 Select 传入的列表清除状态
+type Remover interface {
+	Remove(node *node.Node)
+}
+
+// staleTTL 节点超过这个时间没有出现在 Select 的列表中时清除它的状态，
+// 用于不推送删除事件的服务发现
+const staleTTL = 10 * time.Minute
+
 var (
 	selectors = make(map[string]Selector)
 )
 
+// ErrNodeListEmpty 没有可选节点
+var ErrNodeListEmpty = errors.New("node list empty")
+
 var DefaultSelector = &RandomSelector{}
 
 // Register 注册selector，如l5 dns cmlb tseer
@@ -27,3 +50,16 @@ func GetSelector(name string) Selector {
 	return s
 }
 
+
+type hashKeyCtxKey struct{}
+
+// WithHashKey 设置本次调用的hash key，供 consistenthash selector 使用
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKeyCtxKey{}, key)
+}
+
+// HashKey 获取本次调用的hash key
+func HashKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(hashKeyCtxKey{}).(string)
+	return key, ok
+}
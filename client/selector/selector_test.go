@@ -0,0 +1,247 @@
+package selector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/client/discovery"
+	"github.com/hillguo/sanrpc/client/node"
+)
+
+func newNodes(service string, weights ...int) []*node.Node {
+	list := make([]*node.Node, len(weights))
+	for i, w := range weights {
+		list[i] = &node.Node{
+			ServiceName: service,
+			Network:     "tcp",
+			Address:     "127.0.0.1:" + strconv.Itoa(8000+i),
+			Weight:      w,
+		}
+	}
+	return list
+}
+
+// count selects n times from list and counts the picks by address.
+func count(t *testing.T, s Selector, ctx context.Context, list []*node.Node, n int) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		nd, err := s.Select(ctx, list)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		counts[nd.Address]++
+	}
+	return counts
+}
+
+func TestSelectEmptyList(t *testing.T) {
+	for _, s := range []Selector{
+		&RandomSelector{},
+		&RoundRobinSelector{},
+		&WeightedRoundRobinSelector{},
+		&ConsistentHashSelector{},
+		&P2CSelector{},
+	} {
+		if _, err := s.Select(context.Background(), nil); err != ErrNodeListEmpty {
+			t.Errorf("%s: Select(nil) error = %v, want %v", s.Name(), err, ErrNodeListEmpty)
+		}
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	s := &RoundRobinSelector{}
+	a := newNodes("a", 1, 1, 1)
+	b := newNodes("b", 1, 1)
+
+	for i := 0; i < 9; i++ {
+		got, _ := s.Select(context.Background(), a)
+		if want := a[i%len(a)]; got != want {
+			t.Fatalf("select %d of a = %s, want %s", i, got.Address, want.Address)
+		}
+		// calls to another service must not move the rotation of a
+		for j := 0; j < i%3; j++ {
+			s.Select(context.Background(), b)
+		}
+	}
+
+	counts := count(t, s, context.Background(), b, 100)
+	for _, n := range b {
+		if counts[n.Address] != 50 {
+			t.Errorf("%s selected %d times, want 50", n.Address, counts[n.Address])
+		}
+	}
+}
+
+func TestRotationPerIPService(t *testing.T) {
+	// 两个按 ip 列表访问的服务，节点由 IPDiscovery 生成，轮转互不干扰
+	a, _ := (&discovery.IPDiscovery{}).List("10.0.0.1:80,10.0.0.2:80,10.0.0.3:80")
+	b, _ := (&discovery.IPDiscovery{}).List("10.0.1.1:80,10.0.1.2:80")
+	for _, s := range []Selector{&RoundRobinSelector{}, &WeightedRoundRobinSelector{}} {
+		t.Run(s.Name(), func(t *testing.T) {
+			var gotA, gotB []string
+			for i := 0; i < 6; i++ {
+				na, _ := s.Select(context.Background(), a)
+				nb, _ := s.Select(context.Background(), b)
+				gotA = append(gotA, na.Address)
+				gotB = append(gotB, nb.Address)
+			}
+			for i := len(a); i < len(gotA); i++ {
+				if gotA[i] != gotA[i-len(a)] {
+					t.Fatalf("service a picks %v, not a rotation of %d nodes", gotA, len(a))
+				}
+			}
+			if counts := countAddrs(gotA); len(counts) != len(a) {
+				t.Errorf("service a picks %v, want each node twice", gotA)
+			}
+			if counts := countAddrs(gotB); len(counts) != len(b) || counts[b[0].Address] != 3 {
+				t.Errorf("service b picks %v, want each node 3 times", gotB)
+			}
+		})
+	}
+}
+
+func countAddrs(addrs []string) map[string]int {
+	counts := make(map[string]int)
+	for _, a := range addrs {
+		counts[a]++
+	}
+	return counts
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	tests := []struct {
+		weights []int
+		want    []int // picks per node in one round of sum(weights) selects
+	}{
+		{weights: []int{1, 1, 1}, want: []int{1, 1, 1}},
+		{weights: []int{5, 1, 1}, want: []int{5, 1, 1}},
+		{weights: []int{3, 0, 2}, want: []int{3, 1, 2}}, // weight <= 0 counts as 1
+		{weights: []int{4}, want: []int{4}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.weights), func(t *testing.T) {
+			s := &WeightedRoundRobinSelector{}
+			list := newNodes("svc", tt.weights...)
+			round := 0
+			for _, w := range tt.want {
+				round += w
+			}
+			counts := count(t, s, context.Background(), list, round*10)
+			for i, n := range list {
+				if counts[n.Address] != tt.want[i]*10 {
+					t.Errorf("%s selected %d times, want %d", n.Address, counts[n.Address], tt.want[i]*10)
+				}
+			}
+		})
+	}
+}
+
+func TestWeightedRoundRobinSmooth(t *testing.T) {
+	s := &WeightedRoundRobinSelector{}
+	list := newNodes("svc", 5, 1, 1)
+	want := []int{0, 0, 1, 0, 2, 0, 0}
+	for i, w := range want {
+		got, _ := s.Select(context.Background(), list)
+		if got != list[w] {
+			t.Fatalf("select %d = %s, want %s", i, got.Address, list[w].Address)
+		}
+	}
+}
+
+func TestWeightedRoundRobinKeepsFilteredNodes(t *testing.T) {
+	s := &WeightedRoundRobinSelector{}
+	list := newNodes("svc", 3, 1)
+
+	// a router that sometimes filters out the first node must not reset its state
+	counts := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		nd, _ := s.Select(context.Background(), list)
+		counts[nd.Address]++
+		s.Select(context.Background(), list[1:])
+	}
+	if got := counts[list[0].Address]; got != 300 {
+		t.Errorf("%s selected %d times from the full list, want 300", list[0].Address, got)
+	}
+	if got := counts[list[1].Address]; got != 100 {
+		t.Errorf("%s selected %d times from the full list, want 100", list[1].Address, got)
+	}
+}
+
+func TestWeightedRoundRobinRemove(t *testing.T) {
+	s := &WeightedRoundRobinSelector{}
+	a := newNodes("a", 1, 1)
+	b := newNodes("b", 1)
+	s.Select(context.Background(), a)
+	s.Select(context.Background(), b)
+
+	s.Remove(a[0])
+	if _, ok := s.weights["a"][a[0].Address]; ok {
+		t.Errorf("state of removed node %s kept", a[0].Address)
+	}
+	if _, ok := s.weights["a"][a[1].Address]; !ok {
+		t.Errorf("state of %s dropped", a[1].Address)
+	}
+
+	s.mu.Lock()
+	s.weights["a"][a[1].Address].used = time.Now().Add(-2 * staleTTL)
+	s.sweep(time.Now())
+	s.mu.Unlock()
+	if _, ok := s.weights["a"]; ok {
+		t.Errorf("stale service a kept")
+	}
+	if _, ok := s.weights["b"][b[0].Address]; !ok {
+		t.Errorf("state of %s dropped", b[0].Address)
+	}
+}
+
+func TestConsistentHash(t *testing.T) {
+	s := &ConsistentHashSelector{}
+	list := newNodes("svc", 1, 1, 1, 1)
+
+	const keys = 10000
+	picks := make(map[string]string, keys)
+	counts := make(map[string]int)
+	for i := 0; i < keys; i++ {
+		key := "user-" + strconv.Itoa(i)
+		ctx := WithHashKey(context.Background(), key)
+		nd, err := s.Select(ctx, list)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		if again, _ := s.Select(ctx, list); again != nd {
+			t.Fatalf("key %s selected %s then %s", key, nd.Address, again.Address)
+		}
+		picks[key] = nd.Address
+		counts[nd.Address]++
+	}
+	for _, n := range list {
+		if c := counts[n.Address]; c < keys/4*8/10 || c > keys/4*12/10 {
+			t.Errorf("%s got %d of %d keys, want about %d", n.Address, c, keys, keys/4)
+		}
+	}
+
+	// removing a node only moves the keys of that node
+	removed := list[1].Address
+	rest := append([]*node.Node{list[0]}, list[2:]...)
+	for key, addr := range picks {
+		nd, _ := s.Select(WithHashKey(context.Background(), key), rest)
+		if addr != removed && nd.Address != addr {
+			t.Fatalf("key %s moved from %s to %s", key, addr, nd.Address)
+		}
+	}
+}
+
+func TestConsistentHashWithoutKey(t *testing.T) {
+	s := &ConsistentHashSelector{}
+	list := newNodes("svc", 1, 1)
+	counts := count(t, s, context.Background(), list, 1000)
+	for _, n := range list {
+		if counts[n.Address] == 0 {
+			t.Errorf("%s never selected without a hash key", n.Address)
+		}
+	}
+}
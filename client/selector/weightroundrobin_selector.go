@@ -0,0 +1,109 @@
+package selector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hillguo/sanrpc/client/node"
+)
+
+func init() {
+	Register("weightroundrobin", &WeightedRoundRobinSelector{})
+}
+
+// weighted is the smooth weighted round robin state of one node.
+type weighted struct {
+	weight          int
+	currentWeight   int
+	effectiveWeight int
+	used            time.Time // last time the node was in the list of Select
+}
+
+// WeightedRoundRobinSelector selects nodes with smooth weighted round robin, using node.Weight.
+// The state is kept per service. A node keeps its state while routers filter it out of some calls,
+// and loses it only when discovery deletes it (see Remove) or it has not been selectable for staleTTL.
+type WeightedRoundRobinSelector struct {
+	mu      sync.Mutex
+	weights map[string]map[string]*weighted // service name => address => state
+	swept   time.Time
+}
+
+func (s *WeightedRoundRobinSelector) Select(ctx context.Context, list []*node.Node) (*node.Node, error) {
+	if len(list) == 0 {
+		return nil, ErrNodeListEmpty
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.weights == nil {
+		s.weights = make(map[string]map[string]*weighted)
+	}
+	service := list[0].ServiceName
+	weights := s.weights[service]
+	if weights == nil {
+		weights = make(map[string]*weighted)
+		s.weights[service] = weights
+	}
+
+	var best *node.Node
+	var bestW *weighted
+	total := 0
+	for _, n := range list {
+		weight := n.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		w := weights[n.Address]
+		if w == nil || w.weight != weight {
+			w = &weighted{weight: weight, effectiveWeight: weight}
+			weights[n.Address] = w
+		}
+		w.used = now
+
+		w.currentWeight += w.effectiveWeight
+		total += w.effectiveWeight
+		if w.effectiveWeight < w.weight {
+			w.effectiveWeight++
+		}
+
+		if bestW == nil || w.currentWeight > bestW.currentWeight {
+			best, bestW = n, w
+		}
+	}
+	bestW.currentWeight -= total
+
+	if now.Sub(s.swept) > staleTTL {
+		s.sweep(now)
+	}
+	return best, nil
+}
+
+// sweep drops the state of nodes not selectable for staleTTL. The caller must hold s.mu.
+func (s *WeightedRoundRobinSelector) sweep(now time.Time) {
+	s.swept = now
+	for service, weights := range s.weights {
+		for addr, w := range weights {
+			if now.Sub(w.used) > staleTTL {
+				delete(weights, addr)
+			}
+		}
+		if len(weights) == 0 {
+			delete(s.weights, service)
+		}
+	}
+}
+
+// Remove drops the state of a node deleted by discovery.
+func (s *WeightedRoundRobinSelector) Remove(n *node.Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if weights := s.weights[n.ServiceName]; weights != nil {
+		delete(weights, n.Address)
+	}
+}
+
+func (s *WeightedRoundRobinSelector) Name() string {
+	return "weightroundrobin"
+}
@@ -9,7 +9,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/hillguo/sanrpc/errs"
 
 	log "github.com/hillguo/sanlog"
 	"github.com/hillguo/sanrpc/codec"
@@ -235,11 +234,9 @@ func (client *Client) input() {
 		delete(client.pending, seq)
 		client.mutex.Unlock()
 
-		if res.Err == nil {
-			res.Err.Code = 0
-		}
+		resErr := sanrpc.ToError(res.Err)
 		switch {
-		case res.Err.Code != 0:
+		case resErr != nil:
 			if len(res.Header.MetaData) > 0 {
 				meta := make(map[string]string, len(res.Header.MetaData))
 				for k, v := range res.Header.MetaData {
@@ -247,11 +244,7 @@ func (client *Client) input() {
 				}
 				call.ResMetadata = meta
 			}
-			call.Error = &errs.Error{
-				Type: res.Err.Type,
-				Code: res.Err.Code,
-				Msg:  res.Err.Msg,
-			}
+			call.Error = resErr
 			call.done()
 		default:
 			data := res.Data
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 
@@ -23,9 +25,37 @@ var (
 type XClient interface {
 	Go(ctx context.Context, serviceMethod string, args interface{}, reply interface{}, done chan *Call) (*Call, error)
 	Call(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error
+	Broadcast(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) ([]*NodeResult, error)
+	Fork(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error
 	Close() error
 }
 
+// NodeResult is the result returned by one server in Broadcast or Fork.
+type NodeResult struct {
+	Server string
+	Reply  interface{}
+	Err    error
+}
+
+// MultiError aggregates the errors returned by several servers, keyed by server.
+type MultiError struct {
+	Errors map[string]error
+}
+
+func (e *MultiError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for k := range e.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ss := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ss = append(ss, fmt.Sprintf("%s: %v", k, e.Errors[k]))
+	}
+	return fmt.Sprintf("%d server(s) failed: [%s]", len(keys), strings.Join(ss, "; "))
+}
+
 type xClient struct {
 	failMode     FailMode
 	selectMode   selector.SelectMode
@@ -118,29 +148,56 @@ func (c *xClient) selectClient(ctx context.Context, servicePath, serviceMethod s
 	return k, client, err
 }
 
+// withAuth puts the auth token into the request metadata of ctx.
+func (c *xClient) withAuth(ctx context.Context) context.Context {
+	if c.auth == "" {
+		return ctx
+	}
+	metadata := ctx.Value(ReqMetaDataKey)
+	if metadata == nil {
+		metadata = map[string]string{}
+		ctx = context.WithValue(ctx, ReqMetaDataKey, metadata)
+	}
+	m := metadata.(map[string]string)
+	m[SanRPC_AUTH_KEY] = c.auth
+	return ctx
+}
+
+// getCachedClient returns the cached client of server k, or dials a new one.
+// Dialing happens outside c.mu so that a slow server does not block calls to other servers.
 func (c *xClient) getCachedClient(k string) (RPCClient, error) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	client := c.cachedClient[k]
 	if client != nil {
 		if !client.IsClosing() && !client.IsShutdown() {
+			c.mu.Unlock()
 			return client, nil
 		}
 		delete(c.cachedClient, k)
+	}
+	c.mu.Unlock()
+	if client != nil {
 		client.Close()
 	}
-	if client == nil || client.IsShutdown() {
-		network, addr := splitNetworkAndAddress(k)
-		client = &Client{
-			option: c.option,
-		}
-		err := client.Connect(network, addr)
-		if err != nil {
-			return nil, err
-		}
-		c.cachedClient[k] = client
+
+	network, addr := splitNetworkAndAddress(k)
+	newClient := &Client{
+		option: c.option,
+	}
+	if err := newClient.Connect(network, addr); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	// another goroutine may have dialed the same server meanwhile
+	if cl := c.cachedClient[k]; cl != nil && !cl.IsClosing() && !cl.IsShutdown() {
+		c.mu.Unlock()
+		newClient.Close()
+		return cl, nil
 	}
-	return client, nil
+	c.cachedClient[k] = newClient
+	c.mu.Unlock()
+	return newClient, nil
 }
 
 func (c *xClient) removeClient(k string, client RPCClient) {
@@ -170,15 +227,7 @@ func (c *xClient) Go(ctx context.Context, serviceMethod string, args interface{}
 		return nil, ErrXClientShutdown
 	}
 
-	if c.auth != "" {
-		metadata := ctx.Value(ReqMetaDataKey)
-		if metadata == nil {
-			metadata = map[string]string{}
-			ctx = context.WithValue(ctx, ReqMetaDataKey, metadata)
-		}
-		m := metadata.(map[string]string)
-		m[SanRPC_AUTH_KEY] = c.auth
-	}
+	ctx = c.withAuth(ctx)
 
 	_, client, err := c.selectClient(ctx, c.servicePath, serviceMethod, args)
 	if err != nil {
@@ -192,15 +241,7 @@ func (c *xClient) Call(ctx context.Context, serviceMethod string, args interface
 		return ErrXClientShutdown
 	}
 
-	if c.auth != "" {
-		metadata := ctx.Value(ReqMetaDataKey)
-		if metadata == nil {
-			metadata = map[string]string{}
-			ctx = context.WithValue(ctx, ReqMetaDataKey, metadata)
-		}
-		m := metadata.(map[string]string)
-		m[SanRPC_AUTH_KEY] = c.auth
-	}
+	ctx = c.withAuth(ctx)
 
 	var err error
 	k, client, err := c.selectClient(ctx, c.servicePath, serviceMethod, args)
@@ -270,6 +311,150 @@ func (c *xClient) Call(ctx context.Context, serviceMethod string, args interface
 	}
 }
 
+// Broadcast sends the request to all servers and waits for all of them.
+// It returns the result of every server ordered by server key, and a *MultiError if any server failed.
+// reply is filled with the reply of the first successful server in that order.
+func (c *xClient) Broadcast(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) ([]*NodeResult, error) {
+	if c.isShutdown {
+		return nil, ErrXClientShutdown
+	}
+
+	ctx = c.withAuth(ctx)
+
+	servers := c.serverKeys()
+	if len(servers) == 0 {
+		return nil, ErrXClientNoServer
+	}
+
+	type indexed struct {
+		i int
+		r *NodeResult
+	}
+	done := make(chan indexed, len(servers))
+	for i, k := range servers {
+		go func(i int, k string) {
+			done <- indexed{i, c.callNode(ctx, k, serviceMethod, args, reply)}
+		}(i, k)
+	}
+
+	byServer := make([]*NodeResult, len(servers))
+	var ctxErr error
+wait:
+	for range servers {
+		select {
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+			break wait
+		case d := <-done:
+			byServer[d.i] = d.r
+		}
+	}
+
+	results := make([]*NodeResult, 0, len(servers))
+	merr := &MultiError{Errors: make(map[string]error)}
+	replied := false
+	for _, r := range byServer {
+		if r == nil {
+			continue
+		}
+		results = append(results, r)
+		if r.Err != nil {
+			merr.Errors[r.Server] = r.Err
+			continue
+		}
+		if !replied {
+			setReply(reply, r.Reply)
+			replied = true
+		}
+	}
+
+	if ctxErr != nil {
+		return results, ctxErr
+	}
+	if len(merr.Errors) > 0 {
+		return results, merr
+	}
+	return results, nil
+}
+
+// Fork sends the request to all servers and returns as soon as one of them succeeds.
+// reply is filled with the first successful reply. If all servers fail, a *MultiError is returned.
+func (c *xClient) Fork(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	if c.isShutdown {
+		return ErrXClientShutdown
+	}
+
+	ctx = c.withAuth(ctx)
+
+	servers := c.serverKeys()
+	if len(servers) == 0 {
+		return ErrXClientNoServer
+	}
+
+	done := make(chan *NodeResult, len(servers))
+	for _, k := range servers {
+		go func(k string) {
+			done <- c.callNode(ctx, k, serviceMethod, args, reply)
+		}(k)
+	}
+
+	merr := &MultiError{Errors: make(map[string]error)}
+	for range servers {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case r := <-done:
+			if r.Err == nil {
+				setReply(reply, r.Reply)
+				return nil
+			}
+			merr.Errors[r.Server] = r.Err
+		}
+	}
+	return merr
+}
+
+// serverKeys returns a sorted snapshot of the servers found by discovery.
+func (c *xClient) serverKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	servers := make([]string, 0, len(c.servers))
+	for k := range c.servers {
+		servers = append(servers, k)
+	}
+	sort.Strings(servers)
+	return servers
+}
+
+// callNode calls server k with a fresh reply of the same type as reply.
+func (c *xClient) callNode(ctx context.Context, k string, serviceMethod string, args interface{}, reply interface{}) *NodeResult {
+	r := &NodeResult{Server: k}
+	client, err := c.getCachedClient(k)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	if reply != nil {
+		r.Reply = reflect.New(reflect.ValueOf(reply).Elem().Type()).Interface()
+	}
+	r.Err = client.Call(ctx, c.servicePath, serviceMethod, args, r.Reply)
+	if r.Err != nil {
+		if _, ok := r.Err.(ServiceError); !ok {
+			c.removeClient(k, client)
+		}
+	}
+	return r
+}
+
+// setReply copies the reply of one server into the reply given by the caller.
+func setReply(dst interface{}, src interface{}) {
+	if dst == nil || src == nil {
+		return
+	}
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+}
+
 // Close closes this client_bk and its underlying connnections to services.
 func (c *xClient) Close() error {
 	c.isShutdown = true
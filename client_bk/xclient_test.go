@@ -0,0 +1,201 @@
+package client_bk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClient answers every call with its own name, or with err.
+type fakeClient struct {
+	name  string
+	err   error
+	delay time.Duration
+}
+
+func (c *fakeClient) Connect(network, address string) error { return nil }
+
+func (c *fakeClient) Go(ctx context.Context, servicePath, serviceMethod string, args interface{}, reply interface{}, done chan *Call) *Call {
+	return nil
+}
+
+func (c *fakeClient) Call(ctx context.Context, servicePath, serviceMethod string, args interface{}, reply interface{}) error {
+	if c.delay > 0 {
+		select {
+		case <-time.After(c.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if c.err != nil {
+		return c.err
+	}
+	*reply.(*string) = c.name
+	return nil
+}
+
+func (c *fakeClient) Close() error     { return nil }
+func (c *fakeClient) IsClosing() bool  { return false }
+func (c *fakeClient) IsShutdown() bool { return false }
+
+func newFakeXClient(clients ...*fakeClient) *xClient {
+	c := &xClient{
+		servers:      make(map[string]string),
+		cachedClient: make(map[string]RPCClient),
+	}
+	for _, fc := range clients {
+		c.servers[fc.name] = ""
+		c.cachedClient[fc.name] = fc
+	}
+	return c
+}
+
+func TestBroadcast(t *testing.T) {
+	errFail := ServiceError("fail")
+	tests := []struct {
+		name    string
+		clients []*fakeClient
+		reply   string
+		failed  []string
+		wantOK  int
+	}{
+		{
+			name:    "all succeed",
+			clients: []*fakeClient{{name: "s2"}, {name: "s1"}, {name: "s3"}},
+			reply:   "s1",
+			wantOK:  3,
+		},
+		{
+			name:    "some fail",
+			clients: []*fakeClient{{name: "s1", err: errFail}, {name: "s2"}, {name: "s3", err: errFail}},
+			reply:   "s2",
+			failed:  []string{"s1", "s3"},
+			wantOK:  1,
+		},
+		{
+			name:    "all fail",
+			clients: []*fakeClient{{name: "s1", err: errFail}, {name: "s2", err: errFail}},
+			failed:  []string{"s1", "s2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeXClient(tt.clients...)
+			var reply string
+			results, err := c.Broadcast(context.Background(), "Echo", nil, &reply)
+			if len(results) != len(tt.clients) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.clients))
+			}
+			for i := 1; i < len(results); i++ {
+				if results[i-1].Server >= results[i].Server {
+					t.Errorf("results not ordered by server: %s before %s", results[i-1].Server, results[i].Server)
+				}
+			}
+			ok := 0
+			for _, r := range results {
+				if r.Err == nil {
+					ok++
+					if got := *r.Reply.(*string); got != r.Server {
+						t.Errorf("reply of %s = %q", r.Server, got)
+					}
+				}
+			}
+			if ok != tt.wantOK {
+				t.Errorf("got %d successful results, want %d", ok, tt.wantOK)
+			}
+			if reply != tt.reply {
+				t.Errorf("reply = %q, want %q", reply, tt.reply)
+			}
+
+			if len(tt.failed) == 0 {
+				if err != nil {
+					t.Fatalf("Broadcast() error = %v", err)
+				}
+				return
+			}
+			var merr *MultiError
+			if !errors.As(err, &merr) {
+				t.Fatalf("Broadcast() error = %v, want *MultiError", err)
+			}
+			if len(merr.Errors) != len(tt.failed) {
+				t.Errorf("MultiError has %d errors, want %d", len(merr.Errors), len(tt.failed))
+			}
+			for _, s := range tt.failed {
+				if merr.Errors[s] != errFail {
+					t.Errorf("error of %s = %v, want %v", s, merr.Errors[s], errFail)
+				}
+			}
+		})
+	}
+}
+
+func TestBroadcastNoServer(t *testing.T) {
+	c := newFakeXClient()
+	if _, err := c.Broadcast(context.Background(), "Echo", nil, nil); err != ErrXClientNoServer {
+		t.Fatalf("Broadcast() error = %v, want %v", err, ErrXClientNoServer)
+	}
+}
+
+func TestFork(t *testing.T) {
+	errFail := ServiceError("fail")
+	tests := []struct {
+		name    string
+		clients []*fakeClient
+		reply   string
+		failed  []string
+	}{
+		{
+			name:    "fastest success wins",
+			clients: []*fakeClient{{name: "s1", delay: time.Second}, {name: "s2"}},
+			reply:   "s2",
+		},
+		{
+			name:    "failures are skipped",
+			clients: []*fakeClient{{name: "s1", err: errFail}, {name: "s2", delay: 10 * time.Millisecond}},
+			reply:   "s2",
+		},
+		{
+			name:    "all fail",
+			clients: []*fakeClient{{name: "s1", err: errFail}, {name: "s2", err: errFail}},
+			failed:  []string{"s1", "s2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeXClient(tt.clients...)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var reply string
+			err := c.Fork(ctx, "Echo", nil, &reply)
+			if len(tt.failed) == 0 {
+				if err != nil {
+					t.Fatalf("Fork() error = %v", err)
+				}
+				if reply != tt.reply {
+					t.Errorf("reply = %q, want %q", reply, tt.reply)
+				}
+				return
+			}
+			var merr *MultiError
+			if !errors.As(err, &merr) {
+				t.Fatalf("Fork() error = %v, want *MultiError", err)
+			}
+			for _, s := range tt.failed {
+				if merr.Errors[s] != errFail {
+					t.Errorf("error of %s = %v, want %v", s, merr.Errors[s], errFail)
+				}
+			}
+		})
+	}
+}
+
+func TestForkContextDone(t *testing.T) {
+	c := newFakeXClient(&fakeClient{name: "s1", delay: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var reply string
+	if err := c.Fork(ctx, "Echo", nil, &reply); err != context.DeadlineExceeded {
+		t.Fatalf("Fork() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+)
+
+var (
+	errTimeout    = errs.NewStatusError(errs.StatusDeadlineExceeded, "timeout")
+	errConnClosed = errs.NewStatusError(errs.StatusUnavailable, "connection closed")
+)
+
+// conn 一条复用的连接，请求按 Seq 匹配响应，可以并发调用
+type conn struct {
+	c     net.Conn
+	proto *sanrpc.SanRPCProtocol
+
+	wmu sync.Mutex // 保护写
+
+	mu      sync.Mutex
+	seq     uint64
+	pending map[uint64]chan *sanrpc.MessageProtocol
+	err     error // 读失败后所有调用返回此错误
+}
+
+func dial(network, addr string, timeout time.Duration) (*conn, error) {
+	c, err := net.DialTimeout(network, addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{
+		c:       c,
+		proto:   &sanrpc.SanRPCProtocol{},
+		pending: make(map[uint64]chan *sanrpc.MessageProtocol),
+	}
+	go cn.readLoop()
+	return cn, nil
+}
+
+func (cn *conn) readLoop() {
+	for {
+		msg, err := cn.proto.DecodeMessage(cn.c)
+		if err != nil {
+			cn.mu.Lock()
+			cn.err = err
+			for seq, ch := range cn.pending {
+				close(ch)
+				delete(cn.pending, seq)
+			}
+			cn.mu.Unlock()
+			return
+		}
+		resp, _ := msg.(*sanrpc.MessageProtocol)
+		if resp == nil || resp.Header == nil {
+			continue
+		}
+		cn.mu.Lock()
+		ch, ok := cn.pending[resp.Header.Seq]
+		delete(cn.pending, resp.Header.Seq)
+		cn.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// call 发送 req 的一个拷贝并等待响应，req.Header 的 Seq 会被替换
+func (cn *conn) call(req *sanrpc.MessageProtocol, timeout time.Duration) (*sanrpc.MessageProtocol, error) {
+	ch := make(chan *sanrpc.MessageProtocol, 1)
+	cn.mu.Lock()
+	if cn.err != nil {
+		cn.mu.Unlock()
+		return nil, errConnClosed
+	}
+	cn.seq++
+	seq := cn.seq
+	cn.pending[seq] = ch
+	cn.mu.Unlock()
+
+	header := *req.Header
+	header.Seq = seq
+	data, err := cn.proto.EncodeMessage(&sanrpc.MessageProtocol{Header: &header, Data: req.Data})
+	if err != nil {
+		cn.cancel(seq)
+		return nil, err
+	}
+	cn.wmu.Lock()
+	_ = cn.c.SetWriteDeadline(time.Now().Add(timeout))
+	_, err = cn.c.Write(data)
+	cn.wmu.Unlock()
+	if err != nil {
+		cn.cancel(seq)
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, errConnClosed
+		}
+		return resp, nil
+	case <-timer.C:
+		cn.cancel(seq)
+		return nil, errTimeout
+	}
+}
+
+func (cn *conn) cancel(seq uint64) {
+	cn.mu.Lock()
+	delete(cn.pending, seq)
+	cn.mu.Unlock()
+}
+
+func (cn *conn) Close() error {
+	return cn.c.Close()
+}
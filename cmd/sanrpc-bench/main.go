@@ -0,0 +1,227 @@
+// sanrpc-bench sanrpc 压测工具，按固定 QPS 或固定并发压测一个方法，
+// 输出吞吐、分位耗时、耗时直方图和按错误码分类的错误数。
+//
+//	sanrpc-bench -serve 127.0.0.1:8000
+//	sanrpc-bench -addr 127.0.0.1:8000 -conns 4 -c 64 -d 30s -size 1024 -codec pb -compress gzip
+//
+// 默认压测 -serve 启动的 bench.echo，压测其他方法时用 -data 指定按 -codec 编码好的请求体。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hillguo/sanrpc/cmd/sanrpc-bench/benchpb"
+	"github.com/hillguo/sanrpc/codec"
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+	"github.com/hillguo/sanrpc/service"
+)
+
+var (
+	serve       = flag.String("serve", "", "run the bench echo server on this address instead of load testing")
+	addr        = flag.String("addr", "127.0.0.1:8000", "target address")
+	network     = flag.String("network", "tcp", "target network")
+	serviceName = flag.String("service", "bench", "target service")
+	methodName  = flag.String("method", "echo", "target method")
+	conns       = flag.Int("conns", 1, "number of connections")
+	concurrency = flag.Int("c", 10, "number of concurrent callers")
+	qps         = flag.Int("qps", 0, "target requests per second, 0 for as fast as possible")
+	duration    = flag.Duration("d", 10*time.Second, "test duration")
+	total       = flag.Int64("n", 0, "stop after n requests, 0 for no limit")
+	size        = flag.Int("size", 128, "payload size of bench.echo in bytes")
+	dataFile    = flag.String("data", "", "file with the encoded request body, overrides -size")
+	codecName   = flag.String("codec", "pb", "request codec: pb, json, msgpack, gob or cbor")
+	compress    = flag.String("compress", "none", "request compression: none or gzip")
+	timeout     = flag.Duration("timeout", 5*time.Second, "call timeout")
+)
+
+var codecs = map[string]codec.SerializeType{
+	"json":    codec.JSON,
+	"pb":      codec.ProtoBuffer,
+	"msgpack": codec.MsgPack,
+	"gob":     codec.Gob,
+	"cbor":    codec.CBOR,
+}
+
+var compressors = map[string]codec.CompressType{
+	"none": codec.CompressNone,
+	"gzip": codec.Gzip,
+}
+
+// Bench 回显服务
+type Bench struct{}
+
+// Echo 原样返回请求
+func (b *Bench) Echo(ctx context.Context, req *benchpb.Payload, resp *benchpb.Payload) error {
+	resp.Data = req.Data
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	if *serve != "" {
+		s := service.New(service.WithServiceName("bench"), service.WithAddress(*serve))
+		if err := s.Register(&Bench{}); err != nil {
+			fatal(err)
+		}
+		if err := s.Serve(); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	req, err := buildRequest()
+	if err != nil {
+		fatal(err)
+	}
+	cs := make([]*conn, *conns)
+	for i := range cs {
+		if cs[i], err = dial(*network, *addr, *timeout); err != nil {
+			fatal(err)
+		}
+		defer cs[i].Close()
+	}
+
+	var tokens <-chan struct{}
+	done := make(chan struct{})
+	if *qps > 0 {
+		tokens = limiter(*qps, done)
+	}
+	var sent int64
+	results := make([]*stats, *concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	deadline := start.Add(*duration)
+	for i := range results {
+		results[i] = newStats()
+		wg.Add(1)
+		go func(st *stats, cn *conn) {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				if tokens != nil {
+					select {
+					case <-tokens:
+					case <-time.After(time.Until(deadline)):
+						return
+					}
+				}
+				if *total > 0 && atomic.AddInt64(&sent, 1) > *total {
+					return
+				}
+				t0 := time.Now()
+				resp, err := cn.call(req, *timeout)
+				key, sample := errorKey(resp, err)
+				st.record(time.Since(t0), key, sample)
+			}
+		}(results[i], cs[i%len(cs)])
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(done)
+
+	sum := newStats()
+	for _, st := range results {
+		sum.merge(st)
+	}
+	fmt.Printf("target: %s %s.%s, conns %d, concurrency %d, qps %d, codec %s, compress %s, request %d bytes\n\n",
+		*addr, *serviceName, *methodName, *conns, *concurrency, *qps, *codecName, *compress, len(req.Data))
+	sum.report(os.Stdout, elapsed)
+}
+
+// buildRequest 构造请求模板，请求体只编码、压缩一次
+func buildRequest() (*sanrpc.MessageProtocol, error) {
+	st, ok := codecs[*codecName]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %q", *codecName)
+	}
+	ct, ok := compressors[*compress]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression %q", *compress)
+	}
+
+	var body []byte
+	var err error
+	if *dataFile != "" {
+		body, err = ioutil.ReadFile(*dataFile)
+	} else {
+		payload := make([]byte, *size)
+		for i := range payload {
+			payload[i] = byte('a' + i%26)
+		}
+		body, err = codec.Codecs[st].Encode(&benchpb.Payload{Data: payload})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if body, err = codec.Compressors[ct].Zip(body); err != nil {
+		return nil, err
+	}
+	return &sanrpc.MessageProtocol{
+		Header: &sanrpc.HeaderMsg{
+			CallType:     uint32(sanrpc.SanrpcMsgType_SANRPC_REQUEST_MSG),
+			ServiceName:  *serviceName,
+			MethodName:   *methodName,
+			EncodeType:   uint32(st),
+			CompressType: uint32(ct),
+		},
+		Data: body,
+	}, nil
+}
+
+// limiter 每 10ms 按 qps 发放一批令牌，直到 done 关闭
+func limiter(qps int, done <-chan struct{}) <-chan struct{} {
+	tokens := make(chan struct{}, qps)
+	go func() {
+		const tick = 10 * time.Millisecond
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		last := time.Now()
+		var credit float64
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				credit += now.Sub(last).Seconds() * float64(qps)
+				last = now
+				for ; credit >= 1; credit-- {
+					select {
+					case tokens <- struct{}{}:
+					default:
+						// 调用方跟不上时丢弃令牌，不积压
+					}
+				}
+			}
+		}
+	}()
+	return tokens
+}
+
+// errorKey 返回错误分类和一条错误信息作为样例，成功时 key 为空。
+// 按状态码和错误类型、错误码分类，错误信息中常带有请求相关的内容，不参与分类
+func errorKey(resp *sanrpc.MessageProtocol, err error) (key, sample string) {
+	if err != nil {
+		return fmt.Sprintf("transport %v", errs.Status(err)), err.Error()
+	}
+	e, _ := sanrpc.ToError(resp.GetErr()).(*errs.Error)
+	if e == nil {
+		return "", ""
+	}
+	typ := "business"
+	if e.Type == errs.ErrorTypeFramework {
+		typ = "framework"
+	}
+	return fmt.Sprintf("%s %d %v", typ, e.Code, errs.Status(e)), e.Msg
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// percentiles report 输出的分位
+var percentiles = []float64{50, 90, 99, 99.9}
+
+// stats 汇总压测结果，每个 worker 一个，结束后合并
+type stats struct {
+	latencies []time.Duration   // 成功调用的耗时
+	errors    map[string]int64  // 错误分类 => 次数
+	samples   map[string]string // 错误分类 => 第一次出现的错误信息
+}
+
+func newStats() *stats {
+	return &stats{errors: make(map[string]int64), samples: make(map[string]string)}
+}
+
+func (s *stats) record(cost time.Duration, errKey, sample string) {
+	if errKey == "" {
+		s.latencies = append(s.latencies, cost)
+		return
+	}
+	if _, ok := s.errors[errKey]; !ok {
+		s.samples[errKey] = sample
+	}
+	s.errors[errKey]++
+}
+
+func (s *stats) merge(o *stats) {
+	s.latencies = append(s.latencies, o.latencies...)
+	for k, v := range o.errors {
+		if _, ok := s.errors[k]; !ok {
+			s.samples[k] = o.samples[k]
+		}
+		s.errors[k] += v
+	}
+}
+
+// summary 压测结果的汇总，耗时只统计成功的调用
+type summary struct {
+	total, ok, failed int64
+	qps               float64
+	min, mean, max    time.Duration
+	percentiles       []time.Duration // 与 percentiles 一一对应
+	errors            []errorCount    // 按错误分类排序
+}
+
+// errorCount 一类错误的次数和样例
+type errorCount struct {
+	key    string
+	count  int64
+	sample string
+}
+
+// summarize 汇总 elapsed 时间内的压测结果，会对 latencies 排序
+func (s *stats) summarize(elapsed time.Duration) *summary {
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	sum := &summary{ok: int64(len(s.latencies))}
+	for k, n := range s.errors {
+		sum.failed += n
+		sum.errors = append(sum.errors, errorCount{key: k, count: n, sample: s.samples[k]})
+	}
+	sort.Slice(sum.errors, func(i, j int) bool { return sum.errors[i].key < sum.errors[j].key })
+	sum.total = sum.ok + sum.failed
+	sum.qps = throughput(sum.total, elapsed)
+	if len(s.latencies) > 0 {
+		var total time.Duration
+		for _, l := range s.latencies {
+			total += l
+		}
+		sum.min = s.latencies[0]
+		sum.mean = total / time.Duration(len(s.latencies))
+		sum.max = s.latencies[len(s.latencies)-1]
+		for _, p := range percentiles {
+			sum.percentiles = append(sum.percentiles, percentile(s.latencies, p))
+		}
+	}
+	return sum
+}
+
+// report 输出吞吐、分位耗时、耗时直方图和错误分类
+func (s *stats) report(w io.Writer, elapsed time.Duration) {
+	sum := s.summarize(elapsed)
+	fmt.Fprintf(w, "requests:   %d (ok %d, failed %d)\n", sum.total, sum.ok, sum.failed)
+	fmt.Fprintf(w, "elapsed:    %v\n", elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "throughput: %.1f req/s\n", sum.qps)
+	if sum.ok > 0 {
+		fmt.Fprintf(w, "\nlatency:\n")
+		fmt.Fprintf(w, "  min   %v\n", sum.min)
+		fmt.Fprintf(w, "  mean  %v\n", sum.mean)
+		for i, p := range percentiles {
+			fmt.Fprintf(w, "  p%-4v %v\n", p, sum.percentiles[i])
+		}
+		fmt.Fprintf(w, "  max   %v\n", sum.max)
+		s.histogram(w)
+	}
+	if sum.failed > 0 {
+		fmt.Fprintf(w, "\nerrors:\n")
+		for _, e := range sum.errors {
+			fmt.Fprintf(w, "  %-40s %d  e.g. %s\n", e.key, e.count, e.sample)
+		}
+	}
+}
+
+// throughput elapsed 时间内完成 total 个请求的吞吐，elapsed 不大于 0 时为 0
+func throughput(total int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(total) / elapsed.Seconds()
+}
+
+// percentile 按最近排名法取第 p 分位，调用方需保证 sorted 已排序且不为空
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// histogram 按 2 的幂划分耗时区间，调用方需保证 latencies 已排序
+func (s *stats) histogram(w io.Writer) {
+	const width = 40
+	bounds, counts := buckets(s.latencies)
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+	fmt.Fprintf(w, "\nhistogram:\n")
+	for i, b := range bounds {
+		bar := strings.Repeat("#", counts[i]*width/max)
+		fmt.Fprintf(w, "  <= %-10v %8d %s\n", b, counts[i], bar)
+	}
+}
+
+// buckets 从 100µs 起按 2 的幂划分已排序的耗时，只返回有调用的区间上界和次数
+func buckets(sorted []time.Duration) (bounds []time.Duration, counts []int) {
+	bound := 100 * time.Microsecond
+	for _, l := range sorted {
+		for l > bound {
+			bound *= 2
+		}
+		if len(bounds) == 0 || bounds[len(bounds)-1] != bound {
+			bounds = append(bounds, bound)
+			counts = append(counts, 0)
+		}
+		counts[len(counts)-1]++
+	}
+	return bounds, counts
+}
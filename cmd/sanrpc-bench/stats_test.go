@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+)
+
+func ms(n ...int) []time.Duration {
+	ds := make([]time.Duration, len(n))
+	for i, v := range n {
+		ds[i] = time.Duration(v) * time.Millisecond
+	}
+	return ds
+}
+
+func TestPercentile(t *testing.T) {
+	hundred := make([]int, 100)
+	for i := range hundred {
+		hundred[i] = i + 1
+	}
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{name: "single", sorted: ms(7), p: 50, want: 7 * time.Millisecond},
+		{name: "single p0", sorted: ms(7), p: 0, want: 7 * time.Millisecond},
+		{name: "single p100", sorted: ms(7), p: 100, want: 7 * time.Millisecond},
+		{name: "p50 of 100", sorted: ms(hundred...), p: 50, want: 50 * time.Millisecond},
+		{name: "p90 of 100", sorted: ms(hundred...), p: 90, want: 90 * time.Millisecond},
+		{name: "p99 of 100", sorted: ms(hundred...), p: 99, want: 99 * time.Millisecond},
+		{name: "p99.9 of 100", sorted: ms(hundred...), p: 99.9, want: 100 * time.Millisecond},
+		{name: "p0 clamps to min", sorted: ms(1, 2, 3), p: 0, want: 1 * time.Millisecond},
+		{name: "p100 is max", sorted: ms(1, 2, 3), p: 100, want: 3 * time.Millisecond},
+		{name: "p50 of 4 rounds", sorted: ms(1, 2, 3, 4), p: 50, want: 2 * time.Millisecond},
+		{name: "p90 of 4 rounds", sorted: ms(1, 2, 3, 4), p: 90, want: 4 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	tests := []struct {
+		total   int64
+		elapsed time.Duration
+		want    float64
+	}{
+		{total: 1000, elapsed: time.Second, want: 1000},
+		{total: 1000, elapsed: 2 * time.Second, want: 500},
+		{total: 300, elapsed: 100 * time.Millisecond, want: 3000},
+		{total: 0, elapsed: time.Second, want: 0},
+		{total: 10, elapsed: 0, want: 0},
+		{total: 10, elapsed: -time.Second, want: 0},
+	}
+	for _, tt := range tests {
+		if got := throughput(tt.total, tt.elapsed); got != tt.want {
+			t.Errorf("throughput(%d, %v) = %v, want %v", tt.total, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	// 两个 worker 的结果合并后汇总，同一错误分类的次数相加，样例取先合并的一个
+	a, b := newStats(), newStats()
+	for _, l := range ms(30, 10, 20) {
+		a.record(l, "", "")
+	}
+	a.record(time.Second, "transport Unavailable", "dial 127.0.0.1:1: refused")
+	a.record(time.Second, "transport Unavailable", "dial 127.0.0.1:2: refused")
+	b.record(40*time.Millisecond, "", "")
+	b.record(time.Second, "transport Unavailable", "dial 127.0.0.1:3: refused")
+	b.record(time.Second, "business 1001 Unknown", "no user")
+
+	sum := newStats()
+	sum.merge(a)
+	sum.merge(b)
+	got := sum.summarize(2 * time.Second)
+
+	want := &summary{
+		total:       8,
+		ok:          4,
+		failed:      4,
+		qps:         4,
+		min:         10 * time.Millisecond,
+		mean:        25 * time.Millisecond,
+		max:         40 * time.Millisecond,
+		percentiles: ms(20, 40, 40, 40),
+		errors: []errorCount{
+			{key: "business 1001 Unknown", count: 1, sample: "no user"},
+			{key: "transport Unavailable", count: 3, sample: "dial 127.0.0.1:1: refused"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summarize() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSummarizeNoSuccess(t *testing.T) {
+	s := newStats()
+	s.record(time.Second, "transport DeadlineExceeded", "timeout")
+	got := s.summarize(time.Second)
+	if got.total != 1 || got.ok != 0 || got.failed != 1 || got.percentiles != nil || got.min != 0 {
+		t.Errorf("summarize() = %+v", got)
+	}
+
+	var buf bytes.Buffer
+	s.report(&buf, time.Second)
+	if out := buf.String(); strings.Contains(out, "latency") || !strings.Contains(out, "transport DeadlineExceeded") {
+		t.Errorf("report() =\n%s", out)
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	bounds, counts := buckets([]time.Duration{
+		50 * time.Microsecond,
+		100 * time.Microsecond,
+		150 * time.Microsecond,
+		700 * time.Microsecond,
+		800 * time.Microsecond,
+	})
+	wantBounds := []time.Duration{100 * time.Microsecond, 200 * time.Microsecond, 800 * time.Microsecond}
+	wantCounts := []int{2, 1, 2}
+	if !reflect.DeepEqual(bounds, wantBounds) || !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("buckets() = %v %v, want %v %v", bounds, counts, wantBounds, wantCounts)
+	}
+}
+
+func TestErrorKey(t *testing.T) {
+	errResp := func(e *errs.Error) *sanrpc.MessageProtocol {
+		return &sanrpc.MessageProtocol{Err: &sanrpc.ErrMsg{Type: e.Type, Code: e.Code, Msg: e.Msg}}
+	}
+	tests := []struct {
+		name       string
+		resp       *sanrpc.MessageProtocol
+		err        error
+		wantKey    string
+		wantSample string
+	}{
+		{name: "ok", resp: &sanrpc.MessageProtocol{}},
+		{
+			name:       "transport",
+			err:        fmt.Errorf("read: %w", context.DeadlineExceeded),
+			wantKey:    "transport DeadlineExceeded",
+			wantSample: "read: context deadline exceeded",
+		},
+		{
+			name:       "framework",
+			resp:       errResp(errs.ErrServerOverload),
+			wantKey:    fmt.Sprintf("framework %d %v", errs.ErrServerOverload.Code, errs.Status(errs.ErrServerOverload)),
+			wantSample: "server overload",
+		},
+		{
+			name:       "business",
+			resp:       errResp(errs.New(1001, "no user 42")),
+			wantKey:    "business 1001 Unknown",
+			wantSample: "no user 42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, sample := errorKey(tt.resp, tt.err)
+			if key != tt.wantKey || sample != tt.wantSample {
+				t.Errorf("errorKey() = %q, %q, want %q, %q", key, sample, tt.wantKey, tt.wantSample)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// sanrpc-registry 轻量的注册中心，以 sanrpc 服务 registry 提供注册、注销和长轮询查询。
+//
+//	sanrpc-registry -addr 0.0.0.0:8500 -data registry.json
+//
+// 服务端通过 registryclient.RegisterRegistry 注册，客户端通过 registryclient.RegisterDiscovery 发现。
+package main
+
+import (
+	"flag"
+	"time"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc"
+	"github.com/hillguo/sanrpc/naming/registry"
+	"github.com/hillguo/sanrpc/naming/registry/registryclient"
+	"github.com/hillguo/sanrpc/naming/registry/registryserver"
+	"github.com/hillguo/sanrpc/service"
+)
+
+var (
+	addr  = flag.String("addr", "0.0.0.0:8500", "listen address")
+	data  = flag.String("data", "", "persist instances to this file, empty to keep in memory only")
+	sweep = flag.Duration("sweep", time.Second, "interval to remove expired instances")
+)
+
+func main() {
+	flag.Parse()
+
+	store := registry.NewStore(*sweep)
+	defer store.Close()
+
+	done := make(chan struct{})
+	persisted := make(chan struct{})
+	if *data != "" {
+		if err := registryserver.Load(store, *data); err != nil {
+			log.Errorf("registry: load %s fail: %v", *data, err)
+			return
+		}
+		go func() {
+			registryserver.Persist(store, *data, done)
+			close(persisted)
+		}()
+	} else {
+		close(persisted)
+	}
+
+	svr := sanrpc.NewServer()
+	svr.AddService(registryclient.ServiceName, service.New(
+		service.WithServiceName(registryclient.ServiceName),
+		service.WithAddress(*addr)))
+	svr.Register(registryserver.New(store))
+	svr.Serve()
+	// 服务已经关闭，等最后一次保存完成再退出
+	close(done)
+	<-persisted
+}
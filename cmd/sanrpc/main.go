@@ -0,0 +1,270 @@
+// sanrpc 调试 sanrpc 服务的命令行客户端。
+//
+//	sanrpc list host:port
+//	sanrpc describe host:port [service[.method] | message]
+//	sanrpc call host:port service.method '{"a":1}'
+//
+// 默认通过服务端的 reflection 服务获取消息定义，指定 -proto 时使用本地 .proto 文件，
+// .proto 文件中没有定义服务时仍通过 reflection 列出服务和方法。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hillguo/sanrpc/client"
+	"github.com/hillguo/sanrpc/metadata"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// stringList 可以重复指定的参数
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+var (
+	protoFiles  stringList
+	importPaths stringList
+	headers     stringList
+	timeout     = flag.Duration("timeout", 5*time.Second, "connect/read/write timeout")
+)
+
+func init() {
+	flag.Var(&protoFiles, "proto", "use local .proto file instead of server reflection, repeatable")
+	flag.Var(&importPaths, "I", "import path for -proto, repeatable")
+	flag.Var(&headers, "H", "request metadata key=value, repeatable")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), `usage:
+  sanrpc [flags] list host:port
+  sanrpc [flags] describe host:port [service[.method] | message]
+  sanrpc [flags] call host:port service.method [json]
+
+flags:
+`)
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, addr, rest := args[0], args[1], args[2:]; cmd {
+	case "list":
+		err = list(os.Stdout, newSource(addr))
+	case "describe":
+		err = describe(os.Stdout, newSource(addr), rest)
+	case "call":
+		err = call(os.Stdout, newSource(addr), addr, rest, headers)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func clientOptions() []client.Option {
+	return []client.Option{
+		client.WithConnectTimeout(uint64(*timeout)),
+		client.WithReadTimeout(uint64(*timeout)),
+		client.WithWriteTimeout(uint64(*timeout)),
+	}
+}
+
+func newSource(addr string) source {
+	remote := newReflectionSource(addr, clientOptions()...)
+	if len(protoFiles) == 0 {
+		return remote
+	}
+	src, err := newProtoSource(importPaths, protoFiles, remote)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	return src
+}
+
+func list(w io.Writer, src source) error {
+	services, err := src.Services()
+	if err != nil {
+		return err
+	}
+	for _, s := range services {
+		fmt.Fprintln(w, s.Name)
+		for _, m := range s.Methods {
+			fmt.Fprintf(w, "  %s.%s\n", s.Name, m.Name)
+		}
+	}
+	return nil
+}
+
+func describe(w io.Writer, src source, args []string) error {
+	if len(args) == 0 {
+		return list(w, src)
+	}
+	name := args[0]
+	services, err := src.Services()
+	if err != nil {
+		return err
+	}
+	for _, s := range services {
+		if strings.EqualFold(s.Name, name) {
+			fmt.Fprintf(w, "service %s {\n", s.Name)
+			for _, m := range s.Methods {
+				fmt.Fprintf(w, "  rpc %s(%s) returns (%s);\n", m.Name, m.Arg, m.Reply)
+			}
+			fmt.Fprintln(w, "}")
+			return nil
+		}
+		for _, m := range s.Methods {
+			if strings.EqualFold(s.Name+"."+m.Name, name) {
+				fmt.Fprintf(w, "rpc %s.%s(%s) returns (%s);\n", s.Name, m.Name, m.Arg, m.Reply)
+				for _, msg := range []string{m.Arg, m.Reply} {
+					if err := printMessage(w, src, msg); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+	}
+	return printMessage(w, src, name)
+}
+
+func printMessage(w io.Writer, src source, name string) error {
+	if name == "" {
+		return nil
+	}
+	md, err := src.Message(name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nmessage %s {\n", md.GetFullyQualifiedName())
+	for _, fd := range md.GetFields() {
+		label := ""
+		if fd.IsRepeated() && !fd.IsMap() {
+			label = "repeated "
+		}
+		fmt.Fprintf(w, "  %s%s %s = %d;\n", label, fieldType(fd), fd.GetName(), fd.GetNumber())
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+func fieldType(fd *desc.FieldDescriptor) string {
+	if fd.IsMap() {
+		return fmt.Sprintf("map<%s, %s>", fieldType(fd.GetMapKeyType()), fieldType(fd.GetMapValueType()))
+	}
+	if md := fd.GetMessageType(); md != nil {
+		return md.GetFullyQualifiedName()
+	}
+	if ed := fd.GetEnumType(); ed != nil {
+		return ed.GetFullyQualifiedName()
+	}
+	return strings.ToLower(strings.TrimPrefix(fd.GetType().String(), "TYPE_"))
+}
+
+// call 调用 args[0] 指定的 service.method，args[1] 为 JSON 格式的请求，省略时为 {}
+func call(w io.Writer, src source, addr string, args []string, headers []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing service.method")
+	}
+	serviceName, methodName, err := splitMethod(args[0])
+	if err != nil {
+		return err
+	}
+	body := "{}"
+	if len(args) > 1 {
+		body = args[1]
+	}
+	md, err := parseHeaders(headers)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMethod(src, serviceName, methodName)
+	if err != nil {
+		return err
+	}
+	if m.Arg == "" || m.Reply == "" {
+		return fmt.Errorf("%s.%s does not use protobuf messages", serviceName, methodName)
+	}
+	argmd, err := src.Message(m.Arg)
+	if err != nil {
+		return err
+	}
+	replymd, err := src.Message(m.Reply)
+	if err != nil {
+		return err
+	}
+	req := dynamic.NewMessage(argmd)
+	if err := req.UnmarshalJSON([]byte(body)); err != nil {
+		return fmt.Errorf("parse request: %v", err)
+	}
+	resp := dynamic.NewMessage(replymd)
+
+	ctx := context.Background()
+	if len(md) > 0 {
+		ctx = metadata.NewContext(ctx, md)
+	}
+
+	opts := append([]client.Option{
+		client.WithAddress(addr),
+		client.WithServiceName(serviceName),
+		client.WithMethodName(methodName),
+	}, clientOptions()...)
+	c := client.NewClient(opts...)
+	defer c.Close()
+	if err := c.Invoke(ctx, req, resp); err != nil {
+		return err
+	}
+	out, err := resp.MarshalJSONIndent()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(out))
+	return nil
+}
+
+// splitMethod 把 service.method 拆分为服务名和方法名，服务名可以包含 .
+func splitMethod(s string) (serviceName, methodName string, err error) {
+	i := strings.LastIndex(s, ".")
+	if i <= 0 || i == len(s)-1 {
+		return "", "", fmt.Errorf("invalid method %q, want service.method", s)
+	}
+	return s[:i], s[i+1:], nil
+}
+
+// parseHeaders 解析 -H 指定的 key=value 元数据
+func parseHeaders(headers []string) (metadata.MD, error) {
+	md := metadata.MD{}
+	for _, h := range headers {
+		kv := strings.SplitN(h, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid metadata %q, want key=value", h)
+		}
+		md[kv[0]] = kv[1]
+	}
+	return md, nil
+}
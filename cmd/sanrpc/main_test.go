@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/metadata"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+	"github.com/hillguo/sanrpc/service"
+	"github.com/hillguo/sanrpc/service/healthpb"
+)
+
+// Greeter 请求和响应为 protobuf 消息的测试服务
+type Greeter struct{}
+
+// Hello 返回请求元数据 status 指定的状态
+func (g *Greeter) Hello(ctx context.Context, req *healthpb.CheckReq, resp *healthpb.CheckResp) error {
+	if req.GetService() == "" {
+		return errs.NewStatusError(errs.StatusInvalidArgument, "service required")
+	}
+	md, _ := metadata.FromContext(ctx)
+	resp.Status = healthpb.ServingStatus(healthpb.ServingStatus_value[md["status"]])
+	return nil
+}
+
+type plainReq struct{ A int }
+
+// Plain 参数不是 protobuf 消息的测试服务
+type Plain struct{}
+
+func (p *Plain) Do(ctx context.Context, req *plainReq, resp *plainReq) error {
+	return nil
+}
+
+// startServer 在随机端口启动注册了 Greeter 和 Plain 的服务，返回监听地址和关闭服务的函数
+func startServer(t *testing.T) (string, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := service.New(service.WithServiceName("greeter"), service.WithAddress(addr),
+		service.WithMsgProtocol(&sanrpc.SanRPCProtocol{}), service.WithShutdownGrace(0))
+	for _, svc := range []interface{}{&Greeter{}, &Plain{}} {
+		if err := s.Register(svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go s.Serve()
+	for deadline := time.Now().Add(time.Second); ; {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return addr, func() { s.Close() }
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server not listening: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSplitMethod(t *testing.T) {
+	tests := []struct {
+		in      string
+		service string
+		method  string
+		ok      bool
+	}{
+		{in: "greeter.hello", service: "greeter", method: "hello", ok: true},
+		{in: "pkg.greeter.hello", service: "pkg.greeter", method: "hello", ok: true},
+		{in: "greeter"},
+		{in: ".hello"},
+		{in: "greeter."},
+	}
+	for _, tt := range tests {
+		service, method, err := splitMethod(tt.in)
+		if (err == nil) != tt.ok || service != tt.service || method != tt.method {
+			t.Errorf("splitMethod(%q) = %q, %q, %v", tt.in, service, method, err)
+		}
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	md, err := parseHeaders([]string{"env=test", "token=a=b", "empty="})
+	want := metadata.MD{"env": "test", "token": "a=b", "empty": ""}
+	if err != nil || !reflect.DeepEqual(md, want) {
+		t.Errorf("parseHeaders() = %v, %v, want %v", md, err, want)
+	}
+	if _, err := parseHeaders([]string{"env"}); err == nil {
+		t.Errorf("parseHeaders() without = succeeded")
+	}
+}
+
+func TestList(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+	src := newReflectionSource(addr)
+	var out bytes.Buffer
+	if err := list(&out, src); err != nil {
+		t.Fatalf("list() error = %v", err)
+	}
+	want := `greeter
+  greeter.hello
+health
+  health.check
+  health.watch
+plain
+  plain.do
+reflection
+  reflection.filecontainingmessage
+  reflection.listservices
+`
+	if out.String() != want {
+		t.Errorf("list() output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+	src := newReflectionSource(addr)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Greeter", want: `service greeter {
+  rpc hello(healthpb.CheckReq) returns (healthpb.CheckResp);
+}
+`},
+		{name: "greeter.hello", want: `rpc greeter.hello(healthpb.CheckReq) returns (healthpb.CheckResp);
+
+message healthpb.CheckReq {
+  string service = 1;
+}
+
+message healthpb.CheckResp {
+  healthpb.ServingStatus status = 1;
+}
+`},
+		{name: "plain.do", want: "rpc plain.do() returns ();\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := describe(&out, src, []string{tt.name}); err != nil {
+				t.Fatalf("describe() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("describe() output:\n%s\nwant:\n%s", out.String(), tt.want)
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	if err := describe(&out, src, nil); err != nil || !strings.HasPrefix(out.String(), "greeter\n") {
+		t.Errorf("describe() without name = %q, %v, want the service list", out.String(), err)
+	}
+	if err := describe(&out, src, []string{"nope.Msg"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("describe() of an unknown message error = %v", err)
+	}
+}
+
+func TestDescribeProtoFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sanrpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	const file = `syntax = "proto3";
+package demo;
+
+enum Kind {
+  KIND_NONE = 0;
+}
+
+message Item {
+  string name = 1;
+}
+
+message Order {
+  repeated Item items = 1;
+  map<string, int64> counts = 2;
+  Kind kind = 3;
+  repeated uint32 ids = 4;
+  bytes data = 5;
+}
+
+service Shop {
+  rpc Buy(Order) returns (Item);
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "demo.proto"), []byte(file), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := newProtoSource([]string{dir}, []string{"demo.proto"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := describe(&out, src, []string{"shop.buy"}); err != nil {
+		t.Fatalf("describe() error = %v", err)
+	}
+	want := `rpc Shop.Buy(demo.Order) returns (demo.Item);
+
+message demo.Order {
+  repeated demo.Item items = 1;
+  map<string, int64> counts = 2;
+  demo.Kind kind = 3;
+  repeated uint32 ids = 4;
+  bytes data = 5;
+}
+
+message demo.Item {
+  string name = 1;
+}
+`
+	if out.String() != want {
+		t.Errorf("describe() output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestCall(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+	src := newReflectionSource(addr)
+	var out bytes.Buffer
+	err := call(&out, src, addr, []string{"Greeter.Hello", `{"service": "x"}`}, []string{"status=SERVING"})
+	if err != nil {
+		t.Fatalf("call() error = %v", err)
+	}
+	if want := "{\n  \"status\": \"SERVING\"\n}\n"; out.String() != want {
+		t.Errorf("call() output = %q, want %q", out.String(), want)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		headers []string
+		want    string // 错误信息包含的内容
+	}{
+		{name: "no method", want: "missing service.method"},
+		{name: "invalid method", args: []string{"greeter"}, want: "want service.method"},
+		{name: "unknown service", args: []string{"nope.hello"}, want: "service nope not found"},
+		{name: "unknown method", args: []string{"greeter.bye"}, want: "method bye not found"},
+		{name: "not protobuf", args: []string{"plain.do"}, want: "does not use protobuf messages"},
+		{name: "bad json", args: []string{"greeter.hello", `{"service": 1`}, want: "parse request"},
+		{name: "unknown field", args: []string{"greeter.hello", `{"nope": 1}`}, want: "parse request"},
+		{name: "bad header", args: []string{"greeter.hello"}, headers: []string{"status"}, want: "want key=value"},
+		{name: "server error", args: []string{"greeter.hello"}, want: "service required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := call(ioutil.Discard, src, addr, tt.args, tt.headers)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("call(%q) error = %v, want %q", tt.args, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReflectionUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	src := newReflectionSource(addr, clientOptions()...)
+	if err := list(ioutil.Discard, src); err == nil || !strings.HasPrefix(err.Error(), "reflection: ") {
+		t.Errorf("list() error = %v, want a reflection error", err)
+	}
+	if err := describe(ioutil.Discard, src, []string{"healthpb.CheckReq"}); err == nil || !strings.HasPrefix(err.Error(), "reflection: ") {
+		t.Errorf("describe() error = %v, want a reflection error", err)
+	}
+}
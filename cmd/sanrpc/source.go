@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/hillguo/sanrpc/client"
+	"github.com/hillguo/sanrpc/service/reflectionpb"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// method 一个可调用的方法
+type method struct {
+	Name  string
+	Arg   string // 参数 protobuf 消息全名，不是 protobuf 消息时为空
+	Reply string
+}
+
+// serviceDesc 一个服务及其方法
+type serviceDesc struct {
+	Name    string
+	Methods []method
+}
+
+// source 服务和消息描述的来源：服务端反射或本地 .proto 文件
+type source interface {
+	Services() ([]serviceDesc, error)
+	Message(name string) (*desc.MessageDescriptor, error)
+}
+
+// findMethod 按 sanrpc 的规则不区分大小写地查找方法
+func findMethod(src source, serviceName, methodName string) (*method, error) {
+	services, err := src.Services()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range services {
+		if !strings.EqualFold(s.Name, serviceName) {
+			continue
+		}
+		for _, m := range s.Methods {
+			if strings.EqualFold(m.Name, methodName) {
+				return &m, nil
+			}
+		}
+		return nil, fmt.Errorf("method %s not found in service %s", methodName, s.Name)
+	}
+	return nil, fmt.Errorf("service %s not found", serviceName)
+}
+
+// reflectionSource 通过服务端的 reflection 服务获取描述
+type reflectionSource struct {
+	addr  string
+	opts  []client.Option
+	files map[string]*desc.FileDescriptor
+}
+
+func newReflectionSource(addr string, opts ...client.Option) *reflectionSource {
+	return &reflectionSource{addr: addr, opts: opts, files: make(map[string]*desc.FileDescriptor)}
+}
+
+func (s *reflectionSource) invoke(methodName string, req, resp proto.Message) error {
+	opts := append([]client.Option{
+		client.WithAddress(s.addr),
+		client.WithServiceName("reflection"),
+		client.WithMethodName(methodName),
+	}, s.opts...)
+	c := client.NewClient(opts...)
+	defer c.Close()
+	return c.Invoke(context.Background(), req, resp)
+}
+
+func (s *reflectionSource) Services() ([]serviceDesc, error) {
+	resp := &reflectionpb.ListServicesResp{}
+	if err := s.invoke("listservices", &reflectionpb.ListServicesReq{}, resp); err != nil {
+		return nil, fmt.Errorf("reflection: %v", err)
+	}
+	var services []serviceDesc
+	for _, si := range resp.GetServices() {
+		sd := serviceDesc{Name: si.GetName()}
+		for _, mi := range si.GetMethods() {
+			sd.Methods = append(sd.Methods, method{Name: mi.GetName(), Arg: mi.GetArgMessage(), Reply: mi.GetReplyMessage()})
+		}
+		services = append(services, sd)
+	}
+	return services, nil
+}
+
+func (s *reflectionSource) Message(name string) (*desc.MessageDescriptor, error) {
+	resp := &reflectionpb.FileResp{}
+	if err := s.invoke("filecontainingmessage", &reflectionpb.FileContainingMessageReq{Message: name}, resp); err != nil {
+		return nil, fmt.Errorf("reflection: %v", err)
+	}
+	// 依赖在前，逐个创建
+	var fd *desc.FileDescriptor
+	for _, data := range resp.GetFileDescriptors() {
+		fdp := &dpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(data, fdp); err != nil {
+			return nil, err
+		}
+		if f, ok := s.files[fdp.GetName()]; ok {
+			fd = f
+			continue
+		}
+		deps := make([]*desc.FileDescriptor, 0, len(fdp.GetDependency()))
+		for _, dep := range fdp.GetDependency() {
+			deps = append(deps, s.files[dep])
+		}
+		f, err := desc.CreateFileDescriptor(fdp, deps...)
+		if err != nil {
+			return nil, err
+		}
+		s.files[fdp.GetName()] = f
+		fd = f
+	}
+	if fd == nil {
+		return nil, fmt.Errorf("message %s not found", name)
+	}
+	if md := findMessage(fd, name); md != nil {
+		return md, nil
+	}
+	return nil, fmt.Errorf("message %s not found", name)
+}
+
+// protoSource 从本地 .proto 文件获取描述
+type protoSource struct {
+	files  []*desc.FileDescriptor
+	remote source // .proto 文件中没有定义服务时，用于列出服务
+}
+
+func newProtoSource(importPaths []string, files []string, remote source) (*protoSource, error) {
+	p := protoparse.Parser{ImportPaths: importPaths}
+	fds, err := p.ParseFiles(files...)
+	if err != nil {
+		return nil, err
+	}
+	return &protoSource{files: fds, remote: remote}, nil
+}
+
+// Services 返回 proto 中定义的服务，sanrpc 服务名为实现类型名的小写，需要与 proto 中的服务名一致。
+// proto 中没有定义服务时从 remote 获取。
+func (s *protoSource) Services() ([]serviceDesc, error) {
+	var services []serviceDesc
+	for _, fd := range s.files {
+		for _, sd := range fd.GetServices() {
+			d := serviceDesc{Name: sd.GetName()}
+			for _, md := range sd.GetMethods() {
+				d.Methods = append(d.Methods, method{
+					Name:  md.GetName(),
+					Arg:   md.GetInputType().GetFullyQualifiedName(),
+					Reply: md.GetOutputType().GetFullyQualifiedName(),
+				})
+			}
+			services = append(services, d)
+		}
+	}
+	if len(services) == 0 && s.remote != nil {
+		return s.remote.Services()
+	}
+	return services, nil
+}
+
+func (s *protoSource) Message(name string) (*desc.MessageDescriptor, error) {
+	for _, fd := range s.files {
+		if md := findMessage(fd, name); md != nil {
+			return md, nil
+		}
+	}
+	return nil, fmt.Errorf("message %s not found", name)
+}
+
+// findMessage 在文件及其依赖中查找消息
+func findMessage(fd *desc.FileDescriptor, name string) *desc.MessageDescriptor {
+	if md := fd.FindMessage(name); md != nil {
+		return md
+	}
+	for _, dep := range fd.GetDependencies() {
+		if md := findMessage(dep, name); md != nil {
+			return md
+		}
+	}
+	return nil
+}
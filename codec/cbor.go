@@ -0,0 +1,32 @@
+package codec
+
+import (
+	"reflect"
+
+	cbor "github.com/ugorji/go/codec"
+)
+
+var cborHandle = newCborHandle()
+
+func newCborHandle() *cbor.CborHandle {
+	h := &cbor.CborHandle{}
+	// 字段名优先取 cbor tag，其次 json tag
+	h.TypeInfos = cbor.NewTypeInfos([]string{"cbor", "json"})
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}
+
+// CborCodec uses CBOR (RFC 7049) marshaler and unmarshaler, for constrained devices.
+type CborCodec struct{}
+
+// Encode encodes an object into slice of bytes.
+func (c CborCodec) Encode(i interface{}) ([]byte, error) {
+	var data []byte
+	err := cbor.NewEncoderBytes(&data, cborHandle).Encode(i)
+	return data, err
+}
+
+// Decode decodes an object from slice of bytes.
+func (c CborCodec) Decode(data []byte, i interface{}) error {
+	return cbor.NewDecoderBytes(data, cborHandle).Decode(i)
+}
@@ -0,0 +1,75 @@
+package codec_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/hillguo/sanrpc/codec"
+)
+
+type cborReading struct {
+	Device string  `cbor:"d" json:"device"`
+	Temp   float64 `json:"temp"`
+	Seq    uint32  `cbor:"s"`
+	Raw    []byte  `cbor:"r,omitempty"`
+}
+
+func TestCborCodec(t *testing.T) {
+	c := codec.Codecs[codec.CBOR]
+	in := &cborReading{Device: "sensor-1", Temp: 21.5, Seq: 9, Raw: []byte{1, 2}}
+	data, err := c.Encode(in)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	var out cborReading
+	if err := c.Decode(data, &out); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(&out, in) {
+		t.Errorf("Decode() = %+v, want %+v", out, in)
+	}
+
+	// 字段名取 cbor tag，其次 json tag
+	var m map[string]interface{}
+	if err := c.Decode(data, &m); err != nil {
+		t.Fatalf("Decode() into map error = %v", err)
+	}
+	for _, key := range []string{"d", "temp", "s", "r"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("field %q missing in %v", key, m)
+		}
+	}
+	if m["d"] != "sensor-1" || !bytes.Equal(m["r"].([]byte), in.Raw) {
+		t.Errorf("Decode() into map = %#v", m)
+	}
+}
+
+func TestCborCodecWire(t *testing.T) {
+	// RFC 7049 附录 A 的示例，保证与其他实现互通
+	tests := []struct {
+		in   interface{}
+		want []byte
+	}{
+		{in: 10, want: []byte{0x0a}},
+		{in: 1000, want: []byte{0x19, 0x03, 0xe8}},
+		{in: -1, want: []byte{0x20}},
+		{in: "IETF", want: []byte{0x64, 'I', 'E', 'T', 'F'}},
+		{in: []int{1, 2, 3}, want: []byte{0x83, 0x01, 0x02, 0x03}},
+		{in: true, want: []byte{0xf5}},
+	}
+	c := codec.Codecs[codec.CBOR]
+	for _, tt := range tests {
+		data, err := c.Encode(tt.in)
+		if err != nil || !bytes.Equal(data, tt.want) {
+			t.Errorf("Encode(%v) = %x, %v, want %x", tt.in, data, err, tt.want)
+		}
+	}
+}
+
+func TestCborCodecInvalid(t *testing.T) {
+	c := codec.Codecs[codec.CBOR]
+	if err := c.Decode([]byte{0x64, 'I'}, new(string)); err == nil {
+		t.Errorf("Decode() of a truncated payload succeeded")
+	}
+}
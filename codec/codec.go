@@ -1,10 +1,12 @@
 package codec
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
 
+	"github.com/golang/protobuf/jsonpb"
 	pb "github.com/golang/protobuf/proto"
 )
 
@@ -31,20 +33,40 @@ func (c ByteCodec) Encode(i interface{}) ([]byte, error) {
 
 // Decode returns raw slice of bytes.
 func (c ByteCodec) Decode(data []byte, i interface{}) error {
-	reflect.Indirect(reflect.ValueOf(i)).SetBytes(data)
+	v := reflect.Indirect(reflect.ValueOf(i))
+	if !v.CanSet() || v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uint8 {
+		return fmt.Errorf("%T is not a *[]byte", i)
+	}
+	v.SetBytes(data)
 	return nil
 }
 
 // JSONCodec uses json marshaler and unmarshaler.
+// Protobuf messages use canonical proto3 JSON mapping (jsonpb).
 type JSONCodec struct{}
 
+var (
+	jsonpbMarshaler   = &jsonpb.Marshaler{}
+	jsonpbUnmarshaler = &jsonpb.Unmarshaler{AllowUnknownFields: true}
+)
+
 // Encode encodes an object into slice of bytes.
 func (c JSONCodec) Encode(i interface{}) ([]byte, error) {
+	if m, ok := i.(pb.Message); ok {
+		var buf bytes.Buffer
+		if err := jsonpbMarshaler.Marshal(&buf, m); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
 	return json.Marshal(i)
 }
 
 // Decode decodes an object from slice of bytes.
 func (c JSONCodec) Decode(data []byte, i interface{}) error {
+	if m, ok := i.(pb.Message); ok {
+		return jsonpbUnmarshaler.Unmarshal(bytes.NewReader(data), m)
+	}
 	return json.Unmarshal(data, i)
 }
 
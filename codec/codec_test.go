@@ -0,0 +1,100 @@
+package codec_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	pb "github.com/golang/protobuf/proto"
+	"github.com/hillguo/sanrpc/codec"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+)
+
+func TestJSONCodecProtoEncode(t *testing.T) {
+	c := codec.Codecs[codec.JSON]
+	data, err := c.Encode(&sanrpc.HeaderMsg{ServiceName: "greeter", Seq: 1 << 40, MetaData: map[string]string{"k": "v"}})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Encode() = %s, not JSON: %v", data, err)
+	}
+	// proto3 JSON：字段名为 lowerCamelCase，64 位整数为字符串，零值字段省略
+	want := map[string]interface{}{
+		"serviceName": "greeter",
+		"seq":         "1099511627776",
+		"metaData":    map[string]interface{}{"k": "v"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode() = %s, want %v", data, want)
+	}
+}
+
+func TestJSONCodecProtoDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want *sanrpc.HeaderMsg
+	}{
+		{name: "json name", data: `{"serviceName": "greeter", "seq": "5"}`, want: &sanrpc.HeaderMsg{ServiceName: "greeter", Seq: 5}},
+		{name: "proto name", data: `{"service_name": "greeter", "method_name": "Hello"}`, want: &sanrpc.HeaderMsg{ServiceName: "greeter", MethodName: "Hello"}},
+		{name: "number as integer", data: `{"seq": 5, "encodeType": 2}`, want: &sanrpc.HeaderMsg{Seq: 5, EncodeType: 2}},
+		{name: "unknown field", data: `{"serviceName": "greeter", "extra": true}`, want: &sanrpc.HeaderMsg{ServiceName: "greeter"}},
+	}
+	c := codec.Codecs[codec.JSON]
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &sanrpc.HeaderMsg{}
+			if err := c.Decode([]byte(tt.data), got); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !pb.Equal(got, tt.want) {
+				t.Errorf("Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := c.Decode([]byte(`{"seq": "x"}`), &sanrpc.HeaderMsg{}); err == nil {
+		t.Errorf("Decode() of an invalid value succeeded")
+	}
+}
+
+func TestJSONCodecPlainStruct(t *testing.T) {
+	type req struct {
+		ServiceName string `json:"service_name"`
+		Seq         uint64 `json:"seq"`
+	}
+	c := codec.Codecs[codec.JSON]
+	data, err := c.Encode(&req{ServiceName: "greeter", Seq: 5})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if string(data) != `{"service_name":"greeter","seq":5}` {
+		t.Errorf("Encode() = %s, want encoding/json output", data)
+	}
+	var out req
+	if err := c.Decode(data, &out); err != nil || out.ServiceName != "greeter" || out.Seq != 5 {
+		t.Errorf("Decode() = %+v, %v", out, err)
+	}
+}
+
+func TestPBCodec(t *testing.T) {
+	c := codec.Codecs[codec.ProtoBuffer]
+	in := &sanrpc.HeaderMsg{ServiceName: "greeter", Seq: 5}
+	data, err := c.Encode(in)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	out := &sanrpc.HeaderMsg{}
+	if err := c.Decode(data, out); err != nil || !pb.Equal(out, in) {
+		t.Errorf("Decode() = %v, %v", out, err)
+	}
+
+	if _, err := c.Encode(struct{}{}); err == nil {
+		t.Errorf("Encode() of a non proto message succeeded")
+	}
+	if err := c.Decode(data, &struct{}{}); err == nil {
+		t.Errorf("Decode() into a non proto message succeeded")
+	}
+}
@@ -6,6 +6,12 @@ import (
 	"io/ioutil"
 )
 
+// Compressors are compressors supported by sanrpc, keyed by HeaderMsg.CompressType.
+var Compressors = map[CompressType]Compressor{
+	CompressNone: RawDataCompressor{},
+	Gzip:         GzipCompressor{},
+}
+
 // Compressor defines a common compression interface.
 type Compressor interface {
 	Zip([]byte) ([]byte, error)
@@ -17,19 +23,6 @@ type GzipCompressor struct {
 }
 
 func (c GzipCompressor) Zip(data []byte) ([]byte, error) {
-	gr, err := gzip.NewReader(bytes.NewBuffer(data))
-	if err != nil {
-		return nil, err
-	}
-	defer gr.Close()
-	data, err = ioutil.ReadAll(gr)
-	if err != nil {
-		return nil, err
-	}
-	return data, err
-}
-
-func (c GzipCompressor) Unzip(data []byte) ([]byte, error) {
 	var buf bytes.Buffer
 	w := gzip.NewWriter(&buf)
 	_, err := w.Write(data)
@@ -48,6 +41,19 @@ func (c GzipCompressor) Unzip(data []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+func (c GzipCompressor) Unzip(data []byte) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	data, err = ioutil.ReadAll(gr)
+	if err != nil {
+		return nil, err
+	}
+	return data, err
+}
+
 type RawDataCompressor struct {
 }
 
@@ -0,0 +1,23 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// GobCodec uses encoding/gob, for Go-to-Go calls without schema.
+type GobCodec struct{}
+
+// Encode encodes an object into slice of bytes.
+func (c GobCodec) Encode(i interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(i); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes an object from slice of bytes.
+func (c GobCodec) Decode(data []byte, i interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(i)
+}
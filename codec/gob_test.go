@@ -0,0 +1,57 @@
+package codec_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hillguo/sanrpc/codec"
+)
+
+type gobReq struct {
+	Name  string
+	Count int
+	Attrs map[string][]int
+	Next  *gobReq
+}
+
+func TestGobCodec(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		out  interface{}
+	}{
+		{name: "struct", in: &gobReq{Name: "a", Count: 2, Attrs: map[string][]int{"k": {1, 2}}, Next: &gobReq{Name: "b"}}, out: &gobReq{}},
+		{name: "map", in: map[string]int{"a": 1}, out: &map[string]int{}},
+		{name: "slice", in: []string{"a", "b"}, out: &[]string{}},
+	}
+	c := codec.Codecs[codec.Gob]
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := c.Encode(tt.in)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if err := c.Decode(data, tt.out); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got := reflect.Indirect(reflect.ValueOf(tt.out)).Interface(); !reflect.DeepEqual(got, reflect.Indirect(reflect.ValueOf(tt.in)).Interface()) {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.in)
+			}
+		})
+	}
+}
+
+func TestGobCodecInvalid(t *testing.T) {
+	c := codec.Codecs[codec.Gob]
+	if _, err := c.Encode(func() {}); err == nil {
+		t.Errorf("Encode() of a func succeeded")
+	}
+	if err := c.Decode([]byte("not gob"), &gobReq{}); err == nil {
+		t.Errorf("Decode() of an invalid payload succeeded")
+	}
+	// 类型不匹配时报错，不静默丢弃
+	data, _ := c.Encode(&gobReq{Name: "a"})
+	if err := c.Decode(data, &[]string{}); err == nil {
+		t.Errorf("Decode() into a mismatched type succeeded")
+	}
+}
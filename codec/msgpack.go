@@ -0,0 +1,35 @@
+package codec
+
+import (
+	"reflect"
+
+	msgpack "github.com/ugorji/go/codec"
+)
+
+var msgpackHandle = newMsgpackHandle()
+
+func newMsgpackHandle() *msgpack.MsgpackHandle {
+	h := &msgpack.MsgpackHandle{}
+	// 字段名优先取 msgpack tag，其次 json tag，与 Python/Lua 端约定一致
+	h.TypeInfos = msgpack.NewTypeInfos([]string{"msgpack", "json"})
+	// 使用新版规范区分 str 和 bin，解码到 interface{} 时 str 解为 string
+	h.WriteExt = true
+	h.RawToString = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}
+
+// MsgpackCodec uses MessagePack marshaler and unmarshaler.
+type MsgpackCodec struct{}
+
+// Encode encodes an object into slice of bytes.
+func (c MsgpackCodec) Encode(i interface{}) ([]byte, error) {
+	var data []byte
+	err := msgpack.NewEncoderBytes(&data, msgpackHandle).Encode(i)
+	return data, err
+}
+
+// Decode decodes an object from slice of bytes.
+func (c MsgpackCodec) Decode(data []byte, i interface{}) error {
+	return msgpack.NewDecoderBytes(data, msgpackHandle).Decode(i)
+}
@@ -0,0 +1,68 @@
+package codec_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hillguo/sanrpc/codec"
+)
+
+type msgpackUser struct {
+	ID    int64             `msgpack:"id" json:"user_id"`
+	Name  string            `json:"name"`
+	Tags  []string          `msgpack:"tags,omitempty"`
+	Attrs map[string]string `msgpack:"attrs"`
+	Skip  string            `msgpack:"-"`
+}
+
+func TestMsgpackCodec(t *testing.T) {
+	c := codec.Codecs[codec.MsgPack]
+	in := &msgpackUser{ID: 7, Name: "tom", Tags: []string{"a", "b"}, Attrs: map[string]string{"k": "v"}, Skip: "x"}
+	data, err := c.Encode(in)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var out msgpackUser
+	if err := c.Decode(data, &out); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := *in
+	want.Skip = ""
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Decode() = %+v, want %+v", out, want)
+	}
+
+	// 其他语言的服务端按 tag 取字段，msgpack tag 优先于 json tag，str 解为 string
+	var m map[string]interface{}
+	if err := c.Decode(data, &m); err != nil {
+		t.Fatalf("Decode() into map error = %v", err)
+	}
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{key: "id", want: int64(7)},
+		{key: "name", want: "tom"},
+		{key: "tags", want: []interface{}{"a", "b"}},
+		{key: "attrs", want: map[string]interface{}{"k": "v"}},
+	}
+	for _, tt := range tests {
+		if got := m[tt.key]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("field %q = %#v, want %#v", tt.key, got, tt.want)
+		}
+	}
+	for _, key := range []string{"user_id", "Skip"} {
+		if _, ok := m[key]; ok {
+			t.Errorf("unexpected field %q in %v", key, m)
+		}
+	}
+}
+
+func TestMsgpackCodecInvalid(t *testing.T) {
+	c := codec.Codecs[codec.MsgPack]
+	var out msgpackUser
+	if err := c.Decode([]byte{0xc1}, &out); err == nil {
+		t.Errorf("Decode() of an invalid payload succeeded")
+	}
+}
@@ -1,15 +1,32 @@
 package codec
 
+import (
+	"sort"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
 // SerializeType defines serialization type of payload.
+// Values are sent as HeaderMsg.EncodeType and must equal SanrpcContentEncodeType in sanrpc.proto.
 type SerializeType byte
 
 const (
 	// SerializeNone uses raw []byte and don't serialize/deserialize
-	SerializeNone SerializeType = iota
-	// JSON for payload.
-	JSON
+	SerializeNone SerializeType = 0 // SANRPC_NONE_ENCODE
 	// ProtoBuffer for payload.
-	ProtoBuffer
+	ProtoBuffer SerializeType = 1 // SANRPC_PB_ENCODE
+	// JSON for payload.
+	JSON SerializeType = 2 // SANRPC_JSON_ENCODE
+	// MsgPack for payload.
+	MsgPack SerializeType = 3 // SANRPC_MSGPACK_ENCODE
+	// Gob for payload, Go-to-Go only.
+	Gob SerializeType = 4 // SANRPC_GOB_ENCODE
+	// CBOR for payload.
+	CBOR SerializeType = 5 // SANRPC_CBOR_ENCODE
+	// ThriftBinary for payload of thrift generated structs.
+	ThriftBinary SerializeType = 6 // SANRPC_THRIFT_BINARY_ENCODE
+	// ThriftCompact for payload of thrift generated structs.
+	ThriftCompact SerializeType = 7 // SANRPC_THRIFT_COMPACT_ENCODE
 )
 
 var (
@@ -18,15 +35,47 @@ var (
 		SerializeNone: &ByteCodec{},
 		JSON:          &JSONCodec{},
 		ProtoBuffer:   &PBCodec{},
+		MsgPack:       &MsgpackCodec{},
+		Gob:           &GobCodec{},
+		CBOR:          &CborCodec{},
+		ThriftBinary:  &ThriftCodec{Factory: thrift.NewTBinaryProtocolFactoryDefault()},
+		ThriftCompact: &ThriftCodec{Factory: thrift.NewTCompactProtocolFactory()},
 	}
 )
 
+// GetCodec returns the codec of HeaderMsg.EncodeType, or nil if the encode type is unknown.
+func GetCodec(encodeType uint32) Codec {
+	if encodeType > 0xff {
+		return nil
+	}
+	return Codecs[SerializeType(encodeType)]
+}
+
+// SerializeTypes returns all supported serialize types in ascending order.
+func SerializeTypes() []SerializeType {
+	types := make([]SerializeType, 0, len(Codecs))
+	for t := range Codecs {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
 // CompressType defines decompression type.
+// Values are sent as HeaderMsg.CompressType and must equal SanrpcCompressType in sanrpc.proto.
 type CompressType byte
 
 const (
 	// None does not compress.
-	CompressNone CompressType = iota
+	CompressNone CompressType = 0 // SANRPC_NONE_COMPRESS
 	// Gzip uses gzip compression.
-	Gzip
+	Gzip CompressType = 1 // SANRPC_GZIP_COMPRESS
 )
+
+// GetCompressor returns the compressor of HeaderMsg.CompressType, or nil if the compress type is unknown.
+func GetCompressor(compressType uint32) Compressor {
+	if compressType > 0xff {
+		return nil
+	}
+	return Compressors[CompressType(compressType)]
+}
@@ -0,0 +1,84 @@
+package codec_test
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/hillguo/sanrpc/codec"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+)
+
+func TestSerializeTypeOnTheWire(t *testing.T) {
+	// SerializeType 直接作为 HeaderMsg.EncodeType 发送，必须与 sanrpc.proto 中的取值一致
+	tests := []struct {
+		typ  codec.SerializeType
+		want sanrpc.SanrpcContentEncodeType
+	}{
+		{typ: codec.SerializeNone, want: sanrpc.SanrpcContentEncodeType_SANRPC_NONE_ENCODE},
+		{typ: codec.ProtoBuffer, want: sanrpc.SanrpcContentEncodeType_SANRPC_PB_ENCODE},
+		{typ: codec.JSON, want: sanrpc.SanrpcContentEncodeType_SANRPC_JSON_ENCODE},
+		{typ: codec.MsgPack, want: sanrpc.SanrpcContentEncodeType_SANRPC_MSGPACK_ENCODE},
+		{typ: codec.Gob, want: sanrpc.SanrpcContentEncodeType_SANRPC_GOB_ENCODE},
+		{typ: codec.CBOR, want: sanrpc.SanrpcContentEncodeType_SANRPC_CBOR_ENCODE},
+		{typ: codec.ThriftBinary, want: sanrpc.SanrpcContentEncodeType_SANRPC_THRIFT_BINARY_ENCODE},
+		{typ: codec.ThriftCompact, want: sanrpc.SanrpcContentEncodeType_SANRPC_THRIFT_COMPACT_ENCODE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want.String(), func(t *testing.T) {
+			if int32(tt.typ) != int32(tt.want) {
+				t.Errorf("SerializeType = %d, want %d", tt.typ, tt.want)
+			}
+			if codec.GetCodec(uint32(tt.want)) == nil {
+				t.Errorf("GetCodec(%d) = nil", tt.want)
+			}
+		})
+	}
+	if len(tests) != len(sanrpc.SanrpcContentEncodeType_name) {
+		t.Errorf("%d encode types in sanrpc.proto, %d checked", len(sanrpc.SanrpcContentEncodeType_name), len(tests))
+	}
+}
+
+func TestGetCodecUnknown(t *testing.T) {
+	for _, typ := range []uint32{8, 0xff, 0x100, 0x101} {
+		if c := codec.GetCodec(typ); c != nil {
+			t.Errorf("GetCodec(%#x) = %T, want nil", typ, c)
+		}
+	}
+}
+
+func TestSerializeTypes(t *testing.T) {
+	types := codec.SerializeTypes()
+	if len(types) != len(codec.Codecs) {
+		t.Fatalf("SerializeTypes() = %v, want all %d codecs", types, len(codec.Codecs))
+	}
+	if !sort.SliceIsSorted(types, func(i, j int) bool { return types[i] < types[j] }) {
+		t.Errorf("SerializeTypes() = %v, not sorted", types)
+	}
+}
+
+func TestByteCodec(t *testing.T) {
+	c := codec.Codecs[codec.SerializeNone]
+	payload := []byte("raw")
+	for _, in := range []interface{}{payload, &payload} {
+		data, err := c.Encode(in)
+		if err != nil || !bytes.Equal(data, payload) {
+			t.Errorf("Encode(%T) = %q, %v", in, data, err)
+		}
+	}
+	if _, err := c.Encode("raw"); err == nil {
+		t.Errorf("Encode(string) succeeded")
+	}
+
+	var out []byte
+	if err := c.Decode(payload, &out); err != nil || !bytes.Equal(out, payload) {
+		t.Errorf("Decode() = %q, %v", out, err)
+	}
+	var s string
+	if err := c.Decode(payload, &s); err == nil {
+		t.Errorf("Decode(*string) succeeded")
+	}
+	if err := c.Decode(payload, out); err == nil {
+		t.Errorf("Decode([]byte) succeeded")
+	}
+}
@@ -0,0 +1,36 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// ThriftCodec uses thrift protocol to encode thrift generated structs.
+// TSerializer/TDeserializer are not goroutine safe, so they are created per call.
+type ThriftCodec struct {
+	Factory thrift.TProtocolFactory
+}
+
+// Encode encodes a thrift struct into slice of bytes.
+func (c ThriftCodec) Encode(i interface{}) ([]byte, error) {
+	m, ok := i.(thrift.TStruct)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a thrift.TStruct", i)
+	}
+	t := thrift.NewTSerializer()
+	t.Protocol = c.Factory.GetProtocol(t.Transport)
+	return t.Write(context.Background(), m)
+}
+
+// Decode decodes a thrift struct from slice of bytes.
+func (c ThriftCodec) Decode(data []byte, i interface{}) error {
+	m, ok := i.(thrift.TStruct)
+	if !ok {
+		return fmt.Errorf("%T is not a thrift.TStruct", i)
+	}
+	t := thrift.NewTDeserializer()
+	t.Protocol = c.Factory.GetProtocol(t.Transport)
+	return t.Read(m, data)
+}
@@ -0,0 +1,136 @@
+package codec_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/hillguo/sanrpc/codec"
+)
+
+// thriftUser 按 thrift 生成代码的方式手写的结构体：
+//
+//	struct User { 1: string name, 2: i32 age }
+type thriftUser struct {
+	Name string
+	Age  int32
+}
+
+func (u *thriftUser) Write(p thrift.TProtocol) error {
+	if err := p.WriteStructBegin("User"); err != nil {
+		return err
+	}
+	if err := p.WriteFieldBegin("name", thrift.STRING, 1); err != nil {
+		return err
+	}
+	if err := p.WriteString(u.Name); err != nil {
+		return err
+	}
+	if err := p.WriteFieldEnd(); err != nil {
+		return err
+	}
+	if err := p.WriteFieldBegin("age", thrift.I32, 2); err != nil {
+		return err
+	}
+	if err := p.WriteI32(u.Age); err != nil {
+		return err
+	}
+	if err := p.WriteFieldEnd(); err != nil {
+		return err
+	}
+	if err := p.WriteFieldStop(); err != nil {
+		return err
+	}
+	return p.WriteStructEnd()
+}
+
+func (u *thriftUser) Read(p thrift.TProtocol) error {
+	if _, err := p.ReadStructBegin(); err != nil {
+		return err
+	}
+	for {
+		_, typ, id, err := p.ReadFieldBegin()
+		if err != nil {
+			return err
+		}
+		if typ == thrift.STOP {
+			break
+		}
+		switch {
+		case id == 1 && typ == thrift.STRING:
+			u.Name, err = p.ReadString()
+		case id == 2 && typ == thrift.I32:
+			u.Age, err = p.ReadI32()
+		default:
+			err = p.Skip(typ)
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	return p.ReadStructEnd()
+}
+
+func TestThriftCodec(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  codec.SerializeType
+		want []byte // 对端用同一协议序列化的结果
+	}{
+		{
+			name: "binary",
+			typ:  codec.ThriftBinary,
+			want: []byte{
+				0x0b, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03, 't', 'o', 'm', // 1: string "tom"
+				0x08, 0x00, 0x02, 0x00, 0x00, 0x00, 0x12, // 2: i32 18
+				0x00, // stop
+			},
+		},
+		{
+			name: "compact",
+			typ:  codec.ThriftCompact,
+			want: []byte{
+				0x18, 0x03, 't', 'o', 'm', // 1: string "tom"
+				0x15, 0x24, // 2: i32 18 (zigzag)
+				0x00, // stop
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := codec.Codecs[tt.typ]
+			data, err := c.Encode(&thriftUser{Name: "tom", Age: 18})
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if !bytes.Equal(data, tt.want) {
+				t.Errorf("Encode() = %x, want %x", data, tt.want)
+			}
+			var out thriftUser
+			if err := c.Decode(data, &out); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if out != (thriftUser{Name: "tom", Age: 18}) {
+				t.Errorf("Decode() = %+v", out)
+			}
+			if err := c.Decode(data[:len(data)-3], &thriftUser{}); err == nil {
+				t.Errorf("Decode() of a truncated payload succeeded")
+			}
+		})
+	}
+}
+
+func TestThriftCodecNotStruct(t *testing.T) {
+	for _, typ := range []codec.SerializeType{codec.ThriftBinary, codec.ThriftCompact} {
+		c := codec.Codecs[typ]
+		if _, err := c.Encode(map[string]string{}); err == nil {
+			t.Errorf("%d: Encode() of a non thrift struct succeeded", typ)
+		}
+		if err := c.Decode([]byte{0}, &struct{}{}); err == nil {
+			t.Errorf("%d: Decode() into a non thrift struct succeeded", typ)
+		}
+	}
+}
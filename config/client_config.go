@@ -0,0 +1,40 @@
+package config
+
+import (
+	"github.com/BurntSushi/toml"
+)
+
+// ClientConfig 客户端配置
+type ClientConfig struct {
+	Routes []RouteConfig
+}
+
+// RouteConfig 一条按请求元数据路由的规则。
+// Match 全部满足的请求，有 Percent% 会被路由到元数据包含全部 Labels 的节点。
+// 是否落入 Percent 按 HashKey 对应的元数据值哈希决定，同一用户或会话的结果固定；
+// HashKey 为空时使用 Match 中各 key 的值。
+type RouteConfig struct {
+	Name    string
+	Match   []MatchConfig
+	Labels  map[string]string
+	Percent int // 1-100，必须指定
+	HashKey string
+}
+
+// MatchConfig 匹配请求元数据中的一个 key。
+// Value 非空时精确匹配，否则把值解析为整数并要求 Min <= v <= Max。
+type MatchConfig struct {
+	Key   string
+	Value string
+	Min   int64
+	Max   int64
+}
+
+// LoadClientConfig 从 toml 文件加载客户端配置
+func LoadClientConfig(path string) (*ClientConfig, error) {
+	c := &ClientConfig{}
+	if _, err := toml.DecodeFile(path, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -7,6 +7,10 @@ type ServerConfig struct {
 
 type ServiceConfig struct {
 	Name string
+	Namespace string
+	EnvName string
+	SetName string
+	Zone string
 	NetWork string
 	Address string
 
@@ -17,6 +21,7 @@ type ServiceConfig struct {
 
 	Transport string
 	Protocol string
+	Registry string // 名字服务注册组件名
 }
 
 type RedisConfig struct {
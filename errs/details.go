@@ -0,0 +1,98 @@
+package errs
+
+import (
+	"encoding/json"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+)
+
+// JSONDetailTypeURL 非 protobuf 详情以 JSON 打包时 Any 的 TypeUrl
+const JSONDetailTypeURL = "type.sanrpc.io/json"
+
+// MarshalDetails 将详情打包为 Any，用于写入 ErrMsg.Details
+func MarshalDetails(details []interface{}) ([]*any.Any, error) {
+	if len(details) == 0 {
+		return nil, nil
+	}
+	anys := make([]*any.Any, 0, len(details))
+	for _, d := range details {
+		if a, ok := d.(*any.Any); ok {
+			anys = append(anys, a)
+			continue
+		}
+		if m, ok := d.(proto.Message); ok {
+			a, err := ptypes.MarshalAny(m)
+			if err != nil {
+				return nil, err
+			}
+			anys = append(anys, a)
+			continue
+		}
+		data, err := json.Marshal(d)
+		if err != nil {
+			return nil, err
+		}
+		anys = append(anys, &any.Any{TypeUrl: JSONDetailTypeURL, Value: data})
+	}
+	return anys, nil
+}
+
+// UnmarshalDetails 解出 ErrMsg.Details：已注册的 protobuf 类型解为对应的 proto.Message，
+// JSON 详情解为 json.RawMessage，其他保留为 *any.Any
+func UnmarshalDetails(anys []*any.Any) []interface{} {
+	if len(anys) == 0 {
+		return nil
+	}
+	details := make([]interface{}, 0, len(anys))
+	for _, a := range anys {
+		if a.GetTypeUrl() == JSONDetailTypeURL {
+			details = append(details, json.RawMessage(a.GetValue()))
+			continue
+		}
+		var da ptypes.DynamicAny
+		if err := ptypes.UnmarshalAny(a, &da); err != nil {
+			details = append(details, a)
+			continue
+		}
+		details = append(details, da.Message)
+	}
+	return details
+}
+
+// Detail 将第一个能匹配 target 的详情取到 target 中，用于客户端收到的错误：
+// protobuf 详情按消息类型匹配，JSON 详情解码到 target。target 必须为指针，找到时返回 true
+func (e *Error) Detail(target interface{}) bool {
+	if e == nil {
+		return false
+	}
+	tm, isProto := target.(proto.Message)
+	for _, d := range e.Details {
+		switch v := d.(type) {
+		case json.RawMessage:
+			if isProto {
+				continue
+			}
+			if json.Unmarshal(v, target) == nil {
+				return true
+			}
+		case proto.Message:
+			if !isProto {
+				continue
+			}
+			if a, ok := v.(*any.Any); ok {
+				if ptypes.Is(a, tm) && ptypes.UnmarshalAny(a, tm) == nil {
+					return true
+				}
+				continue
+			}
+			if proto.MessageName(v) == proto.MessageName(tm) {
+				tm.Reset()
+				proto.Merge(tm, v)
+				return true
+			}
+		}
+	}
+	return false
+}
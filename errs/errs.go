@@ -1,6 +1,7 @@
 package errs
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -17,6 +18,7 @@ var (
 	ErrServerNoSupportEncodeType = NewFrameError(123,"server not support content encode type")
 	ErrServerDecodeDataErr = NewFrameError(124, "server decode req data dail")
 	ErrServerEncodeDataErr = NewFrameError(125, "server encode req data dail")
+	ErrServerNoSupportCompressType = NewFrameError(126, "server not support compress type")
 
 	ErrServerTimeout   = NewFrameError(131, "server message timeout")
 	ErrServerOverload  = NewFrameError(132, "server overload")
@@ -30,11 +32,22 @@ const (
 	ErrorTypeBusiness  = 2
 )
 
+// Error 框架和业务统一的错误，Type、Code、Msg 和 Details 会在网络上传输，
+// 客户端收到的 Error 与服务端返回的一致
 type Error struct {
 	Type int32
 	Code int32
 	Msg  string
+	// Details 错误详情，proto.Message 按类型打包为 Any，其他类型以 JSON 打包。
+	// 客户端解出的详情：已注册的 protobuf 类型为对应的 proto.Message，
+	// JSON 详情为 json.RawMessage，未知类型保留为 *any.Any
+	Details []interface{}
+	// Data 只在本地有效，不在网络上传输。
+	//
+	// Deprecated: 使用 Details 携带需要返回给客户端的错误详情。
 	Data interface{}
+
+	cause error
 }
 
 func (e *Error) Error() string {
@@ -47,6 +60,30 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("type:business, code:%d, msg:%s", e.Code, e.Msg)
 }
 
+// Unwrap 返回 Wrap 时传入的原始错误，只在本地有效，不在网络上传输
+func (e *Error) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.cause
+}
+
+// Is 按 Type 和 Code 匹配，使 errors.Is(err, errs.ErrServerNoService) 对客户端收到的错误同样有效
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || e == nil || t == nil {
+		return false
+	}
+	return e.Type == t.Type && e.Code == t.Code
+}
+
+// WithDetails 返回附加了详情的错误副本，不修改 e，可用于预定义的错误
+func (e *Error) WithDetails(details ...interface{}) *Error {
+	ne := *e
+	ne.Details = append(append([]interface{}(nil), e.Details...), details...)
+	return &ne
+}
+
 // New 创建一个error，默认为业务错误类型，提高业务开发效率
 func New(code int, msg string) *Error {
 	return &Error{
@@ -64,3 +101,28 @@ func NewFrameError(code int, msg string) *Error {
 		Msg:  msg,
 	}
 }
+
+// Wrap 创建一个包装了 err 的业务error，err 可通过 errors.Unwrap 取得
+func Wrap(err error, code int, msg string) *Error {
+	e := New(code, msg)
+	e.cause = err
+	return e
+}
+
+// FromError 从 err 的错误链中取出 *Error；err 为 nil 时返回 nil, true。
+// err 不是 *Error 时返回包装了 err 的 ErrUnknown 错误和 false
+func FromError(err error) (*Error, bool) {
+	if err == nil {
+		return nil, true
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return &Error{
+		Type:  ErrUnknown.Type,
+		Code:  ErrUnknown.Code,
+		Msg:   err.Error(),
+		cause: err,
+	}, false
+}
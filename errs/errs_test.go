@@ -0,0 +1,123 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	cause := io.ErrUnexpectedEOF
+	e := Wrap(cause, 1001, "load user")
+	if e.Type != ErrorTypeBusiness || e.Code != 1001 || e.Msg != "load user" {
+		t.Errorf("Wrap() = %v", e)
+	}
+	if errors.Unwrap(e) != cause {
+		t.Errorf("Unwrap() = %v, want %v", errors.Unwrap(e), cause)
+	}
+	if !errors.Is(e, io.ErrUnexpectedEOF) {
+		t.Errorf("errors.Is(Wrap(cause), cause) = false")
+	}
+	if !errors.Is(fmt.Errorf("handler: %w", e), New(1001, "")) {
+		t.Errorf("errors.Is through fmt.Errorf = false")
+	}
+	var nilErr *Error
+	if nilErr.Unwrap() != nil || nilErr.Error() != "nil" {
+		t.Errorf("nil *Error: Unwrap() = %v, Error() = %q", nilErr.Unwrap(), nilErr.Error())
+	}
+}
+
+func TestIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{name: "same", err: ErrServerNoService, target: ErrServerNoService, want: true},
+		{name: "received copy", err: &Error{Type: ErrorTypeFramework, Code: 121, Msg: "other msg"}, target: ErrServerNoService, want: true},
+		{name: "with details", err: ErrServerNoMethod.WithDetails("x"), target: ErrServerNoMethod, want: true},
+		{name: "other code", err: ErrServerNoMethod, target: ErrServerNoService},
+		{name: "business vs framework", err: New(121, ""), target: ErrServerNoService},
+		{name: "business", err: New(7, "a"), target: New(7, "b"), want: true},
+		{name: "wrapped", err: fmt.Errorf("call: %w", ErrServerTimeout), target: ErrServerTimeout, want: true},
+		{name: "not *Error", err: io.EOF, target: ErrUnknown},
+		{name: "nil target", err: ErrUnknown, target: (*Error)(nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	biz := New(1001, "no user")
+	tests := []struct {
+		name     string
+		err      error
+		wantOK   bool
+		wantType int32
+		wantCode int32
+		wantMsg  string
+	}{
+		{name: "nil", wantOK: true},
+		{name: "*Error", err: biz, wantOK: true, wantType: ErrorTypeBusiness, wantCode: 1001, wantMsg: "no user"},
+		{name: "wrapped", err: fmt.Errorf("handler: %w", biz), wantOK: true, wantType: ErrorTypeBusiness, wantCode: 1001, wantMsg: "no user"},
+		{name: "framework", err: ErrServerOverload, wantOK: true, wantType: ErrorTypeFramework, wantCode: 132, wantMsg: "server overload"},
+		{name: "plain", err: io.EOF, wantType: ErrorTypeFramework, wantCode: ErrUnknown.Code, wantMsg: "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := FromError(tt.err)
+			if ok != tt.wantOK {
+				t.Errorf("FromError() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.err == nil {
+				if e != nil {
+					t.Errorf("FromError(nil) = %v, want nil", e)
+				}
+				return
+			}
+			if e.Type != tt.wantType || e.Code != tt.wantCode || e.Msg != tt.wantMsg {
+				t.Errorf("FromError() = %v", e)
+			}
+			if !ok && !errors.Is(e, tt.err) {
+				t.Errorf("FromError() does not wrap %v", tt.err)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want StatusCode
+	}{
+		{name: "nil", want: StatusOK},
+		{name: "status error", err: NewStatusError(StatusNotFound, "x"), want: StatusNotFound},
+		{name: "legacy framework code", err: ErrServerNoService, want: StatusUnimplemented},
+		{name: "business", err: New(int(StatusNotFound), "x"), want: StatusUnknown},
+		{name: "canceled", err: context.Canceled, want: StatusCanceled},
+		{name: "wrapped deadline", err: unknownError(fmt.Errorf("call: %w", context.DeadlineExceeded)), want: StatusDeadlineExceeded},
+		{name: "plain", err: io.EOF, want: StatusUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Status(tt.err); got != tt.want {
+				t.Errorf("Status(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// unknownError 返回 FromError 包装 err 得到的 ErrUnknown 错误
+func unknownError(err error) error {
+	e, _ := FromError(err)
+	return e
+}
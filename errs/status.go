@@ -0,0 +1,178 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// StatusCode 标准状态码，取值与 gRPC 的 codes 一致。
+// 框架错误可以直接使用标准状态码作为 Code（见 NewStatusError），
+// 原有的框架错误码（101~132、999）通过 Status 映射为标准状态码。
+type StatusCode int32
+
+const (
+	StatusOK                 StatusCode = 0
+	StatusCanceled           StatusCode = 1
+	StatusUnknown            StatusCode = 2
+	StatusInvalidArgument    StatusCode = 3
+	StatusDeadlineExceeded   StatusCode = 4
+	StatusNotFound           StatusCode = 5
+	StatusAlreadyExists      StatusCode = 6
+	StatusPermissionDenied   StatusCode = 7
+	StatusResourceExhausted  StatusCode = 8
+	StatusFailedPrecondition StatusCode = 9
+	StatusAborted            StatusCode = 10
+	StatusOutOfRange         StatusCode = 11
+	StatusUnimplemented      StatusCode = 12
+	StatusInternal           StatusCode = 13
+	StatusUnavailable        StatusCode = 14
+	StatusDataLoss           StatusCode = 15
+	StatusUnauthenticated    StatusCode = 16
+)
+
+var statusNames = map[StatusCode]string{
+	StatusOK:                 "OK",
+	StatusCanceled:           "Canceled",
+	StatusUnknown:            "Unknown",
+	StatusInvalidArgument:    "InvalidArgument",
+	StatusDeadlineExceeded:   "DeadlineExceeded",
+	StatusNotFound:           "NotFound",
+	StatusAlreadyExists:      "AlreadyExists",
+	StatusPermissionDenied:   "PermissionDenied",
+	StatusResourceExhausted:  "ResourceExhausted",
+	StatusFailedPrecondition: "FailedPrecondition",
+	StatusAborted:            "Aborted",
+	StatusOutOfRange:         "OutOfRange",
+	StatusUnimplemented:      "Unimplemented",
+	StatusInternal:           "Internal",
+	StatusUnavailable:        "Unavailable",
+	StatusDataLoss:           "DataLoss",
+	StatusUnauthenticated:    "Unauthenticated",
+}
+
+func (c StatusCode) String() string {
+	if s, ok := statusNames[c]; ok {
+		return s
+	}
+	return "StatusCode(" + strconv.Itoa(int(c)) + ")"
+}
+
+// frameStatus 原有框架错误码对应的标准状态码
+var frameStatus = map[int32]StatusCode{
+	ErrServerUnmarshalFail.Code:         StatusInvalidArgument,
+	ErrServerMarshalFail.Code:           StatusInternal,
+	ErrServerNoMsgProtocol.Code:         StatusUnimplemented,
+	ErrServerNoService.Code:             StatusUnimplemented,
+	ErrServerNoMethod.Code:              StatusUnimplemented,
+	ErrServerNoSupportEncodeType.Code:   StatusUnimplemented,
+	ErrServerDecodeDataErr.Code:         StatusInvalidArgument,
+	ErrServerEncodeDataErr.Code:         StatusInternal,
+	ErrServerNoSupportCompressType.Code: StatusUnimplemented,
+	ErrServerTimeout.Code:               StatusDeadlineExceeded,
+	ErrServerOverload.Code:              StatusResourceExhausted,
+	ErrUnknown.Code:                     StatusUnknown,
+}
+
+// NewStatusError 创建一个以标准状态码为 Code 的框架error
+func NewStatusError(code StatusCode, msg string) *Error {
+	return NewFrameError(int(code), msg)
+}
+
+// Status 返回 err 对应的标准状态码：
+// nil 为 OK；框架错误按 Code 映射；业务错误为 Unknown；
+// context 的取消和超时分别为 Canceled、DeadlineExceeded；
+// 网络超时为 DeadlineExceeded，其他网络错误（如连接失败）为 Unavailable。
+// 以上判断都沿错误链进行，被 fmt.Errorf("%w") 包装的错误同样适用
+func Status(err error) StatusCode {
+	if err == nil {
+		return StatusOK
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		s := frameworkStatus(e)
+		// 无法按错误码分类的框架错误（如 FromError 包装的 ErrUnknown）按被包装的原始错误分类
+		if s != StatusUnknown || e.Type != ErrorTypeFramework || e.cause == nil {
+			return s
+		}
+		err = e.cause
+	}
+	if errors.Is(err, context.Canceled) {
+		return StatusCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return StatusDeadlineExceeded
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		if ne.Timeout() {
+			return StatusDeadlineExceeded
+		}
+		return StatusUnavailable
+	}
+	return StatusUnknown
+}
+
+// frameworkStatus 按错误码返回 *Error 的状态码，业务错误为 Unknown
+func frameworkStatus(e *Error) StatusCode {
+	if e.Type != ErrorTypeFramework {
+		return StatusUnknown
+	}
+	if s, ok := frameStatus[e.Code]; ok {
+		return s
+	}
+	if _, ok := statusNames[StatusCode(e.Code)]; ok {
+		return StatusCode(e.Code)
+	}
+	return StatusUnknown
+}
+
+// retryable 服务端确定没有处理请求的状态码，重试不会重复执行请求
+var retryable = map[StatusCode]bool{
+	StatusUnavailable:       true,
+	StatusResourceExhausted: true,
+	StatusAborted:           true,
+}
+
+// Retryable 状态码是否可以安全重试
+func (c StatusCode) Retryable() bool {
+	return retryable[c]
+}
+
+// IsRetryable err 是否可以安全重试。
+// DeadlineExceeded 等状态下服务端可能已经执行了请求，不认为可以重试
+func IsRetryable(err error) bool {
+	return Status(err).Retryable()
+}
+
+// HTTPStatus 返回状态码对应的 HTTP 状态码
+func (c StatusCode) HTTPStatus() int {
+	switch c {
+	case StatusOK:
+		return http.StatusOK
+	case StatusCanceled:
+		return 499 // Client Closed Request
+	case StatusInvalidArgument, StatusFailedPrecondition, StatusOutOfRange:
+		return http.StatusBadRequest
+	case StatusDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case StatusNotFound:
+		return http.StatusNotFound
+	case StatusAlreadyExists, StatusAborted:
+		return http.StatusConflict
+	case StatusPermissionDenied:
+		return http.StatusForbidden
+	case StatusUnauthenticated:
+		return http.StatusUnauthorized
+	case StatusResourceExhausted:
+		return http.StatusTooManyRequests
+	case StatusUnimplemented:
+		return http.StatusNotImplemented
+	case StatusUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
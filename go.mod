@@ -4,10 +4,13 @@ go 1.13
 
 require (
 	github.com/BurntSushi/toml v0.3.1
+	github.com/apache/thrift v0.13.0
 	github.com/edwingeng/doublejump v0.0.0-20190102103700-461a0155c7be
 	github.com/golang/protobuf v1.3.2
 	github.com/hillguo/sanhttp v0.0.0-20200324135546-a8011f2d2ca8
 	github.com/hillguo/sanlog v0.0.0-20191108155211-bfd64160ecd6
+	github.com/jhump/protoreflect v1.5.0
+	github.com/ugorji/go/codec v1.1.7
 	github.com/valyala/fastrand v1.0.0
-	golang.org/x/sys v0.0.0-20191010194322-b09406accb47 // indirect
+	golang.org/x/net v0.11.0
 )
@@ -0,0 +1,49 @@
+package metadata
+
+import "context"
+
+// MD 一次调用携带的元数据，请求时放在 HeaderMsg.MetaData 中透传
+type MD map[string]string
+
+type mdKey struct{}
+
+// NewContext 把元数据放入 ctx
+func NewContext(ctx context.Context, md MD) context.Context {
+	return context.WithValue(ctx, mdKey{}, md)
+}
+
+// FromContext 取出 ctx 中的元数据
+func FromContext(ctx context.Context) (MD, bool) {
+	md, ok := ctx.Value(mdKey{}).(MD)
+	return md, ok
+}
+
+// Get 返回 key 对应的值
+func (md MD) Get(key string) string {
+	return md[key]
+}
+
+// Copy 返回元数据的拷贝
+func (md MD) Copy() MD {
+	c := make(MD, len(md))
+	for k, v := range md {
+		c[k] = v
+	}
+	return c
+}
+
+type respKey struct{}
+
+// NewResponseContext 服务端为一次调用准备响应元数据，handler 通过 SetResponse 设置，
+// 协议在调用结束后把返回的 MD 写回客户端
+func NewResponseContext(ctx context.Context) (context.Context, MD) {
+	md := MD{}
+	return context.WithValue(ctx, respKey{}, md), md
+}
+
+// SetResponse 设置响应元数据，ctx 不是服务端调用的 ctx 时忽略
+func SetResponse(ctx context.Context, key, value string) {
+	if md, ok := ctx.Value(respKey{}).(MD); ok {
+		md[key] = value
+	}
+}
@@ -0,0 +1,9 @@
+package naming
+
+// 节点元数据中约定的 key，服务端注册时写入，客户端 router 按其过滤节点
+const (
+	MetaNamespace = "namespace" // 命名空间 Production/Development
+	MetaEnvName   = "env"       // 环境
+	MetaSetName   = "set"       // set分组
+	MetaZone      = "zone"      // 可用区
+)
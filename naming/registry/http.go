@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPPathPrefix http 注册中心的 url 前缀：
+//
+//	PUT    /registry/{service}            注册或续期，body 为 HTTPInstance
+//	DELETE /registry/{service}?address=   注销
+//	GET    /registry/{service}            返回 []*Instance
+const HTTPPathPrefix = "/registry/"
+
+// HTTPInstance 注册请求
+type HTTPInstance struct {
+	Instance
+	TTL int64 `json:"ttl_ms"` // 租约时长，毫秒，必须大于 0
+}
+
+type httpHandler struct {
+	store *Store
+}
+
+// NewHTTPHandler 返回把 store 以 http 接口提供出去的 handler
+func NewHTTPHandler(store *Store) http.Handler {
+	return &httpHandler{store: store}
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serviceName := strings.TrimPrefix(r.URL.Path, HTTPPathPrefix)
+	if serviceName == "" || serviceName == r.URL.Path || strings.Contains(serviceName, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(h.store.List(serviceName))
+	case http.MethodPut:
+		req := &HTTPInstance{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Address == "" {
+			http.Error(w, ErrAddressEmpty.Error(), http.StatusBadRequest)
+			return
+		}
+		req.ServiceName = serviceName
+		if err := h.store.Put(&req.Instance, time.Duration(req.TTL)*time.Millisecond); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		address := r.URL.Query().Get("address")
+		if address == "" {
+			http.Error(w, ErrAddressEmpty.Error(), http.StatusBadRequest)
+			return
+		}
+		h.store.Delete(serviceName, address)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HTTPRegistry 把实例注册到 NewHTTPHandler 提供的 http 注册中心
+type HTTPRegistry struct {
+	Addr   string // 注册中心地址 http://host:port
+	Client *http.Client
+
+	hb Heartbeats
+}
+
+// NewHTTPRegistry 创建 HTTPRegistry
+func NewHTTPRegistry(addr string) *HTTPRegistry {
+	return &HTTPRegistry{
+		Addr:   strings.TrimSuffix(addr, "/"),
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (r *HTTPRegistry) Register(serviceName string, opt ...Option) error {
+	inst, ttl, err := NewInstance(serviceName, opt...)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(&HTTPInstance{Instance: *inst, TTL: int64(ttl / time.Millisecond)})
+	if err != nil {
+		return err
+	}
+	put := func() error {
+		return r.do(http.MethodPut, r.url(serviceName, ""), body)
+	}
+	if err := put(); err != nil {
+		return err
+	}
+
+	r.hb.Start(InstanceKey(serviceName, inst.Address), ttl/3, put)
+	return nil
+}
+
+func (r *HTTPRegistry) Deregister(serviceName string, opt ...Option) error {
+	inst, _, err := NewInstance(serviceName, opt...)
+	if err != nil {
+		return err
+	}
+	r.hb.Stop(InstanceKey(serviceName, inst.Address))
+	return r.do(http.MethodDelete, r.url(serviceName, inst.Address), nil)
+}
+
+func (r *HTTPRegistry) url(serviceName, address string) string {
+	u := r.Addr + HTTPPathPrefix + url.PathEscape(serviceName)
+	if address != "" {
+		u += "?address=" + url.QueryEscape(address)
+	}
+	return u
+}
+
+func (r *HTTPRegistry) do(method, u string, body []byte) error {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("registry: %s %s: %s %s", method, u, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package registry
+
+import (
+	log "github.com/hillguo/sanlog"
+)
+
+// MemoryRegistry 把实例注册到进程内的 Store，一般与 NewHTTPHandler 配合对外提供注册中心
+type MemoryRegistry struct {
+	Store *Store
+
+	hb Heartbeats
+}
+
+// NewMemoryRegistry 创建 MemoryRegistry
+func NewMemoryRegistry(store *Store) *MemoryRegistry {
+	return &MemoryRegistry{Store: store}
+}
+
+func (r *MemoryRegistry) Register(serviceName string, opt ...Option) error {
+	inst, ttl, err := NewInstance(serviceName, opt...)
+	if err != nil {
+		return err
+	}
+	if err := r.Store.Put(inst, ttl); err != nil {
+		return err
+	}
+	r.hb.Start(InstanceKey(serviceName, inst.Address), ttl/3, func() error {
+		if !r.Store.Renew(serviceName, inst.Address) {
+			// 租约已过期被清理，重新注册
+			log.Warnf("registry: lease of %s %s lost, register again", serviceName, inst.Address)
+			return r.Store.Put(inst, ttl)
+		}
+		return nil
+	})
+	return nil
+}
+
+func (r *MemoryRegistry) Deregister(serviceName string, opt ...Option) error {
+	inst, _, err := NewInstance(serviceName, opt...)
+	if err != nil {
+		return err
+	}
+	r.hb.Stop(InstanceKey(serviceName, inst.Address))
+	r.Store.Delete(serviceName, inst.Address)
+	return nil
+}
@@ -0,0 +1,66 @@
+package registry
+
+import "time"
+
+// Options 注册参数
+type Options struct {
+	Network  string
+	Address  string // ip:port
+	Weight   int
+	Metadata map[string]string
+	TTL      time.Duration // 实例租约时长，默认 DefaultTTL，必须大于 0
+}
+
+// Option 注册参数工具函数
+type Option func(*Options)
+
+func WithNetwork(network string) Option {
+	return func(o *Options) {
+		o.Network = network
+	}
+}
+
+func WithAddress(address string) Option {
+	return func(o *Options) {
+		o.Address = address
+	}
+}
+
+func WithWeight(weight int) Option {
+	return func(o *Options) {
+		o.Weight = weight
+	}
+}
+
+func WithMetadata(md map[string]string) Option {
+	return func(o *Options) {
+		o.Metadata = md
+	}
+}
+
+func WithTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+// NewInstance 由注册参数生成实例，同时返回租约时长
+func NewInstance(serviceName string, opt ...Option) (*Instance, time.Duration, error) {
+	opts := &Options{TTL: DefaultTTL}
+	for _, o := range opt {
+		o(opts)
+	}
+	if opts.Address == "" {
+		return nil, 0, ErrAddressEmpty
+	}
+	if opts.TTL < MinTTL {
+		return nil, 0, ErrTTLInvalid
+	}
+	return &Instance{
+		ServiceName: serviceName,
+		Network:     opts.Network,
+		Address:     opts.Address,
+		Weight:      opts.Weight,
+		Metadata:    opts.Metadata,
+	}, opts.TTL, nil
+}
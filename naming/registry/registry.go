@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/hillguo/sanlog"
+)
+
+// Registry 服务注册接口，service 监听成功后注册，关闭时注销。
+// 注册时设置了 TTL 的实例需要实现方定期续期，直到 Deregister。
+type Registry interface {
+	Register(serviceName string, opt ...Option) error
+	Deregister(serviceName string, opt ...Option) error
+}
+
+// Instance 注册到名字服务的一个服务实例
+type Instance struct {
+	ServiceName string            `json:"service_name"`
+	Network     string            `json:"network,omitempty"`
+	Address     string            `json:"address"`
+	Weight      int               `json:"weight,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// DefaultTTL 默认的实例租约时长
+const DefaultTTL = 30 * time.Second
+
+// ErrAddressEmpty 注册时没有指定地址
+var ErrAddressEmpty = errors.New("registry: address empty")
+
+// MinTTL 注册时允许的最小租约时长，续期间隔为租约时长的 1/3
+const MinTTL = time.Second
+
+// minHeartbeatInterval Heartbeats 的最小续期间隔
+const minHeartbeatInterval = 100 * time.Millisecond
+
+// ErrTTLInvalid 注册时的租约时长小于 MinTTL，或写入 Store 的租约时长不大于 0
+var ErrTTLInvalid = errors.New("registry: ttl too short")
+
+var (
+	registries = make(map[string]Registry)
+	lock       = sync.RWMutex{}
+)
+
+// Register 注册registry
+func Register(name string, r Registry) {
+	lock.Lock()
+	registries[name] = r
+	lock.Unlock()
+}
+
+// Get 获取registry
+func Get(name string) Registry {
+	lock.RLock()
+	r := registries[name]
+	lock.RUnlock()
+	return r
+}
+
+// Heartbeats 管理每个实例的续期协程，供 Registry 实现复用
+type Heartbeats struct {
+	mu    sync.Mutex
+	stops map[string]chan struct{} // serviceName/address => stop
+}
+
+// Start 每隔 interval 执行一次 fn，fn 返回错误时记录日志，同一个 key 只保留最新的协程。
+// interval 小于 minHeartbeatInterval 时按 minHeartbeatInterval 执行
+func (h *Heartbeats) Start(key string, interval time.Duration, fn func() error) {
+	if interval < minHeartbeatInterval {
+		interval = minHeartbeatInterval
+	}
+	stop := make(chan struct{})
+	h.mu.Lock()
+	if h.stops == nil {
+		h.stops = make(map[string]chan struct{})
+	}
+	if old, ok := h.stops[key]; ok {
+		close(old)
+	}
+	h.stops[key] = stop
+	h.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := fn(); err != nil {
+					log.Errorf("registry: heartbeat %s fail: %v", key, err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止 key 对应的续期协程
+func (h *Heartbeats) Stop(key string) {
+	h.mu.Lock()
+	if stop, ok := h.stops[key]; ok {
+		close(stop)
+		delete(h.stops, key)
+	}
+	h.mu.Unlock()
+}
+
+// InstanceKey 实例在 Heartbeats 中的 key
+func InstanceKey(serviceName, address string) string {
+	return serviceName + "/" + address
+}
@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatsMinInterval(t *testing.T) {
+	var h Heartbeats
+	var calls int32
+	// interval 为 0 时 time.NewTicker 会 panic，按 minHeartbeatInterval 执行
+	h.Start("svc/127.0.0.1:8000", 0, func() error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	defer h.Stop("svc/127.0.0.1:8000")
+
+	time.Sleep(minHeartbeatInterval * 5 / 2)
+	if n := atomic.LoadInt32(&calls); n < 1 || n > 2 {
+		t.Errorf("fn called %d times in %v, want 1 or 2", n, minHeartbeatInterval*5/2)
+	}
+}
+
+func TestMemoryRegistryRejectsShortTTL(t *testing.T) {
+	store := NewStore(0)
+	defer store.Close()
+	r := NewMemoryRegistry(store)
+	if err := r.Register("svc", WithAddress("127.0.0.1:8000"), WithTTL(2)); err != ErrTTLInvalid {
+		t.Errorf("Register(ttl 2ns) error = %v, want %v", err, ErrTTLInvalid)
+	}
+	if n := len(store.List("svc")); n != 0 {
+		t.Errorf("rejected Register stored %d instances", n)
+	}
+}
@@ -0,0 +1,111 @@
+package registryclient
+
+import (
+	"context"
+	"time"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/client"
+	"github.com/hillguo/sanrpc/client/discovery"
+	"github.com/hillguo/sanrpc/client/node"
+	pb "github.com/hillguo/sanrpc/naming/registry/registrypb"
+)
+
+// DefaultWait Watch 每次长轮询的等待时间
+const DefaultWait = 30 * time.Second
+
+// retryInterval 长轮询失败后的重试间隔
+const retryInterval = time.Second
+
+// Discovery 从 registryserver 读取服务实例，Watch 通过 List 长轮询推送变更
+type Discovery struct {
+	list  *client.Client
+	watch *client.Client
+	wait  time.Duration
+}
+
+// NewDiscovery 创建 Discovery，addr 为注册中心地址 ip:port，多个地址用逗号分隔
+func NewDiscovery(addr string) *Discovery {
+	return &Discovery{
+		list:  newClient(addr, "list", callTimeout),
+		watch: newClient(addr, "list", DefaultWait+callTimeout),
+		wait:  DefaultWait,
+	}
+}
+
+// RegisterDiscovery 创建 Discovery 并以 "sanrpc-registry" 注册
+func RegisterDiscovery(addr string) *Discovery {
+	d := NewDiscovery(addr)
+	discovery.Register(d.Name(), d)
+	return d
+}
+
+func (d *Discovery) List(serviceName string) ([]*node.Node, error) {
+	nodes, _, err := d.poll(context.Background(), d.list, serviceName, 0, 0)
+	return nodes, err
+}
+
+func (d *Discovery) Watch(ctx context.Context, serviceName string) (<-chan *discovery.Event, error) {
+	last, revision, err := d.poll(ctx, d.list, serviceName, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *discovery.Event, 16)
+	go func() {
+		defer close(ch)
+		for ctx.Err() == nil {
+			cur, rev, err := d.poll(ctx, d.watch, serviceName, revision, d.wait)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Errorf("discovery: watch registry for %s fail, keep last nodes: %v", serviceName, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(retryInterval):
+				}
+				continue
+			}
+			revision = rev
+			for _, ev := range discovery.Diff(last, cur) {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = cur
+		}
+	}()
+	return ch, nil
+}
+
+func (d *Discovery) Name() string {
+	return "sanrpc-registry"
+}
+
+// poll 调用注册中心的 List，revision 未变化时最多等待 wait，ctx 结束时中断等待
+func (d *Discovery) poll(ctx context.Context, c *client.Client, serviceName string, revision uint64, wait time.Duration) ([]*node.Node, uint64, error) {
+	req := &pb.ListReq{
+		ServiceName: serviceName,
+		Revision:    revision,
+		WaitMs:      int64(wait / time.Millisecond),
+	}
+	resp := &pb.ListResp{}
+	if err := c.Invoke(ctx, req, resp); err != nil {
+		return nil, 0, err
+	}
+	nodes := make([]*node.Node, 0, len(resp.GetInstances()))
+	for _, inst := range resp.GetInstances() {
+		nodes = append(nodes, &node.Node{
+			ServiceName: inst.GetServiceName(),
+			Network:     inst.GetNetwork(),
+			Address:     inst.GetAddress(),
+			Weight:      int(inst.GetWeight()),
+			Metadata:    inst.GetMetadata(),
+		})
+	}
+	return nodes, resp.GetRevision(), nil
+}
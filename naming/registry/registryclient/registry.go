@@ -0,0 +1,85 @@
+package registryclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/hillguo/sanrpc/client"
+	"github.com/hillguo/sanrpc/naming/registry"
+	pb "github.com/hillguo/sanrpc/naming/registry/registrypb"
+)
+
+// ServiceName 注册中心的 sanrpc 服务名
+const ServiceName = "registry"
+
+const callTimeout = 5 * time.Second
+
+func newClient(addr, method string, readTimeout time.Duration) *client.Client {
+	return client.NewClient(
+		client.WithAddress(addr),
+		client.WithServiceName(ServiceName),
+		client.WithMethodName(method),
+		client.WithConnectTimeout(uint64(callTimeout)),
+		client.WithWriteTimeout(uint64(callTimeout)),
+		client.WithReadTimeout(uint64(readTimeout)),
+	)
+}
+
+// Registry 通过 sanrpc 把实例注册到 registryserver
+type Registry struct {
+	register   *client.Client
+	deregister *client.Client
+
+	hb registry.Heartbeats
+}
+
+// NewRegistry 创建 Registry，addr 为注册中心地址 ip:port，多个地址用逗号分隔
+func NewRegistry(addr string) *Registry {
+	return &Registry{
+		register:   newClient(addr, "register", callTimeout),
+		deregister: newClient(addr, "deregister", callTimeout),
+	}
+}
+
+// RegisterRegistry 创建 Registry 并以 name 注册，供配置中的 registry 字段引用
+func RegisterRegistry(name, addr string) *Registry {
+	r := NewRegistry(addr)
+	registry.Register(name, r)
+	return r
+}
+
+func (r *Registry) Register(serviceName string, opt ...registry.Option) error {
+	inst, ttl, err := registry.NewInstance(serviceName, opt...)
+	if err != nil {
+		return err
+	}
+	req := &pb.RegisterReq{
+		Instance: &pb.Instance{
+			ServiceName: inst.ServiceName,
+			Network:     inst.Network,
+			Address:     inst.Address,
+			Weight:      int32(inst.Weight),
+			Metadata:    inst.Metadata,
+		},
+		TtlMs: int64(ttl / time.Millisecond),
+	}
+	put := func() error {
+		return r.register.Invoke(context.Background(), req, &pb.RegisterResp{})
+	}
+	if err := put(); err != nil {
+		return err
+	}
+
+	r.hb.Start(registry.InstanceKey(serviceName, inst.Address), ttl/3, put)
+	return nil
+}
+
+func (r *Registry) Deregister(serviceName string, opt ...registry.Option) error {
+	inst, _, err := registry.NewInstance(serviceName, opt...)
+	if err != nil {
+		return err
+	}
+	r.hb.Stop(registry.InstanceKey(serviceName, inst.Address))
+	req := &pb.DeregisterReq{ServiceName: serviceName, Address: inst.Address}
+	return r.deregister.Invoke(context.Background(), req, &pb.DeregisterResp{})
+}
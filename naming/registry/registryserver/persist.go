@@ -0,0 +1,86 @@
+package registryserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/naming/registry"
+)
+
+// persistLease 持久化文件中的一个实例
+type persistLease struct {
+	registry.Instance
+	TTL int64 `json:"ttl_ms"`
+}
+
+// Load 从 path 恢复实例，每个实例按原租约时长重新计时，没有续期的实例会正常过期
+func Load(store *registry.Store, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var leases []*persistLease
+	if err := json.Unmarshal(data, &leases); err != nil {
+		return err
+	}
+	for _, l := range leases {
+		inst := l.Instance
+		if err := store.Put(&inst, time.Duration(l.TTL)*time.Millisecond); err != nil {
+			log.Errorf("registry: skip %s %s in %s: %v", inst.ServiceName, inst.Address, path, err)
+		}
+	}
+	log.Infof("registry: load %d instances from %s", len(leases), path)
+	return nil
+}
+
+// Persist 每次实例变化后把全部实例写入 path，直到 done 关闭。
+// done 关闭后再保存一次，保证退出前的最后变化不会丢失，保存完成后才返回
+func Persist(store *registry.Store, path string, done <-chan struct{}) {
+	for {
+		changed := store.Changed()
+		if err := save(store, path); err != nil {
+			log.Errorf("registry: persist to %s fail: %v", path, err)
+		}
+		select {
+		case <-done:
+			if err := save(store, path); err != nil {
+				log.Errorf("registry: persist to %s fail: %v", path, err)
+			}
+			return
+		case <-changed:
+		}
+	}
+}
+
+// save 先写临时文件再改名，避免写到一半的文件
+func save(store *registry.Store, path string) error {
+	var leases []*persistLease
+	for _, l := range store.Dump() {
+		leases = append(leases, &persistLease{Instance: *l.Instance, TTL: int64(l.TTL / time.Millisecond)})
+	}
+	data, err := json.Marshal(leases)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
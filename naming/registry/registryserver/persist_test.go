@@ -0,0 +1,51 @@
+package registryserver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/naming/registry"
+)
+
+func TestPersistSavesOnDone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "registry.json")
+
+	store := registry.NewStore(0)
+	defer store.Close()
+	done := make(chan struct{})
+	returned := make(chan struct{})
+	go func() {
+		Persist(store, path, done)
+		close(returned)
+	}()
+
+	// 变化后立即关闭 done，Persist 返回前必须已经保存了这次变化
+	inst := &registry.Instance{ServiceName: "svc", Address: "127.0.0.1:8000", Weight: 3}
+	if err := store.Put(inst, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	close(done)
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Persist() did not return after done")
+	}
+
+	restored := registry.NewStore(0)
+	defer restored.Close()
+	if err := Load(restored, path); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	insts := restored.List("svc")
+	if len(insts) != 1 || insts[0].Address != inst.Address || insts[0].Weight != 3 {
+		t.Errorf("restored instances = %v, want %v", insts, inst)
+	}
+}
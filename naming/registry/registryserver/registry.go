@@ -0,0 +1,98 @@
+package registryserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/naming/registry"
+	pb "github.com/hillguo/sanrpc/naming/registry/registrypb"
+)
+
+// MaxWait List 长轮询的最长等待时间
+const MaxWait = time.Minute
+
+// Registry 以 sanrpc 服务提供的注册中心，服务名 registry
+type Registry struct {
+	store *registry.Store
+}
+
+// New 创建注册中心服务
+func New(store *registry.Store) *Registry {
+	return &Registry{store: store}
+}
+
+// Register 注册或续期
+func (r *Registry) Register(ctx context.Context, req *pb.RegisterReq, resp *pb.RegisterResp) error {
+	inst := req.GetInstance()
+	if inst.GetServiceName() == "" || inst.GetAddress() == "" {
+		return errs.NewStatusError(errs.StatusInvalidArgument, "service_name and address required")
+	}
+	// 没有租约的实例永不过期，心跳失去意义
+	if req.GetTtlMs() <= 0 {
+		return errs.NewStatusError(errs.StatusInvalidArgument, "ttl_ms must be positive")
+	}
+	return r.store.Put(FromPB(inst), time.Duration(req.GetTtlMs())*time.Millisecond)
+}
+
+// Deregister 注销
+func (r *Registry) Deregister(ctx context.Context, req *pb.DeregisterReq, resp *pb.DeregisterResp) error {
+	if req.GetServiceName() == "" || req.GetAddress() == "" {
+		return errs.NewStatusError(errs.StatusInvalidArgument, "service_name and address required")
+	}
+	r.store.Delete(req.GetServiceName(), req.GetAddress())
+	return nil
+}
+
+// List 返回服务的实例。req.Revision 与当前版本相同时最多等待 req.WaitMs 直到有变化
+func (r *Registry) List(ctx context.Context, req *pb.ListReq, resp *pb.ListResp) error {
+	revision := r.store.Revision(req.GetServiceName())
+	if req.GetRevision() == revision && req.GetWaitMs() > 0 {
+		var changed <-chan struct{}
+		var cancel func()
+		revision, changed, cancel = r.store.Watch(req.GetServiceName())
+		defer cancel()
+		wait := time.Duration(req.GetWaitMs()) * time.Millisecond
+		if wait > MaxWait {
+			wait = MaxWait
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		if req.GetRevision() == revision {
+			select {
+			case <-changed:
+			case <-timer.C:
+			case <-ctx.Done():
+			}
+		}
+		revision = r.store.Revision(req.GetServiceName())
+	}
+
+	for _, inst := range r.store.List(req.GetServiceName()) {
+		resp.Instances = append(resp.Instances, ToPB(inst))
+	}
+	resp.Revision = revision
+	return nil
+}
+
+// ToPB 转换为 pb 实例
+func ToPB(inst *registry.Instance) *pb.Instance {
+	return &pb.Instance{
+		ServiceName: inst.ServiceName,
+		Network:     inst.Network,
+		Address:     inst.Address,
+		Weight:      int32(inst.Weight),
+		Metadata:    inst.Metadata,
+	}
+}
+
+// FromPB 从 pb 实例转换
+func FromPB(inst *pb.Instance) *registry.Instance {
+	return &registry.Instance{
+		ServiceName: inst.GetServiceName(),
+		Network:     inst.GetNetwork(),
+		Address:     inst.GetAddress(),
+		Weight:      int(inst.GetWeight()),
+		Metadata:    inst.GetMetadata(),
+	}
+}
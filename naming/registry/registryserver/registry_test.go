@@ -0,0 +1,88 @@
+package registryserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/naming/registry"
+	pb "github.com/hillguo/sanrpc/naming/registry/registrypb"
+)
+
+func TestRegisterInvalid(t *testing.T) {
+	store := registry.NewStore(0)
+	defer store.Close()
+	r := New(store)
+
+	tests := []struct {
+		name string
+		req  *pb.RegisterReq
+	}{
+		{name: "no service name", req: &pb.RegisterReq{Instance: &pb.Instance{Address: "127.0.0.1:8000"}, TtlMs: 1000}},
+		{name: "no address", req: &pb.RegisterReq{Instance: &pb.Instance{ServiceName: "svc"}, TtlMs: 1000}},
+		{name: "zero ttl", req: &pb.RegisterReq{Instance: &pb.Instance{ServiceName: "svc", Address: "127.0.0.1:8000"}}},
+		{name: "negative ttl", req: &pb.RegisterReq{Instance: &pb.Instance{ServiceName: "svc", Address: "127.0.0.1:8000"}, TtlMs: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Register(context.Background(), tt.req, &pb.RegisterResp{})
+			if s := errs.Status(err); s != errs.StatusInvalidArgument {
+				t.Fatalf("Register() error = %v, status %v, want %v", err, s, errs.StatusInvalidArgument)
+			}
+		})
+	}
+	if n := len(store.List("svc")); n != 0 {
+		t.Errorf("invalid registrations stored %d instances", n)
+	}
+
+	err := r.Deregister(context.Background(), &pb.DeregisterReq{ServiceName: "svc"}, &pb.DeregisterResp{})
+	if s := errs.Status(err); s != errs.StatusInvalidArgument {
+		t.Errorf("Deregister() error = %v, status %v, want %v", err, s, errs.StatusInvalidArgument)
+	}
+}
+
+func TestListLongPoll(t *testing.T) {
+	store := registry.NewStore(0)
+	defer store.Close()
+	r := New(store)
+	inst := &pb.Instance{ServiceName: "svc", Address: "127.0.0.1:8000"}
+	if err := r.Register(context.Background(), &pb.RegisterReq{Instance: inst, TtlMs: 60000}, &pb.RegisterResp{}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	first := &pb.ListResp{}
+	if err := r.List(context.Background(), &pb.ListReq{ServiceName: "svc"}, first); err != nil || len(first.Instances) != 1 {
+		t.Fatalf("List() = %v, %v", first, err)
+	}
+
+	// 版本变化时返回
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		r.Deregister(context.Background(), &pb.DeregisterReq{ServiceName: "svc", Address: inst.Address}, &pb.DeregisterResp{})
+	}()
+	resp := &pb.ListResp{}
+	if err := r.List(context.Background(), &pb.ListReq{ServiceName: "svc", Revision: first.Revision, WaitMs: 5000}, resp); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(resp.Instances) != 0 || resp.Revision == first.Revision {
+		t.Errorf("List() after deregister = %v", resp)
+	}
+
+	// 没有实例也没有等待者的服务不保留版本号，版本号变为 0
+	empty := &pb.ListResp{}
+	if err := r.List(context.Background(), &pb.ListReq{ServiceName: "svc", Revision: resp.Revision, WaitMs: 5000}, empty); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(empty.Instances) != 0 || empty.Revision != 0 {
+		t.Errorf("List() of a removed service = %v, want revision 0", empty)
+	}
+
+	// 调用方取消时返回
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	r.List(ctx, &pb.ListReq{ServiceName: "svc", Revision: empty.Revision, WaitMs: 5000}, &pb.ListResp{})
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("List() waited %v after ctx done", d)
+	}
+}
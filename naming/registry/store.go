@@ -0,0 +1,264 @@
+package registry
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store 内存中带租约的服务实例表
+type Store struct {
+	mu       sync.Mutex
+	services map[string]map[string]*entry // serviceName => address => lease
+	// watches 有实例或有等待者的服务的版本，按服务通知，避免一个服务的变化唤醒所有长轮询。
+	// 其他服务（如查询不存在的服务名）不保存，版本为 0
+	watches  map[string]*watch
+	revision uint64        // 最近一次变化的全局版本号，服务的版本号取自它，删除后重建也不会重复
+	changed  chan struct{} // 任意实例变化时关闭并替换，用于持久化
+
+	done chan struct{}
+	once sync.Once
+}
+
+// watch 一个服务的版本号、版本变化时会被关闭的 channel 和等待者数量
+type watch struct {
+	revision uint64
+	changed  chan struct{}
+	waiters  int
+}
+
+type entry struct {
+	inst   *Instance
+	ttl    time.Duration
+	expire time.Time
+}
+
+// Lease 实例及其租约时长
+type Lease struct {
+	Instance *Instance
+	TTL      time.Duration
+}
+
+// NewStore 创建 Store，并每隔 sweepInterval 清理一次过期实例
+func NewStore(sweepInterval time.Duration) *Store {
+	s := &Store{
+		services: make(map[string]map[string]*entry),
+		watches:  make(map[string]*watch),
+		changed:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if sweepInterval > 0 {
+		go s.sweep(sweepInterval)
+	}
+	return s
+}
+
+func (s *Store) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.Expire(now)
+		}
+	}
+}
+
+// Put 添加实例或为实例续期，ttl 必须大于 0，否则返回 ErrTTLInvalid
+func (s *Store) Put(inst *Instance, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrTTLInvalid
+	}
+	l := &entry{inst: inst, ttl: ttl, expire: time.Now().Add(ttl)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	insts := s.services[inst.ServiceName]
+	if insts == nil {
+		insts = make(map[string]*entry)
+		s.services[inst.ServiceName] = insts
+	}
+	old := insts[inst.Address]
+	insts[inst.Address] = l
+	// 单纯的续期不通知，已过期未清理的实例重新出现时需要通知
+	if old == nil || old.expire.Before(time.Now()) || !sameInstance(old.inst, inst) {
+		s.notify(inst.ServiceName)
+	}
+	return nil
+}
+
+// Renew 按实例原来的租约时长续期，实例不存在或已过期时返回 false
+func (s *Store) Renew(serviceName, address string) bool {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.services[serviceName][address]
+	if !ok || l.expire.Before(now) {
+		return false
+	}
+	l.expire = now.Add(l.ttl)
+	return true
+}
+
+// Delete 删除实例
+func (s *Store) Delete(serviceName, address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	insts := s.services[serviceName]
+	if _, ok := insts[address]; !ok {
+		return
+	}
+	delete(insts, address)
+	if len(insts) == 0 {
+		delete(s.services, serviceName)
+	}
+	s.notify(serviceName)
+}
+
+// Expire 删除在 now 之前过期的实例
+func (s *Store) Expire(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, insts := range s.services {
+		expired := false
+		for addr, l := range insts {
+			if l.expire.Before(now) {
+				delete(insts, addr)
+				expired = true
+			}
+		}
+		if len(insts) == 0 {
+			delete(s.services, name)
+		}
+		if expired {
+			s.notify(name)
+		}
+	}
+}
+
+// List 返回服务未过期的实例，按地址排序
+func (s *Store) List(serviceName string) []*Instance {
+	now := time.Now()
+	s.mu.Lock()
+	insts := make([]*Instance, 0, len(s.services[serviceName]))
+	for _, l := range s.services[serviceName] {
+		if !l.expire.Before(now) {
+			insts = append(insts, l.inst)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(insts, func(i, j int) bool { return insts[i].Address < insts[j].Address })
+	return insts
+}
+
+// Dump 返回所有未过期的实例及其租约时长，用于持久化
+func (s *Store) Dump() []Lease {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var leases []Lease
+	for _, insts := range s.services {
+		for _, l := range insts {
+			if !l.expire.Before(now) {
+				leases = append(leases, Lease{Instance: l.inst, TTL: l.ttl})
+			}
+		}
+	}
+	return leases
+}
+
+// Services 返回所有服务名
+func (s *Store) Services() []string {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.services))
+	for name := range s.services {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+// Revision 返回服务的当前版本号，没有实例也没有等待者的服务为 0
+func (s *Store) Revision(serviceName string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w := s.watches[serviceName]; w != nil {
+		return w.revision
+	}
+	return 0
+}
+
+// Watch 返回服务的当前版本号和该服务实例变化时会被关闭的 channel。
+// 调用方不再等待时必须调用 cancel，否则服务的版本信息不会被清理
+func (s *Store) Watch(serviceName string) (revision uint64, changed <-chan struct{}, cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.watch(serviceName)
+	w.waiters++
+	var once sync.Once
+	return w.revision, w.changed, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			w.waiters--
+			s.release(serviceName)
+		})
+	}
+}
+
+// Changed 返回任意实例变化时会被关闭的 channel
+func (s *Store) Changed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+// watch 调用方需持有 s.mu
+func (s *Store) watch(serviceName string) *watch {
+	w := s.watches[serviceName]
+	if w == nil {
+		w = &watch{changed: make(chan struct{})}
+		s.watches[serviceName] = w
+	}
+	return w
+}
+
+// release 服务没有实例也没有等待者时删除其版本信息，调用方需持有 s.mu
+func (s *Store) release(serviceName string) {
+	if w := s.watches[serviceName]; w != nil && w.waiters == 0 && len(s.services[serviceName]) == 0 {
+		delete(s.watches, serviceName)
+	}
+}
+
+// Close 停止清理过期实例
+func (s *Store) Close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// notify 调用方需持有 s.mu
+func (s *Store) notify(serviceName string) {
+	s.revision++
+	w := s.watch(serviceName)
+	w.revision = s.revision
+	close(w.changed)
+	w.changed = make(chan struct{})
+	s.release(serviceName)
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func sameInstance(a, b *Instance) bool {
+	if a.Network != b.Network || a.Weight != b.Weight || len(a.Metadata) != len(b.Metadata) {
+		return false
+	}
+	for k, v := range a.Metadata {
+		if bv, ok := b.Metadata[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
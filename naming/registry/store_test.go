@@ -0,0 +1,124 @@
+package registry
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewInstanceTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     []Option
+		wantTTL time.Duration
+		wantErr error
+	}{
+		{name: "default", opt: []Option{WithAddress("127.0.0.1:8000")}, wantTTL: DefaultTTL},
+		{name: "custom", opt: []Option{WithAddress("127.0.0.1:8000"), WithTTL(time.Second)}, wantTTL: time.Second},
+		{name: "zero", opt: []Option{WithAddress("127.0.0.1:8000"), WithTTL(0)}, wantErr: ErrTTLInvalid},
+		{name: "negative", opt: []Option{WithAddress("127.0.0.1:8000"), WithTTL(-time.Second)}, wantErr: ErrTTLInvalid},
+		{name: "nanoseconds", opt: []Option{WithAddress("127.0.0.1:8000"), WithTTL(2)}, wantErr: ErrTTLInvalid},
+		{name: "below MinTTL", opt: []Option{WithAddress("127.0.0.1:8000"), WithTTL(MinTTL - 1)}, wantErr: ErrTTLInvalid},
+		{name: "no address", opt: []Option{WithTTL(time.Second)}, wantErr: ErrAddressEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst, ttl, err := NewInstance("svc", tt.opt...)
+			if err != tt.wantErr {
+				t.Fatalf("NewInstance() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (ttl != tt.wantTTL || inst.ServiceName != "svc") {
+				t.Errorf("NewInstance() = %+v, %v, want ttl %v", inst, ttl, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestStorePut(t *testing.T) {
+	s := NewStore(0)
+	defer s.Close()
+	inst := &Instance{ServiceName: "svc", Address: "127.0.0.1:8000"}
+
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		if err := s.Put(inst, ttl); err != ErrTTLInvalid {
+			t.Errorf("Put(ttl %v) error = %v, want %v", ttl, err, ErrTTLInvalid)
+		}
+	}
+	if n := len(s.List("svc")); n != 0 {
+		t.Fatalf("rejected Put stored %d instances", n)
+	}
+
+	rev, changed, cancel := s.Watch("svc")
+	defer cancel()
+	if err := s.Put(inst, time.Minute); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Errorf("Put of a new instance did not notify")
+	}
+	if n := len(s.List("svc")); n != 1 {
+		t.Fatalf("List() returned %d instances, want 1", n)
+	}
+
+	s.Expire(time.Now().Add(2 * time.Minute))
+	if n := len(s.List("svc")); n != 0 {
+		t.Errorf("expired instance still listed")
+	}
+	if next := s.Revision("svc"); next != rev+2 {
+		t.Errorf("revision = %d, want %d after put and expire", next, rev+2)
+	}
+	if s.Renew("svc", inst.Address) {
+		t.Errorf("Renew() of an expired instance succeeded")
+	}
+}
+
+func TestStoreWatchRelease(t *testing.T) {
+	s := NewStore(0)
+	defer s.Close()
+	watches := func() int {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.watches)
+	}
+
+	// 查询不存在的服务不保留任何状态
+	for i := 0; i < 100; i++ {
+		name := "missing" + strconv.Itoa(i)
+		s.List(name)
+		if rev := s.Revision(name); rev != 0 {
+			t.Fatalf("Revision(%s) = %d, want 0", name, rev)
+		}
+		_, _, cancel := s.Watch(name)
+		cancel()
+		cancel()
+	}
+	if n := watches(); n != 0 {
+		t.Fatalf("%d services kept after their watches were canceled", n)
+	}
+
+	inst := &Instance{ServiceName: "svc", Address: "127.0.0.1:8000"}
+	rev, changed, cancel := s.Watch("svc")
+	s.Put(inst, time.Minute)
+	s.Delete("svc", inst.Address)
+	<-changed
+	// 等待者还在时保留版本号
+	if got := s.Revision("svc"); got == rev {
+		t.Errorf("Revision() = %d after put and delete, want changed", got)
+	}
+	cancel()
+	if n := watches(); n != 0 {
+		t.Errorf("%d services kept after the last instance and watch were gone", n)
+	}
+
+	// 服务重新注册后版本号不会与删除前的版本号重复
+	last := s.Revision("svc")
+	s.Put(inst, time.Minute)
+	if got := s.Revision("svc"); got <= rev || got == last {
+		t.Errorf("Revision() = %d after re-register, old revisions %d, %d", got, rev, last)
+	}
+	if n := watches(); n != 1 {
+		t.Errorf("%d services with state, want 1 with instances", n)
+	}
+}
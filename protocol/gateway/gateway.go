@@ -0,0 +1,251 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/codec"
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/metadata"
+	"github.com/hillguo/sanrpc/protocol"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+)
+
+const (
+	// MetaHeaderPrefix 以此为前缀的 HTTP 头与调用元数据互相映射，如 Sanrpc-Meta-Env: test => env: test
+	MetaHeaderPrefix = "Sanrpc-Meta-"
+
+	// DefaultMaxBodySize 默认的请求体大小上限
+	DefaultMaxBodySize = 4 << 20
+)
+
+// ContentTypes 支持的请求 Content-Type 及其编码，响应使用与请求相同的 Content-Type，
+// 请求没有 Content-Type 时按 JSON 处理
+var ContentTypes = map[string]codec.SerializeType{
+	"application/json":       codec.JSON,
+	"application/x-protobuf": codec.ProtoBuffer,
+	"application/protobuf":   codec.ProtoBuffer,
+	"application/msgpack":    codec.MsgPack,
+	"application/cbor":       codec.CBOR,
+}
+
+var DefaultGatewayProtocol = &GatewayProtocol{}
+
+// GatewayProtocol 把 HTTP 请求 POST /{service}/{method} 和 AddRoute 注册的 REST 路由
+// 映射到 BaseService 中注册的方法，请求体按 Content-Type 解码，错误按 errs.Status 映射为 HTTP 状态码
+type GatewayProtocol struct {
+	protocol.BaseService
+
+	// MaxBodySize 请求体大小上限，为 0 时使用 DefaultMaxBodySize
+	MaxBodySize int64
+
+	routesMu sync.RWMutex
+	routes   []*route // AddRoute 注册的 REST 路由
+}
+
+// ErrorBody 非 protobuf 请求的错误响应，protobuf 请求的错误响应为 sanrpc.ErrMsg
+type ErrorBody struct {
+	Type    int32             `json:"type"`
+	Code    int32             `json:"code"`
+	Msg     string            `json:"msg"`
+	Status  string            `json:"status"`
+	Details []json.RawMessage `json:"details,omitempty"`
+}
+
+func (p *GatewayProtocol) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	contentType, st, ok := requestContentType(r)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	rt, vars, allow := p.findRoute(r)
+	if rt != nil {
+		p.serve(w, r, contentType, st, rt.Service, rt.Method, func(argv interface{}, body []byte, decode func([]byte, interface{}) error) error {
+			return rt.bind(argv, body, decode, vars, r.URL.Query())
+		})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+		if r.Method == http.MethodPost {
+			p.serve(w, r, contentType, st, parts[0], parts[1], nil)
+			return
+		}
+		allow = append(allow, http.MethodPost)
+	}
+	if len(allow) > 0 {
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// binder 把请求体和其他请求参数绑定到请求消息，为 nil 时请求体解码为整个请求消息
+type binder func(argv interface{}, body []byte, decode func([]byte, interface{}) error) error
+
+// serve 解码请求体，调用 serviceName.methodName 并写回响应
+func (p *GatewayProtocol) serve(w http.ResponseWriter, r *http.Request, contentType string, st codec.SerializeType,
+	serviceName, methodName string, bind binder) {
+	cc := codec.GetCodec(uint32(st))
+	body, err := p.readBody(r)
+	if err != nil {
+		log.Errorf("gateway: read request body fail: %v", err)
+		if err == errBodyTooLarge {
+			writeErrorStatus(w, contentType, errs.NewStatusError(errs.StatusResourceExhausted, err.Error()),
+				http.StatusRequestEntityTooLarge)
+			return
+		}
+		writeError(w, contentType, errs.ErrServerDecodeDataErr)
+		return
+	}
+
+	ctx, respMD := metadata.NewResponseContext(metadata.NewContext(r.Context(), headerToMD(r.Header)))
+	reply, err := p.Invoke(ctx, serviceName, methodName, func(argv interface{}) error {
+		if bind != nil {
+			if err := bind(argv, body, cc.Decode); err != nil {
+				return errs.NewStatusError(errs.StatusInvalidArgument, err.Error())
+			}
+			return nil
+		}
+		if len(body) == 0 {
+			return nil
+		}
+		return cc.Decode(body, argv)
+	})
+	for k, v := range respMD {
+		w.Header().Set(MetaHeaderPrefix+k, v)
+	}
+	if err != nil {
+		writeError(w, contentType, err)
+		return
+	}
+	data, err := cc.Encode(reply)
+	if err != nil {
+		log.Errorf("gateway: encode response fail: %v", err)
+		writeError(w, contentType, errs.ErrServerEncodeDataErr)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+// errBodyTooLarge 请求体（解压前或解压后）超过 MaxBodySize
+var errBodyTooLarge = errors.New("gateway: request body too large")
+
+// readBody 读取请求体，支持 Content-Encoding: gzip。
+// MaxBodySize 同时限制压缩的请求体和解压后的大小，避免 gzip 炸弹
+func (p *GatewayProtocol) readBody(r *http.Request) ([]byte, error) {
+	limit := p.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+	body, err := readLimited(r.Body, limit)
+	if err != nil || r.Header.Get("Content-Encoding") != "gzip" {
+		return body, err
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	return readLimited(gr, limit)
+}
+
+// readLimited 读取 r 的全部内容，超过 limit 字节时返回 errBodyTooLarge
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errBodyTooLarge
+	}
+	return data, nil
+}
+
+func requestContentType(r *http.Request) (string, codec.SerializeType, bool) {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return "application/json", codec.JSON, true
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", 0, false
+	}
+	st, ok := ContentTypes[mt]
+	return mt, st, ok
+}
+
+// headerToMD 取出 MetaHeaderPrefix 开头的请求头作为调用元数据，key 为小写
+func headerToMD(h http.Header) metadata.MD {
+	md := metadata.MD{}
+	for k, v := range h {
+		if len(v) == 0 || !strings.HasPrefix(k, MetaHeaderPrefix) {
+			continue
+		}
+		md[strings.ToLower(strings.TrimPrefix(k, MetaHeaderPrefix))] = v[0]
+	}
+	return md
+}
+
+// writeError 写回错误响应，HTTP 状态码按 errs.Status 映射。
+// 业务错误码不会被当作标准状态码，需要特定 HTTP 状态码的错误使用 errs.NewStatusError 创建
+func writeError(w http.ResponseWriter, contentType string, err error) {
+	e, _ := errs.FromError(err)
+	writeErrorStatus(w, contentType, e, errs.Status(e).HTTPStatus())
+}
+
+// writeErrorStatus 以 httpStatus 写回错误响应
+func writeErrorStatus(w http.ResponseWriter, contentType string, e *errs.Error, httpStatus int) {
+	if ContentTypes[contentType] == codec.ProtoBuffer {
+		details, derr := errs.MarshalDetails(e.Details)
+		if derr != nil {
+			log.Errorf("gateway: marshal error details fail: %v", derr)
+		}
+		data, _ := proto.Marshal(&sanrpc.ErrMsg{Type: e.Type, Code: e.Code, Msg: e.Msg, Details: details})
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(httpStatus)
+		w.Write(data)
+		return
+	}
+
+	body := &ErrorBody{Type: e.Type, Code: e.Code, Msg: e.Msg, Status: errs.Status(e).String()}
+	for _, d := range e.Details {
+		data, derr := detailJSON(d)
+		if derr != nil {
+			log.Errorf("gateway: marshal error detail fail: %v", derr)
+			continue
+		}
+		body.Details = append(body.Details, data)
+	}
+	data, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	w.Write(data)
+}
+
+// detailJSON protobuf 详情使用 proto3 JSON 格式，其他详情使用 encoding/json
+func detailJSON(d interface{}) (json.RawMessage, error) {
+	switch v := d.(type) {
+	case json.RawMessage:
+		return v, nil
+	case proto.Message:
+		s, err := (&jsonpb.Marshaler{}).MarshalToString(v)
+		return json.RawMessage(s), err
+	default:
+		return json.Marshal(v)
+	}
+}
@@ -0,0 +1,129 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+)
+
+// Failer 返回请求 path 指定的错误
+type Failer struct{}
+
+var failures = map[string]error{
+	"status":          errs.NewStatusError(errs.StatusNotFound, "no user"),
+	"legacy":          errs.ErrServerOverload,
+	"business status": errs.New(int(errs.StatusPermissionDenied), "forbidden"),
+	"business":        errs.New(1001, "balance not enough"),
+	"business zero":   errs.New(0, "failed"),
+	"plain":           errors.New("boom"),
+}
+
+func (f *Failer) Fail(ctx context.Context, req *testReq, resp *testReq) error {
+	return failures[req.Path]
+}
+
+func TestServeHTTPError(t *testing.T) {
+	p := &GatewayProtocol{}
+	if err := p.RegisterService(&Failer{}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path   string
+		status int
+		want   ErrorBody
+	}{
+		{"status", http.StatusNotFound, ErrorBody{Type: errs.ErrorTypeFramework, Code: 5, Msg: "no user", Status: "NotFound"}},
+		{"legacy", http.StatusTooManyRequests, ErrorBody{Type: errs.ErrorTypeFramework, Code: 132, Msg: "server overload", Status: "ResourceExhausted"}},
+		// 业务错误码即使与标准状态码取值相同也不重新解释
+		{"business status", http.StatusInternalServerError, ErrorBody{Type: errs.ErrorTypeBusiness, Code: 7, Msg: "forbidden", Status: "Unknown"}},
+		{"business", http.StatusInternalServerError, ErrorBody{Type: errs.ErrorTypeBusiness, Code: 1001, Msg: "balance not enough", Status: "Unknown"}},
+		{"business zero", http.StatusInternalServerError, ErrorBody{Type: errs.ErrorTypeBusiness, Code: 0, Msg: "failed", Status: "Unknown"}},
+		{"plain", http.StatusInternalServerError, ErrorBody{Type: errs.ErrorTypeFramework, Code: 999, Msg: "boom", Status: "Unknown"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			body, _ := json.Marshal(&testReq{Path: tt.path})
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, httptest.NewRequest("POST", "/failer/fail", bytes.NewReader(body)))
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+			var got ErrorBody
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %s: %v", w.Body, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+}
+
+func TestWriteErrorProtobuf(t *testing.T) {
+	// protobuf 请求的错误响应为 sanrpc.ErrMsg，状态码与 JSON 相同
+	w := httptest.NewRecorder()
+	writeError(w, "application/x-protobuf", failures["status"])
+	var msg sanrpc.ErrMsg
+	if err := proto.Unmarshal(w.Body.Bytes(), &msg); err != nil {
+		t.Fatalf("protobuf error body: %v", err)
+	}
+	if w.Code != http.StatusNotFound || msg.Type != errs.ErrorTypeFramework || msg.Code != 5 || msg.Msg != "no user" {
+		t.Errorf("protobuf error: status %d, body %v", w.Code, &msg)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-protobuf" {
+		t.Errorf("Content-Type %q", ct)
+	}
+}
+
+func TestServeHTTPBodyTooLarge(t *testing.T) {
+	p := &GatewayProtocol{MaxBodySize: 4 << 10}
+	if err := p.RegisterService(&Users{}); err != nil {
+		t.Fatal(err)
+	}
+	small := []byte(`{"path":"x"}`)
+	// 1 MiB 的请求体压缩后约 1 KiB，不超过 MaxBodySize，解压后超过
+	large := []byte(`{"path":"` + strings.Repeat("x", 1<<20) + `"}`)
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+		status   int
+	}{
+		{name: "small", body: small, status: http.StatusOK},
+		{name: "large", body: large, status: http.StatusRequestEntityTooLarge},
+		{name: "small gzip", body: gzipped(small), encoding: "gzip", status: http.StatusOK},
+		{name: "gzip bomb", body: gzipped(large), encoding: "gzip", status: http.StatusRequestEntityTooLarge},
+		{name: "bad gzip", body: small, encoding: "gzip", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/users/get", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", tt.encoding)
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("%d bytes: status %d, want %d, body %.100s", len(tt.body), w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func gzipped(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,348 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Route 把 HTTP 方法和路径模板映射到 Service.Method，如 GET /v1/users/{id} => user.get。
+// 路径模板中 {name} 匹配一段路径，{name=**} 只能在最后，匹配剩余的所有路径，每段分别反转义；
+// name 为请求消息的字段名，可以用 . 指定嵌套字段，如 {user.id}。
+// 没有绑定到路径和请求体的字段可以通过查询参数设置，repeated 字段可以重复出现，bytes 字段为 base64。
+type Route struct {
+	HTTPMethod string
+	Pattern    string
+	Service    string
+	Method     string
+	// Body 请求体绑定到的字段："*" 为整个请求消息，空为没有请求体
+	Body string
+}
+
+type segment struct {
+	literal string
+	field   string // 不为空时为变量
+	rest    bool   // {name=**}
+}
+
+type route struct {
+	Route
+	segments []segment
+}
+
+// compile 解析路径模板
+func compile(r Route) (*route, error) {
+	if r.HTTPMethod == "" || r.Service == "" || r.Method == "" {
+		return nil, fmt.Errorf("gateway: route %+v: http method, service and method required", r)
+	}
+	if !strings.HasPrefix(r.Pattern, "/") {
+		return nil, fmt.Errorf("gateway: route pattern %q must start with /", r.Pattern)
+	}
+	rt := &route{Route: r}
+	rt.HTTPMethod = strings.ToUpper(r.HTTPMethod)
+	parts := strings.Split(strings.Trim(r.Pattern, "/"), "/")
+	for i, p := range parts {
+		if !strings.HasPrefix(p, "{") {
+			if p == "" || strings.ContainsAny(p, "{}") {
+				return nil, fmt.Errorf("gateway: route pattern %q invalid", r.Pattern)
+			}
+			rt.segments = append(rt.segments, segment{literal: p})
+			continue
+		}
+		if !strings.HasSuffix(p, "}") {
+			return nil, fmt.Errorf("gateway: route pattern %q invalid", r.Pattern)
+		}
+		name := p[1 : len(p)-1]
+		seg := segment{field: name}
+		if i := strings.Index(name, "="); i >= 0 {
+			switch name[i+1:] {
+			case "*":
+			case "**":
+				seg.rest = true
+			default:
+				return nil, fmt.Errorf("gateway: route pattern %q: unsupported variable %s", r.Pattern, p)
+			}
+			seg.field = name[:i]
+		}
+		if seg.field == "" || (seg.rest && i != len(parts)-1) {
+			return nil, fmt.Errorf("gateway: route pattern %q invalid", r.Pattern)
+		}
+		rt.segments = append(rt.segments, seg)
+	}
+	return rt, nil
+}
+
+// match 匹配转义形式的路径（URL.EscapedPath），每段只反转义一次，返回路径变量。
+// 先解码整个路径会把 %2F 变成分隔符，再反转义又会把 %2541 解码两次
+func (rt *route) match(path string) (map[string]string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < len(rt.segments) {
+		return nil, false
+	}
+	for i, part := range parts {
+		v, err := url.PathUnescape(part)
+		if err != nil {
+			return nil, false
+		}
+		parts[i] = v
+	}
+	vars := make(map[string]string)
+	for i, seg := range rt.segments {
+		if seg.rest {
+			vars[seg.field] = strings.Join(parts[i:], "/")
+			return vars, true
+		}
+		if seg.field == "" {
+			if parts[i] != seg.literal {
+				return nil, false
+			}
+			continue
+		}
+		if parts[i] == "" {
+			return nil, false
+		}
+		vars[seg.field] = parts[i]
+	}
+	return vars, len(parts) == len(rt.segments)
+}
+
+// AddRoute 注册 REST 路由，路由优先于默认的 POST /{service}/{method}，按注册顺序匹配
+func (p *GatewayProtocol) AddRoute(r Route) error {
+	rt, err := compile(r)
+	if err != nil {
+		return err
+	}
+	p.routesMu.Lock()
+	p.routes = append(p.routes, rt)
+	p.routesMu.Unlock()
+	return nil
+}
+
+// findRoute 返回匹配的路由和路径变量；路径匹配但 HTTP 方法都不匹配时返回允许的方法
+func (p *GatewayProtocol) findRoute(r *http.Request) (*route, map[string]string, []string) {
+	p.routesMu.RLock()
+	defer p.routesMu.RUnlock()
+	var allow []string
+	for _, rt := range p.routes {
+		vars, ok := rt.match(r.URL.EscapedPath())
+		if !ok {
+			continue
+		}
+		if rt.HTTPMethod != r.Method {
+			allow = append(allow, rt.HTTPMethod)
+			continue
+		}
+		return rt, vars, nil
+	}
+	return nil, nil, allow
+}
+
+// bind 把请求体、路径变量和查询参数绑定到请求消息 argv
+func (rt *route) bind(argv interface{}, body []byte, decode func([]byte, interface{}) error,
+	vars map[string]string, query url.Values) error {
+	bound := make(map[string]bool, len(vars))
+	switch rt.Body {
+	case "":
+	case "*":
+		if len(body) > 0 {
+			if err := decode(body, argv); err != nil {
+				return err
+			}
+		}
+	default:
+		if len(body) > 0 {
+			fv, _, err := fieldByPath(reflect.ValueOf(argv), rt.Body)
+			if err != nil {
+				return err
+			}
+			// 指针字段直接解码到新分配的值，protobuf 消息才能按 proto3 JSON 解码
+			if fv.Kind() == reflect.Ptr {
+				ptr := reflect.New(fv.Type().Elem())
+				if err := decode(body, ptr.Interface()); err != nil {
+					return err
+				}
+				fv.Set(ptr)
+			} else {
+				ptr := reflect.New(fv.Type())
+				if err := decode(body, ptr.Interface()); err != nil {
+					return err
+				}
+				fv.Set(ptr.Elem())
+			}
+		}
+		bound[rt.Body] = true
+	}
+	for name, v := range vars {
+		if err := setField(argv, name, []string{v}); err != nil {
+			return err
+		}
+		bound[name] = true
+	}
+	if rt.Body == "*" {
+		return nil
+	}
+	for name, vs := range query {
+		if bound[name] || underBound(name, bound) {
+			continue
+		}
+		if err := setField(argv, name, vs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func underBound(name string, bound map[string]bool) bool {
+	for b := range bound {
+		if strings.HasPrefix(name, b+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func setField(argv interface{}, path string, values []string) error {
+	fv, enum, err := fieldByPath(reflect.ValueOf(argv), path)
+	if err != nil {
+		return err
+	}
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setScalar(s.Index(i), enum, v); err != nil {
+				return fmt.Errorf("gateway: field %s: %v", path, err)
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+	if err := setScalar(fv, enum, values[len(values)-1]); err != nil {
+		return fmt.Errorf("gateway: field %s: %v", path, err)
+	}
+	return nil
+}
+
+// fieldByPath 按 . 分隔的字段名找到字段，中间的 nil 指针会被分配。
+// 字段为 protobuf 枚举时同时返回枚举的全名
+func fieldByPath(v reflect.Value, path string) (reflect.Value, string, error) {
+	var enum string
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, "", fmt.Errorf("gateway: field %s not found", path)
+		}
+		i := fieldIndex(v.Type(), name)
+		if i < 0 {
+			return reflect.Value{}, "", fmt.Errorf("gateway: field %s not found", path)
+		}
+		enum = ""
+		for _, opt := range strings.Split(v.Type().Field(i).Tag.Get("protobuf"), ",") {
+			if strings.HasPrefix(opt, "enum=") {
+				enum = strings.TrimPrefix(opt, "enum=")
+			}
+		}
+		v = v.Field(i)
+	}
+	return v, enum, nil
+}
+
+// fieldIndex 按 protobuf 字段名、JSON 名或 Go 字段名（不区分大小写）查找字段
+func fieldIndex(t reflect.Type, name string) int {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || strings.HasPrefix(f.Name, "XXX_") {
+			continue
+		}
+		for _, opt := range strings.Split(f.Tag.Get("protobuf"), ",") {
+			if opt == "name="+name || opt == "json="+name {
+				return i
+			}
+		}
+		if jsonName := strings.Split(f.Tag.Get("json"), ",")[0]; jsonName == name {
+			return i
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.PkgPath == "" && strings.EqualFold(f.Name, strings.Replace(name, "_", "", -1)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// setScalar 把字符串转换为字段类型，enum 不为空时可以使用枚举值的名字
+func setScalar(v reflect.Value, enum, s string) error {
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setScalar(ptr.Elem(), enum, s); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := proto.EnumValueMap(enum)[s]; ok && enum != "" {
+			v.SetInt(int64(n))
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		b, err := decodeBytes(s)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// decodeBytes 按 proto3 JSON 的规则解码 bytes 字段：标准或 URL 安全的 base64，可以省略填充。
+// 查询参数中未转义的 '+' 会被解码为空格，这里还原为 '+'
+func decodeBytes(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.Replace(s, " ", "+", -1), "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
@@ -0,0 +1,296 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hillguo/sanrpc/codec"
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+)
+
+type testUser struct {
+	Id   int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+type testReq struct {
+	UserId int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Path   string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Tags   []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	Ids    []int32                `protobuf:"varint,4,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	Type   sanrpc.SanrpcMsgType   `protobuf:"varint,5,opt,name=type,proto3,enum=sanrpc.SanrpcMsgType" json:"type,omitempty"`
+	User   *testUser              `protobuf:"bytes,6,opt,name=user,proto3" json:"user,omitempty"`
+	Types  []sanrpc.SanrpcMsgType `protobuf:"varint,7,rep,packed,name=types,proto3,enum=sanrpc.SanrpcMsgType" json:"types,omitempty"`
+	Ok     bool                   `protobuf:"varint,8,opt,name=ok,proto3" json:"ok,omitempty"`
+	Data   []byte                 `protobuf:"bytes,9,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{"/v1/users/{id}", true},
+		{"/v1/users/{user.id}/books", true},
+		{"/v1/files/{path=**}", true},
+		{"/v1/users/{id=*}", true},
+		{"v1/users", false},
+		{"/v1//users", false},
+		{"/v1/{}", false},
+		{"/v1/{id", false},
+		{"/v1/a}b", false},
+		{"/v1/{=**}", false},
+		{"/v1/{path=**}/meta", false},
+		{"/v1/{id=foo}", false},
+	}
+	for _, tt := range tests {
+		_, err := compile(Route{HTTPMethod: "GET", Pattern: tt.pattern, Service: "s", Method: "m"})
+		if (err == nil) != tt.ok {
+			t.Errorf("compile(%q) err = %v, want ok %v", tt.pattern, err, tt.ok)
+		}
+	}
+	if _, err := compile(Route{HTTPMethod: "GET", Pattern: "/v1"}); err == nil {
+		t.Error("compile without service and method should fail")
+	}
+	rt, err := compile(Route{HTTPMethod: "get", Pattern: "/v1", Service: "s", Method: "m"})
+	if err != nil || rt.HTTPMethod != "GET" {
+		t.Errorf("compile lower case method = %v, %v", rt, err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		vars    map[string]string // nil 为不匹配
+	}{
+		{"/v1/users/{id}", "/v1/users/42", map[string]string{"id": "42"}},
+		{"/v1/users/{id}", "/v1/users/42/", map[string]string{"id": "42"}},
+		{"/v1/users/{id}", "/v1/users/a%20b", map[string]string{"id": "a b"}},
+		{"/v1/users/{id}", "/v1/users", nil},
+		{"/v1/users/{id}", "/v1/users/42/books", nil},
+		{"/v1/users/{id}", "/v1/groups/42", nil},
+		{"/v1/users/{id}", "/v1/users/%zz", nil},
+		{"/v1/users/{id}", "/v1/users/a%2Fb", map[string]string{"id": "a/b"}},
+		{"/v1/users/{id}", "/v1/users/%2541", map[string]string{"id": "%41"}},
+		{"/v1/users/{id}", "/v1/%75sers/1", map[string]string{"id": "1"}},
+		{"/v1/users/{user.id}/books/{name}", "/v1/users/7/books/go", map[string]string{"user.id": "7", "name": "go"}},
+		{"/v1/files/{path=**}", "/v1/files/a", map[string]string{"path": "a"}},
+		{"/v1/files/{path=**}", "/v1/files/a/b/c.txt", map[string]string{"path": "a/b/c.txt"}},
+		{"/v1/files/{path=**}", "/v1/files/a%20b/c%2Fd", map[string]string{"path": "a b/c/d"}},
+		{"/v1/files/{path=**}", "/v1/files/a/%zz", nil},
+		{"/v1/files/{path=**}", "/v1/files", nil},
+		{"/v1/files/{path=**}", "/v2/files/a", nil},
+	}
+	for _, tt := range tests {
+		rt, err := compile(Route{HTTPMethod: "GET", Pattern: tt.pattern, Service: "s", Method: "m"})
+		if err != nil {
+			t.Fatalf("compile(%q): %v", tt.pattern, err)
+		}
+		vars, ok := rt.match(tt.path)
+		if ok != (tt.vars != nil) || (ok && !reflect.DeepEqual(vars, tt.vars)) {
+			t.Errorf("%q match %q = %v, %v, want %v", tt.pattern, tt.path, vars, ok, tt.vars)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string // Route.Body
+		data  string
+		vars  map[string]string
+		query string
+		want  *testReq
+		err   bool
+	}{
+		{
+			name:  "path and query",
+			vars:  map[string]string{"user_id": "42"},
+			query: "path=a&ok=true",
+			want:  &testReq{UserId: 42, Path: "a", Ok: true},
+		},
+		{
+			name:  "repeated query",
+			query: "tags=a&tags=b&ids=1&ids=2&ids=3",
+			want:  &testReq{Tags: []string{"a", "b"}, Ids: []int32{1, 2, 3}},
+		},
+		{
+			name:  "last value for singular field",
+			query: "path=a&path=b",
+			want:  &testReq{Path: "b"},
+		},
+		{
+			name:  "enum by name and number",
+			query: "type=SANRPC_RESPONSE_MSG&types=1&types=SANRPC_RESPONSE_MSG",
+			want: &testReq{Type: sanrpc.SanrpcMsgType_SANRPC_RESPONSE_MSG,
+				Types: []sanrpc.SanrpcMsgType{sanrpc.SanrpcMsgType_SANRPC_REQUEST_MSG, sanrpc.SanrpcMsgType_SANRPC_RESPONSE_MSG}},
+		},
+		{
+			name:  "nested field",
+			vars:  map[string]string{"user.id": "7"},
+			query: "user.name=bob",
+			want:  &testReq{User: &testUser{Id: 7, Name: "bob"}},
+		},
+		{
+			name:  "json and go field names",
+			query: "userId=1&Path=a",
+			want:  &testReq{UserId: 1, Path: "a"},
+		},
+		{
+			name:  "path wins over query",
+			vars:  map[string]string{"user_id": "42"},
+			query: "user_id=1",
+			want:  &testReq{UserId: 42},
+		},
+		{
+			name:  "body field",
+			body:  "user",
+			data:  `{"id":1,"name":"bob"}`,
+			query: "user.name=alice&path=a",
+			want:  &testReq{User: &testUser{Id: 1, Name: "bob"}, Path: "a"},
+		},
+		{
+			name:  "whole body ignores query",
+			body:  "*",
+			data:  `{"path":"a"}`,
+			vars:  map[string]string{"user_id": "42"},
+			query: "tags=x",
+			want:  &testReq{UserId: 42, Path: "a"},
+		},
+		{
+			name:  "bytes std base64",
+			query: "data=%2B%2F8%3D",
+			want:  &testReq{Data: []byte{0xfb, 0xff}},
+		},
+		{
+			name:  "bytes unescaped plus",
+			query: "data=+/8=",
+			want:  &testReq{Data: []byte{0xfb, 0xff}},
+		},
+		{
+			name:  "bytes url base64 without padding",
+			query: "data=-_8",
+			want:  &testReq{Data: []byte{0xfb, 0xff}},
+		},
+		{
+			name: "bytes path variable",
+			vars: map[string]string{"data": "aGk="},
+			want: &testReq{Data: []byte("hi")},
+		},
+		{name: "bad bytes", query: "data=!!", err: true},
+		{name: "bad int", query: "user_id=x", err: true},
+		{name: "bad enum", query: "type=NOPE", err: true},
+		{name: "unknown field", query: "nope=1", err: true},
+		{name: "bad body", body: "*", data: `{`, err: true},
+	}
+	cc := codec.GetCodec(uint32(codec.JSON))
+	for _, tt := range tests {
+		rt, err := compile(Route{HTTPMethod: "POST", Pattern: "/v1", Service: "s", Method: "m", Body: tt.body})
+		if err != nil {
+			t.Fatal(err)
+		}
+		query, _ := url.ParseQuery(tt.query)
+		got := &testReq{}
+		err = rt.bind(got, []byte(tt.data), cc.Decode, tt.vars, query)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: want error, got %+v", tt.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+type Users struct{}
+
+func (u *Users) Get(ctx context.Context, req *testReq, resp *testReq) error {
+	if req.UserId == 404 {
+		return errs.NewStatusError(errs.StatusNotFound, "user not found")
+	}
+	*resp = *req
+	return nil
+}
+
+func TestServeHTTP(t *testing.T) {
+	p := &GatewayProtocol{}
+	if err := p.RegisterService(&Users{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []Route{
+		{HTTPMethod: "GET", Pattern: "/v1/users/{user_id}", Service: "users", Method: "get"},
+		{HTTPMethod: "PUT", Pattern: "/v1/users/{user_id}", Service: "users", Method: "get", Body: "user"},
+		{HTTPMethod: "GET", Pattern: "/v1/files/{path=**}", Service: "users", Method: "get"},
+		{HTTPMethod: "GET", Pattern: "/v1/paths/{path}", Service: "users", Method: "get"},
+	} {
+		if err := p.AddRoute(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		status int
+		allow  string
+		want   *testReq
+	}{
+		{"GET", "/v1/users/42?tags=a&tags=b", "", http.StatusOK, "", &testReq{UserId: 42, Tags: []string{"a", "b"}}},
+		{"PUT", "/v1/users/42", `{"name":"bob"}`, http.StatusOK, "", &testReq{UserId: 42, User: &testUser{Name: "bob"}}},
+		{"GET", "/v1/files/a/b.txt", "", http.StatusOK, "", &testReq{Path: "a/b.txt"}},
+		// 路径变量按转义形式匹配，每段只反转义一次
+		{"GET", "/v1/paths/a%2Fb", "", http.StatusOK, "", &testReq{Path: "a/b"}},
+		{"GET", "/v1/paths/100%25", "", http.StatusOK, "", &testReq{Path: "100%"}},
+		{"GET", "/v1/paths/%2541", "", http.StatusOK, "", &testReq{Path: "%41"}},
+		{"GET", "/v1/paths/a%20b", "", http.StatusOK, "", &testReq{Path: "a b"}},
+		{"GET", "/v1/files/a%2Fb/c", "", http.StatusOK, "", &testReq{Path: "a/b/c"}},
+		{"GET", "/v1/files/100%25/%2541", "", http.StatusOK, "", &testReq{Path: "100%/%41"}},
+		{"GET", "/v1/users/%34%32", "", http.StatusOK, "", &testReq{UserId: 42}},
+		{"GET", "/v1/paths/a/b", "", http.StatusNotFound, "", nil},
+		{"POST", "/users/get", `{"path":"x"}`, http.StatusOK, "", &testReq{Path: "x"}},
+		{"DELETE", "/v1/users/42", "", http.StatusMethodNotAllowed, "GET, PUT", nil},
+		{"GET", "/users/get", "", http.StatusMethodNotAllowed, "POST", nil},
+		{"GET", "/v1/users/404", "", http.StatusNotFound, "", nil},
+		{"GET", "/v1/users/x", "", http.StatusBadRequest, "", nil},
+		{"GET", "/v1/groups/1", "", http.StatusNotFound, "", nil},
+		{"POST", "/nope/get", "", http.StatusNotImplemented, "", nil},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d, body %s", tt.method, tt.target, w.Code, tt.status, w.Body)
+			continue
+		}
+		if got := w.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s: Allow %q, want %q", tt.method, tt.target, got, tt.allow)
+		}
+		if tt.want == nil {
+			continue
+		}
+		got := &testReq{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Errorf("%s %s: %v", tt.method, tt.target, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %s: got %+v, want %+v", tt.method, tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/hillguo/sanrpc/protocol"
+)
+
+// Framing TCP 上的消息分帧方式
+type Framing int
+
+const (
+	// FramingNewline 每个消息一行，以 \n 结尾，空行忽略
+	FramingNewline Framing = iota
+	// FramingLengthPrefix 每个消息前为 4 字节大端的消息长度
+	FramingLengthPrefix
+)
+
+// DefaultMaxMessageSize 默认的单个消息大小上限
+const DefaultMaxMessageSize = 4 << 20
+
+var ErrMessageTooLarge = errors.New("jsonrpc: message too large")
+
+// lineReader 换行分帧时连接上的读缓冲，同一连接上的读取是串行的
+type lineReader struct {
+	r *bufio.Reader
+}
+
+func (p *JSONRPCProtocol) maxMessageSize() int {
+	if p.MaxMessageSize <= 0 {
+		return DefaultMaxMessageSize
+	}
+	return p.MaxMessageSize
+}
+
+func (p *JSONRPCProtocol) Handshake(rw io.ReadWriter) error {
+	return nil
+}
+
+// DecodeMessage 读取一个完整的消息，JSON 解析错误在 HandleMessage 中作为 -32700 返回给对端
+func (p *JSONRPCProtocol) DecodeMessage(rw io.ReadWriter) (protocol.Message, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if p.Framing == FramingLengthPrefix {
+		data, err = p.readLengthPrefixed(rw)
+	} else {
+		data, err = p.readLine(rw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parse(data), nil
+}
+
+func (p *JSONRPCProtocol) readLengthPrefixed(r io.Reader) ([]byte, error) {
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(head)
+	if int64(n) > int64(p.maxMessageSize()) {
+		return nil, ErrMessageTooLarge
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (p *JSONRPCProtocol) readLine(rw io.ReadWriter) ([]byte, error) {
+	lr := p.lineReader(rw)
+	for {
+		var line []byte
+		for {
+			frag, isPrefix, err := lr.r.ReadLine()
+			if err != nil {
+				p.CloseConn(rw)
+				return nil, err
+			}
+			line = append(line, frag...)
+			if len(line) > p.maxMessageSize() {
+				p.CloseConn(rw)
+				return nil, ErrMessageTooLarge
+			}
+			if !isPrefix {
+				break
+			}
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			return line, nil
+		}
+	}
+}
+
+func (p *JSONRPCProtocol) lineReader(rw io.ReadWriter) *lineReader {
+	p.readersMu.Lock()
+	defer p.readersMu.Unlock()
+	if p.readers == nil {
+		p.readers = make(map[interface{}]*lineReader)
+	}
+	lr, ok := p.readers[rw]
+	if !ok {
+		lr = &lineReader{r: bufio.NewReader(rw)}
+		p.readers[rw] = lr
+	}
+	return lr
+}
+
+// CloseConn 释放连接的读缓冲，实现 protocol.ConnCloser，transport 关闭连接后调用
+func (p *JSONRPCProtocol) CloseConn(rw io.ReadWriter) {
+	p.readersMu.Lock()
+	delete(p.readers, rw)
+	p.readersMu.Unlock()
+}
+
+// HandleMessage 处理单个或批量请求，全部为通知时返回空的响应
+func (p *JSONRPCProtocol) HandleMessage(ctx context.Context, req protocol.Message) (protocol.Message, error) {
+	msg, ok := req.(*message)
+	if !ok {
+		return nil, errors.New("jsonrpc: msg assert fail")
+	}
+	return p.handle(ctx, msg), nil
+}
+
+// EncodeMessage 按 Framing 编码响应，没有需要写回的响应时返回 nil
+func (p *JSONRPCProtocol) EncodeMessage(res protocol.Message) ([]byte, error) {
+	rep, ok := res.(*reply)
+	if !ok {
+		return nil, errors.New("jsonrpc: msg assert fail")
+	}
+	data, err := rep.marshal()
+	if err != nil || data == nil {
+		return nil, err
+	}
+	if p.Framing == FramingLengthPrefix {
+		buf := make([]byte, 4+len(data))
+		binary.BigEndian.PutUint32(buf, uint32(len(data)))
+		copy(buf[4:], data)
+		return buf, nil
+	}
+	return append(data, '\n'), nil
+}
+
+// marshal 批量请求的响应为数组，没有响应时返回 nil
+func (rep *reply) marshal() ([]byte, error) {
+	if len(rep.resps) == 0 {
+		return nil, nil
+	}
+	if rep.batch {
+		return json.Marshal(rep.resps)
+	}
+	return json.Marshal(rep.resps[0])
+}
@@ -0,0 +1,52 @@
+package jsonrpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/metadata"
+	"github.com/hillguo/sanrpc/protocol/gateway"
+)
+
+// ServeHTTP 处理 HTTP POST 请求，请求体为单个或批量的 JSON-RPC 请求。
+// 与 gateway 一样，gateway.MetaHeaderPrefix 开头的头与调用元数据互相映射；
+// 全部为通知时返回 204
+func (p *JSONRPCProtocol) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, int64(p.maxMessageSize())))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	md := metadata.MD{}
+	for k, v := range r.Header {
+		if len(v) > 0 && strings.HasPrefix(k, gateway.MetaHeaderPrefix) {
+			md[strings.ToLower(strings.TrimPrefix(k, gateway.MetaHeaderPrefix))] = v[0]
+		}
+	}
+	ctx, respMD := metadata.NewResponseContext(metadata.NewContext(r.Context(), md))
+	rep := p.handle(ctx, parse(body))
+	for k, v := range respMD {
+		w.Header().Set(gateway.MetaHeaderPrefix+k, v)
+	}
+
+	data, err := rep.marshal()
+	if err != nil {
+		log.Errorf("jsonrpc: encode response fail: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if data == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
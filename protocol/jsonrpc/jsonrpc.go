@@ -0,0 +1,316 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/codec"
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/protocol"
+)
+
+// Version JSON-RPC 版本
+const Version = "2.0"
+
+// JSON-RPC 2.0 预定义的错误码
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+const (
+	// DefaultMaxBatchSize 默认的批量请求中请求数的上限
+	DefaultMaxBatchSize = 100
+	// DefaultBatchConcurrency 默认的批量请求中同时处理的请求数
+	DefaultBatchConcurrency = 8
+)
+
+var DefaultJSONRPCProtocol = &JSONRPCProtocol{}
+
+// JSONRPCProtocol JSON-RPC 2.0 协议，method 为 service.method，映射到 BaseService 中注册的方法。
+// 支持批量请求和通知（没有 id 的请求，不返回响应）。
+// 通过 TCP 时每个消息按 Framing 分帧，也可以作为 HttpMsgProtocol 处理 HTTP POST 请求。
+type JSONRPCProtocol struct {
+	protocol.BaseService
+
+	// Framing TCP 上的分帧方式，默认按换行分隔
+	Framing Framing
+	// MaxMessageSize 单个消息的大小上限，为 0 时使用 DefaultMaxMessageSize
+	MaxMessageSize int
+	// MaxBatchSize 批量请求中请求数的上限，超过时整个批量请求返回 -32600，为 0 时使用 DefaultMaxBatchSize
+	MaxBatchSize int
+	// BatchConcurrency 批量请求中同时处理的请求数，为 0 时使用 DefaultBatchConcurrency
+	BatchConcurrency int
+
+	readersMu sync.Mutex
+	readers   map[interface{}]*lineReader // 换行分帧时每个连接的读缓冲
+}
+
+// Request JSON-RPC 请求，ID 为空时是通知
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// Response JSON-RPC 响应，Error 不为 nil 时没有 result
+type Response struct {
+	Result json.RawMessage
+	Error  *Error
+	ID     json.RawMessage
+}
+
+// Error JSON-RPC 错误。Code 为 JSON-RPC 错误码或业务错误码，
+// Data 为 ErrorData，带上 errs.Error 的原始类型、错误码、标准状态和详情
+type Error struct {
+	Code    int32       `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// ErrorData Error.Data 的内容
+type ErrorData struct {
+	Type    int32             `json:"type"`
+	Code    int32             `json:"code"`
+	Status  string            `json:"status"`
+	Details []json.RawMessage `json:"details,omitempty"`
+}
+
+func (r *Response) MarshalJSON() ([]byte, error) {
+	id := r.ID
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	if r.Error != nil {
+		return json.Marshal(&struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Error   *Error          `json:"error"`
+			ID      json.RawMessage `json:"id"`
+		}{Version, r.Error, id})
+	}
+	result := r.Result
+	if len(result) == 0 {
+		result = json.RawMessage("null")
+	}
+	return json.Marshal(&struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result"`
+		ID      json.RawMessage `json:"id"`
+	}{Version, result, id})
+}
+
+// message 解码后的一个消息，可能是单个请求或批量请求
+type message struct {
+	batch bool
+	reqs  []json.RawMessage
+	err   *Error // 整个消息无法解析
+}
+
+// reply 一个消息的所有响应，全部为通知时为空，不需要写回
+type reply struct {
+	batch bool
+	resps []*Response
+}
+
+// parse 解析一个完整的消息
+func parse(data []byte) *message {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var reqs []json.RawMessage
+		if err := json.Unmarshal(data, &reqs); err != nil {
+			return &message{err: &Error{Code: CodeParseError, Message: "parse error: " + err.Error()}}
+		}
+		if len(reqs) == 0 {
+			return &message{err: &Error{Code: CodeInvalidRequest, Message: "invalid request: empty batch"}}
+		}
+		return &message{batch: true, reqs: reqs}
+	}
+	if !json.Valid(data) {
+		return &message{err: &Error{Code: CodeParseError, Message: "parse error"}}
+	}
+	return &message{reqs: []json.RawMessage{data}}
+}
+
+func (p *JSONRPCProtocol) maxBatchSize() int {
+	if p.MaxBatchSize <= 0 {
+		return DefaultMaxBatchSize
+	}
+	return p.MaxBatchSize
+}
+
+func (p *JSONRPCProtocol) batchConcurrency() int {
+	if p.BatchConcurrency <= 0 {
+		return DefaultBatchConcurrency
+	}
+	return p.BatchConcurrency
+}
+
+// handle 处理消息中的所有请求，通知不返回响应。
+// 批量请求由最多 BatchConcurrency 个协程处理，响应顺序与请求一致
+func (p *JSONRPCProtocol) handle(ctx context.Context, msg *message) *reply {
+	if msg.err != nil {
+		return &reply{resps: []*Response{{Error: msg.err}}}
+	}
+	if max := p.maxBatchSize(); len(msg.reqs) > max {
+		return &reply{resps: []*Response{{Error: &Error{Code: CodeInvalidRequest,
+			Message: fmt.Sprintf("invalid request: batch of %d requests exceeds the limit %d", len(msg.reqs), max)}}}}
+	}
+	rep := &reply{batch: msg.batch}
+	resps := make([]*Response, len(msg.reqs))
+	workers := p.batchConcurrency()
+	if workers > len(msg.reqs) {
+		workers = len(msg.reqs)
+	}
+	next := make(chan int, len(msg.reqs))
+	for i := range msg.reqs {
+		next <- i
+	}
+	close(next)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				resps[i] = p.call(ctx, msg.reqs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	for _, resp := range resps {
+		if resp != nil {
+			rep.resps = append(rep.resps, resp)
+		}
+	}
+	return rep
+}
+
+// call 调用一个请求，通知返回 nil
+func (p *JSONRPCProtocol) call(ctx context.Context, raw json.RawMessage) *Response {
+	req := &Request{}
+	if err := json.Unmarshal(raw, req); err != nil || req.JSONRPC != Version || req.Method == "" {
+		resp := &Response{Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}}
+		// 尽量带上请求的 id
+		var idOnly struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if json.Unmarshal(raw, &idOnly) == nil && validID(idOnly.ID) {
+			resp.ID = idOnly.ID
+		}
+		return resp
+	}
+	notification := len(req.ID) == 0
+	if !notification && !validID(req.ID) {
+		return &Response{Error: &Error{Code: CodeInvalidRequest, Message: "invalid request: id must be string, number or null"}}
+	}
+
+	result, err := p.invoke(ctx, req)
+	if notification {
+		if err != nil {
+			log.Warnf("jsonrpc: notification %s fail: %v", req.Method, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return &Response{Error: toError(err), ID: req.ID}
+	}
+	return &Response{Result: result, ID: req.ID}
+}
+
+func (p *JSONRPCProtocol) invoke(ctx context.Context, req *Request) (json.RawMessage, error) {
+	i := strings.LastIndex(req.Method, ".")
+	if i <= 0 || i == len(req.Method)-1 {
+		return nil, errs.ErrServerNoMethod
+	}
+	cc := codec.Codecs[codec.JSON]
+	replyv, err := p.Invoke(ctx, req.Method[:i], req.Method[i+1:], func(argv interface{}) error {
+		params, err := unwrapParams(req.Params)
+		if err != nil {
+			return err
+		}
+		if len(params) == 0 {
+			return nil
+		}
+		return cc.Decode(params, argv)
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, err := cc.Encode(replyv)
+	if err != nil {
+		return nil, errs.ErrServerEncodeDataErr
+	}
+	return data, nil
+}
+
+// unwrapParams 方法只有一个参数：params 为对象时即为参数，为数组时只能有一个元素
+func unwrapParams(params json.RawMessage) (json.RawMessage, error) {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || params[0] != '[' {
+		return params, nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(params, &list); err != nil {
+		return nil, err
+	}
+	switch len(list) {
+	case 0:
+		return nil, nil
+	case 1:
+		return list[0], nil
+	default:
+		return nil, errs.NewStatusError(errs.StatusInvalidArgument, "params must be an object or an array of one element")
+	}
+}
+
+func validID(id json.RawMessage) bool {
+	if len(id) == 0 {
+		return false
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+// toError 把 errs.Error 转换为 JSON-RPC 错误：找不到方法为 -32601，参数错误为 -32602，
+// 其他框架错误为 -32603，业务错误使用业务错误码
+func toError(err error) *Error {
+	e, _ := errs.FromError(err)
+	status := errs.Status(e)
+	data := &ErrorData{Type: e.Type, Code: e.Code, Status: status.String()}
+	for _, d := range e.Details {
+		b, derr := codec.Codecs[codec.JSON].Encode(d)
+		if derr != nil {
+			log.Errorf("jsonrpc: marshal error detail fail: %v", derr)
+			continue
+		}
+		data.Details = append(data.Details, b)
+	}
+
+	je := &Error{Code: e.Code, Message: e.Msg, Data: data}
+	if e.Type == errs.ErrorTypeFramework {
+		switch status {
+		case errs.StatusUnimplemented:
+			je.Code = CodeMethodNotFound
+			if e.Code == errs.ErrServerNoSupportEncodeType.Code || e.Code == errs.ErrServerNoSupportCompressType.Code {
+				je.Code = CodeInternalError
+			}
+		case errs.StatusInvalidArgument:
+			je.Code = CodeInvalidParams
+		default:
+			je.Code = CodeInternalError
+		}
+	}
+	return je
+}
@@ -0,0 +1,513 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/errs"
+)
+
+type Args struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type Reply struct {
+	C int `json:"c"`
+}
+
+type Arith struct {
+	notified chan int
+}
+
+func (a *Arith) Add(ctx context.Context, args *Args, reply *Reply) error {
+	reply.C = args.A + args.B
+	return nil
+}
+
+func (a *Arith) Notify(ctx context.Context, args *Args, reply *Reply) error {
+	a.notified <- args.A
+	return nil
+}
+
+func (a *Arith) Fail(ctx context.Context, args *Args, reply *Reply) error {
+	return errs.New(7, "business fail")
+}
+
+// Gauge 记录同时处理中的请求数
+type Gauge struct {
+	mu       sync.Mutex
+	cur, max int
+}
+
+func (g *Gauge) Enter(ctx context.Context, args *Args, reply *Reply) error {
+	g.mu.Lock()
+	g.cur++
+	if g.cur > g.max {
+		g.max = g.cur
+	}
+	g.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	g.mu.Lock()
+	g.cur--
+	g.mu.Unlock()
+	reply.C = args.A
+	return nil
+}
+
+// testResponse 客户端看到的响应
+type testResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func newTestProtocol(t *testing.T, framing Framing) (*JSONRPCProtocol, *Arith) {
+	arith := &Arith{notified: make(chan int, 16)}
+	p := &JSONRPCProtocol{Framing: framing}
+	if err := p.RegisterService(arith); err != nil {
+		t.Fatal(err)
+	}
+	return p, arith
+}
+
+// serveConn 与 tcp transport 一样循环读取、处理并写回响应，没有响应时不写
+func serveConn(p *JSONRPCProtocol, conn net.Conn) {
+	defer conn.Close()
+	defer p.CloseConn(conn)
+	for {
+		req, err := p.DecodeMessage(conn)
+		if err != nil {
+			return
+		}
+		resp, err := p.HandleMessage(context.Background(), req)
+		if err != nil {
+			return
+		}
+		data, err := p.EncodeMessage(resp)
+		if err != nil {
+			return
+		}
+		if len(data) == 0 {
+			continue
+		}
+		if _, err := conn.Write(data); err != nil {
+			return
+		}
+	}
+}
+
+// testClient 按 Framing 收发消息
+type testClient struct {
+	t       *testing.T
+	framing Framing
+	conn    net.Conn
+	r       *bufio.Reader
+}
+
+func (c *testClient) send(msg string) {
+	var data []byte
+	if c.framing == FramingLengthPrefix {
+		data = make([]byte, 4+len(msg))
+		binary.BigEndian.PutUint32(data, uint32(len(msg)))
+		copy(data[4:], msg)
+	} else {
+		data = []byte(msg + "\n")
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	if _, err := c.conn.Write(data); err != nil {
+		c.t.Fatalf("send %s: %v", msg, err)
+	}
+}
+
+func (c *testClient) recv() []byte {
+	c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	if c.framing == FramingLengthPrefix {
+		head := make([]byte, 4)
+		if _, err := io.ReadFull(c.r, head); err != nil {
+			c.t.Fatalf("recv: %v", err)
+		}
+		data := make([]byte, binary.BigEndian.Uint32(head))
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			c.t.Fatalf("recv: %v", err)
+		}
+		return data
+	}
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("recv: %v", err)
+	}
+	return line
+}
+
+func (c *testClient) recvOne() *testResponse {
+	resp := &testResponse{}
+	if data := c.recv(); json.Unmarshal(data, resp) != nil {
+		c.t.Fatalf("recv: invalid response %s", data)
+	}
+	return resp
+}
+
+func (c *testClient) recvBatch() []*testResponse {
+	var resps []*testResponse
+	if data := c.recv(); json.Unmarshal(data, &resps) != nil {
+		c.t.Fatalf("recv: invalid batch response %s", data)
+	}
+	return resps
+}
+
+func checkResult(t *testing.T, resp *testResponse, id, result string) {
+	t.Helper()
+	if resp.JSONRPC != Version || resp.Error != nil || string(resp.ID) != id || string(resp.Result) != result {
+		t.Errorf("response = %+v, want id %s result %s", resp, id, result)
+	}
+}
+
+func checkError(t *testing.T, resp *testResponse, id string, code int32) {
+	t.Helper()
+	if resp.Error == nil || resp.Error.Code != code || string(resp.ID) != id || resp.Result != nil {
+		t.Errorf("response = %+v (error %+v), want id %s code %d", resp, resp.Error, id, code)
+	}
+}
+
+func TestPipeRoundTrip(t *testing.T) {
+	for _, framing := range []Framing{FramingNewline, FramingLengthPrefix} {
+		p, arith := newTestProtocol(t, framing)
+		server, conn := net.Pipe()
+		go serveConn(p, server)
+		c := &testClient{t: t, framing: framing, conn: conn, r: bufio.NewReader(conn)}
+
+		c.send(`{"jsonrpc":"2.0","method":"arith.add","params":{"a":1,"b":2},"id":1}`)
+		checkResult(t, c.recvOne(), "1", `{"c":3}`)
+
+		// params 为只有一个元素的数组
+		c.send(`{"jsonrpc":"2.0","method":"Arith.Add","params":[{"a":2,"b":2}],"id":"x"}`)
+		checkResult(t, c.recvOne(), `"x"`, `{"c":4}`)
+
+		// 通知没有响应，下一个响应属于后面的请求
+		c.send(`{"jsonrpc":"2.0","method":"arith.notify","params":{"a":5}}`)
+		c.send(`{"jsonrpc":"2.0","method":"arith.add","params":{"a":3,"b":3},"id":2}`)
+		checkResult(t, c.recvOne(), "2", `{"c":6}`)
+		if n := <-arith.notified; n != 5 {
+			t.Errorf("notified %d, want 5", n)
+		}
+
+		// 批量请求中混有通知，只返回请求的响应，顺序与请求一致
+		c.send(`[{"jsonrpc":"2.0","method":"arith.add","params":{"a":1,"b":1},"id":10},` +
+			`{"jsonrpc":"2.0","method":"arith.notify","params":{"a":6}},` +
+			`{"jsonrpc":"2.0","method":"arith.nope","id":11},` +
+			`{"jsonrpc":"2.0","method":"arith.add","params":{"a":2,"b":1},"id":12}]`)
+		resps := c.recvBatch()
+		if len(resps) != 3 {
+			t.Fatalf("batch responses = %d, want 3", len(resps))
+		}
+		checkResult(t, resps[0], "10", `{"c":2}`)
+		checkError(t, resps[1], "11", CodeMethodNotFound)
+		checkResult(t, resps[2], "12", `{"c":3}`)
+		if n := <-arith.notified; n != 6 {
+			t.Errorf("notified %d, want 6", n)
+		}
+
+		// 全部为通知的批量请求没有响应
+		c.send(`[{"jsonrpc":"2.0","method":"arith.notify","params":{"a":7}},` +
+			`{"jsonrpc":"2.0","method":"arith.notify","params":{"a":8}}]`)
+		c.send(`{"jsonrpc":"2.0","method":"arith.add","params":{"a":0,"b":0},"id":3}`)
+		checkResult(t, c.recvOne(), "3", `{"c":0}`)
+		if a, b := <-arith.notified, <-arith.notified; a+b != 15 {
+			t.Errorf("notified %d, %d, want 7 and 8", a, b)
+		}
+
+		conn.Close()
+	}
+}
+
+func TestPipeErrors(t *testing.T) {
+	for _, framing := range []Framing{FramingNewline, FramingLengthPrefix} {
+		p, _ := newTestProtocol(t, framing)
+		server, conn := net.Pipe()
+		go serveConn(p, server)
+		c := &testClient{t: t, framing: framing, conn: conn, r: bufio.NewReader(conn)}
+
+		tests := []struct {
+			req  string
+			id   string
+			code int32
+		}{
+			{`{"jsonrpc":"2.0","method":"arith.add"`, "null", CodeParseError},
+			{`[{"jsonrpc":"2.0"`, "null", CodeParseError},
+			{`[]`, "null", CodeInvalidRequest},
+			{`{"jsonrpc":"1.0","method":"arith.add","id":1}`, "1", CodeInvalidRequest},
+			{`{"jsonrpc":"2.0","id":1}`, "1", CodeInvalidRequest},
+			{`{"jsonrpc":"2.0","method":"arith.add","id":{"a":1}}`, "null", CodeInvalidRequest},
+			{`{"jsonrpc":"2.0","method":"arith.add","id":[1]}`, "null", CodeInvalidRequest},
+			{`{"jsonrpc":"2.0","method":"arith.add","id":true}`, "null", CodeInvalidRequest},
+			{`{"jsonrpc":"2.0","method":"nope.add","id":1}`, "1", CodeMethodNotFound},
+			{`{"jsonrpc":"2.0","method":"arith","id":1}`, "1", CodeMethodNotFound},
+			{`{"jsonrpc":"2.0","method":"arith.add","params":[{},{}],"id":1}`, "1", CodeInvalidParams},
+			{`{"jsonrpc":"2.0","method":"arith.add","params":{"a":"x"},"id":1}`, "1", CodeInvalidParams},
+			{`{"jsonrpc":"2.0","method":"arith.fail","id":1}`, "1", 7},
+		}
+		for _, tt := range tests {
+			c.send(tt.req)
+			checkError(t, c.recvOne(), tt.id, tt.code)
+		}
+
+		// 批量请求中的无效请求单独返回错误
+		c.send(`[1,{"jsonrpc":"2.0","method":"arith.add","params":{"a":1,"b":1},"id":1}]`)
+		resps := c.recvBatch()
+		if len(resps) != 2 {
+			t.Fatalf("batch responses = %d, want 2", len(resps))
+		}
+		checkError(t, resps[0], "null", CodeInvalidRequest)
+		checkResult(t, resps[1], "1", `{"c":2}`)
+
+		conn.Close()
+	}
+}
+
+func batch(n int) []byte {
+	reqs := make([]string, n)
+	for i := range reqs {
+		reqs[i] = `{"jsonrpc":"2.0","method":"gauge.enter","params":{"a":` + strconv.Itoa(i) + `},"id":` + strconv.Itoa(i) + `}`
+	}
+	return []byte("[" + strings.Join(reqs, ",") + "]")
+}
+
+func TestBatchLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxBatch    int
+		concurrency int
+		size        int
+		wantMax     int // 同时处理的请求数上限
+		reject      bool
+	}{
+		{name: "bounded workers", maxBatch: 20, concurrency: 3, size: 20, wantMax: 3},
+		{name: "too large", maxBatch: 20, concurrency: 3, size: 21, reject: true},
+		{name: "defaults", size: DefaultMaxBatchSize, wantMax: DefaultBatchConcurrency},
+		{name: "default limit", size: DefaultMaxBatchSize + 1, reject: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gauge := &Gauge{}
+			p := &JSONRPCProtocol{MaxBatchSize: tt.maxBatch, BatchConcurrency: tt.concurrency}
+			if err := p.RegisterService(gauge); err != nil {
+				t.Fatal(err)
+			}
+			rep := p.handle(context.Background(), parse(batch(tt.size)))
+			if tt.reject {
+				if rep.batch || len(rep.resps) != 1 || rep.resps[0].Error == nil || rep.resps[0].Error.Code != CodeInvalidRequest {
+					t.Fatalf("oversized batch reply = %+v, want a single -32600 error", rep)
+				}
+				if gauge.max != 0 {
+					t.Errorf("%d requests of a rejected batch handled", gauge.max)
+				}
+				return
+			}
+			if len(rep.resps) != tt.size {
+				t.Fatalf("%d responses, want %d", len(rep.resps), tt.size)
+			}
+			for i, resp := range rep.resps {
+				if string(resp.ID) != strconv.Itoa(i) || string(resp.Result) != `{"c":`+strconv.Itoa(i)+`}` {
+					t.Errorf("response %d = id %s, result %s", i, resp.ID, resp.Result)
+				}
+			}
+			if gauge.max > tt.wantMax {
+				t.Errorf("%d requests handled at once, want at most %d", gauge.max, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestErrorData(t *testing.T) {
+	p, _ := newTestProtocol(t, FramingNewline)
+	rep := p.handle(context.Background(), parse([]byte(`{"jsonrpc":"2.0","method":"arith.fail","id":1}`)))
+	data, err := rep.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		Error struct {
+			Code    int32     `json:"code"`
+			Message string    `json:"message"`
+			Data    ErrorData `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	e := resp.Error
+	if e.Code != 7 || e.Message != "business fail" || e.Data.Type != errs.ErrorTypeBusiness || e.Data.Code != 7 || e.Data.Status != "Unknown" {
+		t.Errorf("error = %+v", e)
+	}
+}
+
+func TestNewlineFraming(t *testing.T) {
+	p, _ := newTestProtocol(t, FramingNewline)
+	// 空行忽略，\r\n 结尾同样可以
+	rw := bytes.NewBufferString("\n  \n{\"jsonrpc\":\"2.0\",\"method\":\"arith.add\",\"id\":1}\r\n")
+	msg, err := p.DecodeMessage(rw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := msg.(*message); m.err != nil || m.batch || len(m.reqs) != 1 {
+		t.Errorf("message = %+v", m)
+	}
+	if _, err := p.DecodeMessage(rw); err != io.EOF {
+		t.Errorf("DecodeMessage at EOF err = %v", err)
+	}
+	if len(p.readers) != 0 {
+		t.Errorf("line reader of closed conn not released")
+	}
+}
+
+func TestMessageTooLarge(t *testing.T) {
+	big := `{"jsonrpc":"2.0","method":"arith.add","params":{"a":1,"b":2},"id":1}`
+	for _, framing := range []Framing{FramingNewline, FramingLengthPrefix} {
+		p, _ := newTestProtocol(t, framing)
+		p.MaxMessageSize = len(big) - 1
+
+		var buf bytes.Buffer
+		if framing == FramingLengthPrefix {
+			binary.Write(&buf, binary.BigEndian, uint32(len(big)))
+			buf.WriteString(big)
+		} else {
+			buf.WriteString(big + "\n")
+		}
+		if _, err := p.DecodeMessage(&buf); err != ErrMessageTooLarge {
+			t.Errorf("framing %d: DecodeMessage err = %v, want ErrMessageTooLarge", framing, err)
+		}
+		if len(p.readers) != 0 {
+			t.Errorf("framing %d: line reader not released", framing)
+		}
+
+		// 刚好等于上限的消息可以读取
+		p.MaxMessageSize = len(big)
+		buf.Reset()
+		if framing == FramingLengthPrefix {
+			binary.Write(&buf, binary.BigEndian, uint32(len(big)))
+			buf.WriteString(big)
+		} else {
+			buf.WriteString(big + "\n")
+		}
+		if _, err := p.DecodeMessage(&buf); err != nil {
+			t.Errorf("framing %d: DecodeMessage err = %v", framing, err)
+		}
+		p.CloseConn(&buf)
+	}
+
+	// 超过缓冲区大小的行分多次读取
+	p, _ := newTestProtocol(t, FramingNewline)
+	p.MaxMessageSize = 8 << 10
+	line := `{"jsonrpc":"2.0","method":"arith.add","params":{"a":1,"b":2,"pad":"` + strings.Repeat("x", 6<<10) + `"},"id":1}`
+	if _, err := p.DecodeMessage(bytes.NewBufferString(line + "\n")); err != nil {
+		t.Errorf("DecodeMessage long line err = %v", err)
+	}
+	if _, err := p.DecodeMessage(bytes.NewBufferString(strings.Repeat("x", 10<<10) + "\n")); err != ErrMessageTooLarge {
+		t.Errorf("DecodeMessage long line err = %v, want ErrMessageTooLarge", err)
+	}
+}
+
+func TestEncodeMessage(t *testing.T) {
+	for _, framing := range []Framing{FramingNewline, FramingLengthPrefix} {
+		p := &JSONRPCProtocol{Framing: framing}
+		data, err := p.EncodeMessage(&reply{batch: true})
+		if err != nil || data != nil {
+			t.Errorf("framing %d: empty reply = %q, %v", framing, data, err)
+		}
+		data, err = p.EncodeMessage(&reply{resps: []*Response{{Result: json.RawMessage(`1`), ID: json.RawMessage(`1`)}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"jsonrpc":"2.0","result":1,"id":1}`
+		if framing == FramingLengthPrefix {
+			if n := binary.BigEndian.Uint32(data); int(n) != len(want) || string(data[4:]) != want {
+				t.Errorf("length prefixed = %q", data)
+			}
+		} else if string(data) != want+"\n" {
+			t.Errorf("newline = %q", data)
+		}
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	p, arith := newTestProtocol(t, FramingNewline)
+	p.MaxMessageSize = 1 << 10
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	post := func(body string) (*http.Response, []byte) {
+		t.Helper()
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, data
+	}
+
+	resp, data := post(`{"jsonrpc":"2.0","method":"arith.add","params":{"a":1,"b":2},"id":1}`)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	one := &testResponse{}
+	if err := json.Unmarshal(data, one); err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, one, "1", `{"c":3}`)
+
+	resp, data = post(`[{"jsonrpc":"2.0","method":"arith.add","params":{"a":1,"b":1},"id":1},` +
+		`{"jsonrpc":"2.0","method":"arith.notify","params":{"a":1}}]`)
+	var batch []*testResponse
+	if err := json.Unmarshal(data, &batch); err != nil || resp.StatusCode != http.StatusOK || len(batch) != 1 {
+		t.Fatalf("batch status %d, body %s", resp.StatusCode, data)
+	}
+	checkResult(t, batch[0], "1", `{"c":2}`)
+	<-arith.notified
+
+	resp, data = post(`[{"jsonrpc":"2.0","method":"arith.notify","params":{"a":2}}]`)
+	if resp.StatusCode != http.StatusNoContent || len(data) != 0 {
+		t.Errorf("notification batch status %d, body %q", resp.StatusCode, data)
+	}
+	<-arith.notified
+
+	// JSON-RPC 错误仍以 200 返回
+	resp, data = post(`{"jsonrpc":"2.0","method":"arith.add","id":{}}`)
+	one = &testResponse{}
+	if err := json.Unmarshal(data, one); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("invalid id status %d, body %s", resp.StatusCode, data)
+	}
+	checkError(t, one, "null", CodeInvalidRequest)
+
+	resp, _ = post(`{"jsonrpc":"2.0","method":"arith.add","params":{"pad":"` + strings.Repeat("x", 2<<10) + `"},"id":1}`)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversize status %d", resp.StatusCode)
+	}
+
+	get, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.Body.Close()
+	if get.StatusCode != http.StatusMethodNotAllowed || get.Header.Get("Allow") != http.MethodPost {
+		t.Errorf("GET status %d, Allow %q", get.StatusCode, get.Header.Get("Allow"))
+	}
+}
@@ -23,6 +23,11 @@ type RpcMsgProtocol interface {
 	EncodeMessage(res Message) ([]byte, error)
 }
 
+// ConnCloser 按连接保存状态的 RpcMsgProtocol 实现，transport 在连接关闭后调用 CloseConn 清理
+type ConnCloser interface {
+	CloseConn(rw io.ReadWriter)
+}
+
 //HttpMsgProtocol http protocol interface
 type HttpMsgProtocol interface {
 	ServeHTTP(w http.ResponseWriter, r *http.Request)
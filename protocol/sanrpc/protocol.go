@@ -8,9 +8,12 @@ import (
 	log "github.com/hillguo/sanlog"
 	"github.com/hillguo/sanrpc/codec"
 	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/metadata"
 	"github.com/hillguo/sanrpc/protocol"
+	"fmt"
 	"io"
-	"reflect"
+	"io/ioutil"
+	"strconv"
 	"strings"
 )
 
@@ -57,12 +60,29 @@ func (p *SanRPCProtocol) DecodeMessage(r io.ReadWriter) (protocol.Message, error
 	if bodylen == 0 {
 		return &MessageProtocol{}, nil
 	}
+	body := io.LimitReader(r, int64(bodylen))
+	read, header, err := readHeader(body, int(bodylen))
+	if err != nil {
+		return nil, err
+	}
+	// 不支持的编码或压缩方式不读入 Data，丢弃剩余的消息体，由 DisspatchMessage 返回支持的编码
+	if header != nil && !supportEncoding(header) {
+		if _, err := io.Copy(ioutil.Discard, body); err != nil {
+			return nil, err
+		}
+		log.Infof("skip sanrpc message body, encode type:%d, compress type:%d", header.EncodeType, header.CompressType)
+		return &MessageProtocol{Header: header}, nil
+	}
 	msg := make([]byte, bodylen)
-	n,err = io.ReadFull(r, msg)
-	if  err != nil {
+	copy(msg, read)
+	n, err = io.ReadFull(body, msg[len(read):])
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, ErrReadMsgBodyInvalid
+		}
 		return nil, err
 	}
-	if n != int(bodylen) {
+	if len(read)+n != int(bodylen) {
 		return nil, ErrReadMsgBodyInvalid
 	}
 	log.Info("read a full sanrpc message")
@@ -75,6 +95,51 @@ func (p *SanRPCProtocol) DecodeMessage(r io.ReadWriter) (protocol.Message, error
 	return msgprotocol, nil
 }
 
+// headerTag MessageProtocol 中 Header 字段的 tag，序列化时 Header 在消息体最前面
+const headerTag = 1<<3 | proto.WireBytes
+
+// byteRecorder 逐字节读取并记录读到的内容
+type byteRecorder struct {
+	r    io.Reader
+	read []byte
+}
+
+func (b *byteRecorder) ReadByte() (byte, error) {
+	var c [1]byte
+	if _, err := io.ReadFull(b.r, c[:]); err != nil {
+		return 0, err
+	}
+	b.read = append(b.read, c[0])
+	return c[0], nil
+}
+
+// readHeader 只读取消息体开头的 Header 字段，返回已读取的字节和解码后的 Header。
+// 消息体不以 Header 开头时 Header 为 nil，调用方读取完整消息体后再解码
+func readHeader(r io.Reader, bodylen int) ([]byte, *HeaderMsg, error) {
+	b := &byteRecorder{r: r}
+	tag, err := binary.ReadUvarint(b)
+	if err != nil || tag != headerTag {
+		return b.read, nil, nil
+	}
+	size, err := binary.ReadUvarint(b)
+	if err != nil || size > uint64(bodylen-len(b.read)) {
+		return b.read, nil, nil
+	}
+	read := make([]byte, len(b.read)+int(size))
+	n := copy(read, b.read)
+	if _, err := io.ReadFull(r, read[n:]); err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			return nil, nil, ErrReadMsgBodyInvalid
+		}
+		return nil, nil, err
+	}
+	header := &HeaderMsg{}
+	if err := proto.Unmarshal(read[n:], header); err != nil {
+		return nil, nil, ErrServerUnmarshalFail
+	}
+	return read, header, nil
+}
+
 func (p *SanRPCProtocol) EncodeMessage(msg protocol.Message) ([]byte,error) {
 
 	m, ok := msg.(*MessageProtocol)
@@ -95,46 +160,57 @@ func (p *SanRPCProtocol) EncodeMessage(msg protocol.Message) ([]byte,error) {
 	return d, nil
 }
 
-func (p *SanRPCProtocol) DisspatchMessage(req *MessageProtocol, resp *MessageProtocol)  error{
-	serviceName := strings.ToLower(req.Header.ServiceName)
-	methodName := strings.ToLower(req.Header.MethodName)
+// MetaEncodeTypes 不支持请求的编码时，响应元数据中列出服务端支持的 EncodeType，逗号分隔
+const MetaEncodeTypes = "sanrpc-encode-types"
 
-	p.ServiceMapMu.RLock()
-	service := p.ServiceMap[serviceName]
-	p.ServiceMapMu.RUnlock()
+// supportEncoding 是否支持 Header 中的编码和压缩方式
+func supportEncoding(h *HeaderMsg) bool {
+	return codec.GetCodec(h.EncodeType) != nil && codec.GetCompressor(h.CompressType) != nil
+}
 
-	if service == nil {
-		return errs.ErrServerNoService
-	}
-	mtype := service.GetMethod(methodName)
-	if mtype == nil {
-		return errs.ErrServerNoMethod
+// checkEncoding 在查找服务和解码之前检查编码和压缩方式，不支持时在错误和响应元数据中返回支持的编码。
+// 这类请求的 Data 在 DecodeMessage 中已丢弃，没有读入内存
+func checkEncoding(req *MessageProtocol, resp *MessageProtocol) error {
+	if codec.GetCodec(req.Header.EncodeType) == nil {
+		types := make([]string, 0, len(codec.Codecs))
+		for _, t := range codec.SerializeTypes() {
+			types = append(types, strconv.Itoa(int(t)))
+		}
+		supported := strings.Join(types, ",")
+		if resp.Header.MetaData == nil {
+			resp.Header.MetaData = make(map[string]string)
+		}
+		resp.Header.MetaData[MetaEncodeTypes] = supported
+		return errs.NewFrameError(int(errs.ErrServerNoSupportEncodeType.Code),
+			fmt.Sprintf("%s %d, supported: %s", errs.ErrServerNoSupportEncodeType.Msg, req.Header.EncodeType, supported))
 	}
-	var argv interface{}
-	if mtype.ArgType.Kind() != reflect.Ptr {
-		argv = reflect.New(mtype.ArgType).Elem().Interface()
-	} else {
-		argv = reflect.New(mtype.ArgType.Elem()).Interface()
+	if codec.GetCompressor(req.Header.CompressType) == nil {
+		return errs.ErrServerNoSupportCompressType
 	}
+	return nil
+}
 
-
-	cc := codec.Codecs[codec.SerializeType(req.Header.EncodeType)]
-	if cc == nil {
-		return errs.ErrServerNoSupportEncodeType
-	}
-	err := cc.Decode(req.Data, argv)
-	if err != nil {
-		return errs.ErrServerDecodeDataErr
+func (p *SanRPCProtocol) DisspatchMessage(ctx context.Context, req *MessageProtocol, resp *MessageProtocol)  error{
+	if err := checkEncoding(req, resp); err != nil {
+		return err
 	}
+	cc := codec.GetCodec(req.Header.EncodeType)
+	compressor := codec.GetCompressor(req.Header.CompressType)
 
-	replyv := reflect.New(mtype.ReplyType.Elem()).Interface()
-
-	ctx:=context.Background()
-
-	log.Debugf("req:%+v", argv)
-	err = service.Call(ctx, mtype, reflect.ValueOf(argv), reflect.ValueOf(replyv))
-	log.Debugf("resp:%+v", replyv)
-
+	ctx, respMD := metadata.NewResponseContext(metadata.NewContext(ctx, req.Header.MetaData))
+	replyv, err := p.Invoke(ctx, req.Header.ServiceName, req.Header.MethodName, func(argv interface{}) error {
+		reqData, err := compressor.Unzip(req.Data)
+		if err != nil {
+			return err
+		}
+		return cc.Decode(reqData, argv)
+	})
+	for k, v := range respMD {
+		if resp.Header.MetaData == nil {
+			resp.Header.MetaData = make(map[string]string)
+		}
+		resp.Header.MetaData[k] = v
+	}
 	if err != nil {
 		return err
 	}
@@ -143,6 +219,12 @@ func (p *SanRPCProtocol) DisspatchMessage(req *MessageProtocol, resp *MessagePro
 	if err != nil {
 		return errs.ErrServerEncodeDataErr
 	}
+	// 响应使用与请求相同的压缩方式
+	data, err = compressor.Zip(data)
+	if err != nil {
+		return errs.ErrServerEncodeDataErr
+	}
+	resp.Header.CompressType = req.Header.CompressType
 	resp.Data = data
 	return nil
 }
@@ -160,21 +242,43 @@ func (p *SanRPCProtocol) HandleMessage(ctx context.Context, r protocol.Message)
 			Msg:"success",
 		},
 	}
+	if req.Header == nil {
+		req.Header = &HeaderMsg{}
+	}
+	resp.Header = &HeaderMsg{
+		Version:      req.Header.Version,
+		CallType:     uint32(SanrpcMsgType_SANRPC_RESPONSE_MSG),
+		Seq:          req.Header.Seq,
+		ServiceName:  req.Header.ServiceName,
+		MethodName:   req.Header.MethodName,
+		EncodeType:   req.Header.EncodeType,
+		CompressType: uint32(codec.CompressNone),
+	}
 
 	log.Debugf("req msg: %v", req)
-	err := p.DisspatchMessage(req, resp)
+	err := p.DisspatchMessage(ctx, req, resp)
 	if err != nil {
 		log.Error(err)
-		if e, ok := err.(*errs.Error); ok {
-			resp.Err.Type = e.Type
-			resp.Err.Code = e.Code
-			resp.Err.Msg = e.Msg
-			return resp, nil
+		// 非 *errs.Error 的错误以 ErrUnknown 返回
+		e, _ := errs.FromError(err)
+		resp.Err.Type = e.Type
+		resp.Err.Code = e.Code
+		resp.Err.Msg = e.Msg
+		details, derr := errs.MarshalDetails(e.Details)
+		if derr != nil {
+			log.Errorf("rpc: marshal error details fail: %v", derr)
 		}
-		resp.Err.Type = 0
-		resp.Err.Code = 999
-		resp.Err.Msg = err.Error()
+		resp.Err.Details = details
 	}
 	log.Debugf("resp msg: %v", resp)
 	return resp, nil
-}
\ No newline at end of file
+}
+
+// ToError 把响应中的 ErrMsg 转换为 *errs.Error，调用成功时返回 nil。
+// 成功的响应是 Code 为 0 的框架类型（见 HandleMessage），业务错误的 Code 为 0 时仍然是错误
+func ToError(m *ErrMsg) error {
+	if m == nil || (m.Code == 0 && m.Type != errs.ErrorTypeBusiness) {
+		return nil
+	}
+	return &errs.Error{Type: m.Type, Code: m.Code, Msg: m.Msg, Details: errs.UnmarshalDetails(m.Details)}
+}
@@ -0,0 +1,176 @@
+package sanrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hillguo/sanrpc/codec"
+	"github.com/hillguo/sanrpc/errs"
+)
+
+func encode(t *testing.T, m *MessageProtocol) []byte {
+	t.Helper()
+	data, err := DefaultSanRPCProtocol.EncodeMessage(m)
+	if err != nil {
+		t.Fatalf("EncodeMessage() error = %v", err)
+	}
+	return data
+}
+
+func TestDecodeMessageSkipsUnsupportedEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   *HeaderMsg
+		wantData bool
+	}{
+		{name: "supported", header: &HeaderMsg{Seq: 1, EncodeType: uint32(codec.JSON)}, wantData: true},
+		{name: "unknown encode type", header: &HeaderMsg{Seq: 1, EncodeType: 99}},
+		{name: "unknown compress type", header: &HeaderMsg{Seq: 1, CompressType: 99}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			buf.Write(encode(t, &MessageProtocol{Header: tt.header, Data: bytes.Repeat([]byte("x"), 1024)}))
+			buf.Write(encode(t, &MessageProtocol{Header: &HeaderMsg{Seq: 2}}))
+
+			msg, err := DefaultSanRPCProtocol.DecodeMessage(&buf)
+			if err != nil {
+				t.Fatalf("DecodeMessage() error = %v", err)
+			}
+			m := msg.(*MessageProtocol)
+			if m.Header.GetSeq() != 1 || (len(m.Data) > 0) != tt.wantData {
+				t.Errorf("DecodeMessage() = header %v, %d bytes of data", m.Header, len(m.Data))
+			}
+
+			// 丢弃的消息体不影响下一条消息
+			msg, err = DefaultSanRPCProtocol.DecodeMessage(&buf)
+			if err != nil || msg.(*MessageProtocol).Header.GetSeq() != 2 {
+				t.Errorf("next DecodeMessage() = %v, %v", msg, err)
+			}
+		})
+	}
+}
+
+func TestHandleMessageUnsupportedEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   *HeaderMsg
+		wantCode int32
+		wantMeta string
+	}{
+		{name: "encode type", header: &HeaderMsg{EncodeType: 99}, wantCode: errs.ErrServerNoSupportEncodeType.Code, wantMeta: "0,1,2,3,4,5,6,7"},
+		{name: "compress type", header: &HeaderMsg{CompressType: 99}, wantCode: errs.ErrServerNoSupportCompressType.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &SanRPCProtocol{}
+			msg, err := p.HandleMessage(context.Background(), &MessageProtocol{Header: tt.header})
+			if err != nil {
+				t.Fatalf("HandleMessage() error = %v", err)
+			}
+			resp := msg.(*MessageProtocol)
+			if resp.Err.GetType() != errs.ErrorTypeFramework || resp.Err.GetCode() != tt.wantCode {
+				t.Errorf("resp.Err = %v, want framework code %d", resp.Err, tt.wantCode)
+			}
+			if got := resp.Header.MetaData[MetaEncodeTypes]; got != tt.wantMeta {
+				t.Errorf("supported encode types = %q, want %q", got, tt.wantMeta)
+			}
+		})
+	}
+}
+
+func TestToError(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *ErrMsg
+		want *errs.Error // nil 表示调用成功
+	}{
+		{name: "nil"},
+		{name: "success", msg: &ErrMsg{Type: errs.ErrorTypeFramework, Code: 0, Msg: "success"}},
+		{name: "empty", msg: &ErrMsg{}},
+		{name: "framework", msg: &ErrMsg{Type: errs.ErrorTypeFramework, Code: 131, Msg: "timeout"}, want: errs.ErrServerTimeout},
+		{name: "business", msg: &ErrMsg{Type: errs.ErrorTypeBusiness, Code: 1001, Msg: "no user"}, want: errs.New(1001, "no user")},
+		{name: "business code 0", msg: &ErrMsg{Type: errs.ErrorTypeBusiness, Code: 0, Msg: "failed"}, want: errs.New(0, "failed")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ToError(tt.msg)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("ToError() = %v, want nil", err)
+				}
+				return
+			}
+			e, ok := err.(*errs.Error)
+			if !ok {
+				t.Fatalf("ToError() = %#v, want *errs.Error", err)
+			}
+			if e.Type != tt.want.Type || e.Code != tt.want.Code || e.Msg != tt.msg.Msg {
+				t.Errorf("ToError() = %v, want %v", e, tt.want)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+		})
+	}
+}
+
+// ErrService 返回请求中指定的错误
+type ErrService struct {
+	errs map[string]error
+}
+
+func (s *ErrService) Fail(ctx context.Context, name *string, reply *string) error {
+	return s.errs[*name]
+}
+
+func TestHandleMessageErrorRoundTrip(t *testing.T) {
+	// 服务端返回的错误经 HandleMessage 写入 ErrMsg，客户端 ToError 后与原错误一致
+	tests := []struct {
+		name string
+		err  error
+		want *errs.Error // nil 表示调用成功
+	}{
+		{name: "success"},
+		{name: "business", err: errs.New(1001, "no user"), want: errs.New(1001, "no user")},
+		{name: "business code 0", err: errs.New(0, "failed"), want: errs.New(0, "failed")},
+		{name: "framework", err: errs.ErrServerOverload, want: errs.ErrServerOverload},
+		{name: "wrapped", err: fmt.Errorf("load: %w", errs.New(7, "bad")), want: errs.New(7, "bad")},
+		{name: "plain", err: errors.New("boom"), want: errs.NewFrameError(int(errs.ErrUnknown.Code), "boom")},
+	}
+	svc := &ErrService{errs: make(map[string]error)}
+	for _, tt := range tests {
+		svc.errs[tt.name] = tt.err
+	}
+	p := &SanRPCProtocol{}
+	if err := p.RegisterService(svc); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := codec.Codecs[codec.JSON].Encode(tt.name)
+			req := &MessageProtocol{
+				Header: &HeaderMsg{ServiceName: "ErrService", MethodName: "Fail", EncodeType: uint32(codec.JSON)},
+				Data:   data,
+			}
+			msg, err := p.HandleMessage(context.Background(), req)
+			if err != nil {
+				t.Fatalf("HandleMessage() error = %v", err)
+			}
+			err = ToError(msg.(*MessageProtocol).Err)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("ToError() = %v, want nil", err)
+				}
+				return
+			}
+			got, ok := err.(*errs.Error)
+			if !ok || got.Type != tt.want.Type || got.Code != tt.want.Code || got.Msg != tt.want.Msg {
+				t.Errorf("ToError() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
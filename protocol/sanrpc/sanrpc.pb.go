@@ -6,6 +6,7 @@ package sanrpc
 import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	any "github.com/golang/protobuf/ptypes/any"
 	math "math"
 )
 
@@ -48,21 +49,36 @@ func (SanrpcMagic) EnumDescriptor() ([]byte, []int) {
 type SanrpcContentEncodeType int32
 
 const (
-	SanrpcContentEncodeType_SANRPC_NONE_ENCODE SanrpcContentEncodeType = 0
-	SanrpcContentEncodeType_SANRPC_PB_ENCODE   SanrpcContentEncodeType = 1
-	SanrpcContentEncodeType_SANRPC_JSON_ENCODE SanrpcContentEncodeType = 2
+	SanrpcContentEncodeType_SANRPC_NONE_ENCODE           SanrpcContentEncodeType = 0
+	SanrpcContentEncodeType_SANRPC_PB_ENCODE             SanrpcContentEncodeType = 1
+	SanrpcContentEncodeType_SANRPC_JSON_ENCODE           SanrpcContentEncodeType = 2
+	SanrpcContentEncodeType_SANRPC_MSGPACK_ENCODE        SanrpcContentEncodeType = 3
+	SanrpcContentEncodeType_SANRPC_GOB_ENCODE            SanrpcContentEncodeType = 4
+	SanrpcContentEncodeType_SANRPC_CBOR_ENCODE           SanrpcContentEncodeType = 5
+	SanrpcContentEncodeType_SANRPC_THRIFT_BINARY_ENCODE  SanrpcContentEncodeType = 6
+	SanrpcContentEncodeType_SANRPC_THRIFT_COMPACT_ENCODE SanrpcContentEncodeType = 7
 )
 
 var SanrpcContentEncodeType_name = map[int32]string{
 	0: "SANRPC_NONE_ENCODE",
 	1: "SANRPC_PB_ENCODE",
 	2: "SANRPC_JSON_ENCODE",
+	3: "SANRPC_MSGPACK_ENCODE",
+	4: "SANRPC_GOB_ENCODE",
+	5: "SANRPC_CBOR_ENCODE",
+	6: "SANRPC_THRIFT_BINARY_ENCODE",
+	7: "SANRPC_THRIFT_COMPACT_ENCODE",
 }
 
 var SanrpcContentEncodeType_value = map[string]int32{
-	"SANRPC_NONE_ENCODE": 0,
-	"SANRPC_PB_ENCODE":   1,
-	"SANRPC_JSON_ENCODE": 2,
+	"SANRPC_NONE_ENCODE":           0,
+	"SANRPC_PB_ENCODE":             1,
+	"SANRPC_JSON_ENCODE":           2,
+	"SANRPC_MSGPACK_ENCODE":        3,
+	"SANRPC_GOB_ENCODE":            4,
+	"SANRPC_CBOR_ENCODE":           5,
+	"SANRPC_THRIFT_BINARY_ENCODE":  6,
+	"SANRPC_THRIFT_COMPACT_ENCODE": 7,
 }
 
 func (x SanrpcContentEncodeType) String() string {
@@ -230,12 +246,14 @@ func (m *HeaderMsg) GetMetaData() map[string]string {
 }
 
 type ErrMsg struct {
-	Type                 int32    `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
-	Code                 int32    `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
-	Msg                  string   `protobuf:"bytes,3,opt,name=msg,proto3" json:"msg,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Type int32  `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Code int32  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Msg  string `protobuf:"bytes,3,opt,name=msg,proto3" json:"msg,omitempty"`
+	// 错误详情，protobuf 消息按类型打包，其他类型以 JSON 打包
+	Details              []*any.Any `protobuf:"bytes,4,rep,name=details,proto3" json:"details,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
 func (m *ErrMsg) Reset()         { *m = ErrMsg{} }
@@ -284,6 +302,13 @@ func (m *ErrMsg) GetMsg() string {
 	return ""
 }
 
+func (m *ErrMsg) GetDetails() []*any.Any {
+	if m != nil {
+		return m.Details
+	}
+	return nil
+}
+
 type MessageProtocol struct {
 	Header               *HeaderMsg `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	Err                  *ErrMsg    `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
@@ -353,39 +378,46 @@ func init() {
 func init() { proto.RegisterFile("sanrpc.proto", fileDescriptor_be86558a1b70b3c0) }
 
 var fileDescriptor_be86558a1b70b3c0 = []byte{
-	// 544 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x53, 0x41, 0x6f, 0xda, 0x4c,
-	0x10, 0x8d, 0x4d, 0x20, 0x78, 0x0c, 0x5f, 0xf8, 0x36, 0xa8, 0x71, 0x53, 0xa9, 0xa1, 0xf4, 0x42,
-	0x39, 0x70, 0xa0, 0x97, 0xaa, 0xed, 0x85, 0x80, 0x0b, 0xa9, 0x62, 0xe3, 0xae, 0xa1, 0x87, 0x5e,
-	0xac, 0xad, 0x59, 0x11, 0x54, 0x6c, 0x53, 0x7b, 0x83, 0xc4, 0xdf, 0xe9, 0xdf, 0xa8, 0xfa, 0xab,
-	0xfa, 0x07, 0xaa, 0x9d, 0xb5, 0x89, 0xab, 0xde, 0x66, 0xde, 0xbc, 0x79, 0x7e, 0xfb, 0x46, 0x86,
-	0x46, 0xc6, 0xe2, 0x74, 0x17, 0x0e, 0x76, 0x69, 0x22, 0x12, 0x52, 0x53, 0x5d, 0xf7, 0xb7, 0x0e,
-	0xc6, 0x8c, 0xb3, 0x15, 0x4f, 0x9d, 0x6c, 0x4d, 0x2c, 0x38, 0xdb, 0xf3, 0x34, 0xdb, 0x24, 0xb1,
-	0xa5, 0x75, 0xb4, 0x5e, 0x93, 0x16, 0x2d, 0x79, 0x06, 0x46, 0xc8, 0xb6, 0xdb, 0x40, 0x1c, 0x76,
-	0xdc, 0xd2, 0x71, 0x56, 0x97, 0xc0, 0xe2, 0xb0, 0xe3, 0xa4, 0x05, 0x95, 0x8c, 0x7f, 0xb7, 0x2a,
-	0x1d, 0xad, 0x77, 0x4a, 0x65, 0x29, 0x85, 0xc4, 0x26, 0xe2, 0xc9, 0x83, 0xb0, 0x4e, 0x95, 0x50,
-	0xde, 0x92, 0x17, 0xd0, 0xc8, 0x78, 0xba, 0xdf, 0x84, 0x3c, 0x88, 0x59, 0xc4, 0xad, 0x6a, 0x47,
-	0xeb, 0x19, 0xd4, 0xcc, 0x31, 0x97, 0x45, 0x9c, 0x5c, 0x83, 0x19, 0x71, 0x71, 0x9f, 0xac, 0x14,
-	0xa3, 0x86, 0x0c, 0x50, 0x50, 0x41, 0xe0, 0x71, 0x98, 0xac, 0xb8, 0xb2, 0x73, 0x86, 0x5f, 0x00,
-	0x05, 0xa1, 0xa1, 0x97, 0xd0, 0x0c, 0x93, 0x68, 0x97, 0xf2, 0x2c, 0x53, 0x94, 0x3a, 0x52, 0x1a,
-	0x05, 0x88, 0xa4, 0xf7, 0x60, 0x44, 0x5c, 0xb0, 0x60, 0xc5, 0x04, 0xb3, 0x8c, 0x4e, 0xa5, 0x67,
-	0x0e, 0xaf, 0x07, 0x79, 0x48, 0xc7, 0x48, 0x06, 0x0e, 0x17, 0x6c, 0xc2, 0x04, 0xb3, 0x63, 0x91,
-	0x1e, 0x68, 0x3d, 0xca, 0xdb, 0xab, 0x77, 0xd0, 0xfc, 0x6b, 0x24, 0x43, 0xf8, 0xc6, 0x0f, 0x98,
-	0x9b, 0x41, 0x65, 0x49, 0xda, 0x50, 0xdd, 0xb3, 0xed, 0x83, 0xca, 0xcb, 0xa0, 0xaa, 0x79, 0xab,
-	0xbf, 0xd1, 0xba, 0x37, 0x50, 0xb3, 0x53, 0x4c, 0x9c, 0xc0, 0x29, 0x1a, 0x94, 0x6b, 0x55, 0x8a,
-	0xb5, 0xc4, 0xe4, 0x4b, 0x70, 0xad, 0x4a, 0xb1, 0x96, 0xea, 0x51, 0xb6, 0xc6, 0x88, 0x0d, 0x2a,
-	0xcb, 0x6e, 0x0a, 0xe7, 0x0e, 0xcf, 0x32, 0xb6, 0xe6, 0x9e, 0xbc, 0x68, 0x98, 0x6c, 0xc9, 0x2b,
-	0xa8, 0xdd, 0xa3, 0x71, 0x94, 0x33, 0x87, 0xff, 0xff, 0xf3, 0x1c, 0x9a, 0x13, 0x48, 0x07, 0x2a,
-	0x3c, 0x4d, 0xf1, 0x13, 0xe6, 0xf0, 0xbf, 0x82, 0xa7, 0x4c, 0x51, 0x39, 0x92, 0x2e, 0x30, 0x19,
-	0x79, 0xbf, 0x06, 0xc5, 0xba, 0x3f, 0x03, 0xd3, 0x47, 0xa6, 0xc3, 0xd6, 0x9b, 0x90, 0x3c, 0x87,
-	0x2b, 0x7f, 0xe4, 0x52, 0x6f, 0x1c, 0x4c, 0xec, 0x0f, 0xa3, 0xe5, 0xdd, 0x22, 0x70, 0x46, 0xd3,
-	0xdb, 0x71, 0xf0, 0x79, 0x74, 0xb7, 0xb4, 0x5b, 0x27, 0xe4, 0x29, 0x90, 0x7c, 0x5e, 0xc6, 0x7f,
-	0xfc, 0xfc, 0xa5, 0xf7, 0x03, 0xb8, 0x54, 0x4a, 0xe3, 0x24, 0x16, 0x3c, 0x16, 0xf6, 0xe3, 0xf1,
-	0x9e, 0x1c, 0xb7, 0xdc, 0xb9, 0x6b, 0x07, 0xb6, 0x3b, 0x9e, 0x4f, 0xa4, 0x5a, 0x1b, 0x5a, 0x39,
-	0xee, 0xdd, 0x14, 0xa8, 0x56, 0x62, 0x7f, 0xf4, 0xe7, 0x6e, 0x81, 0xeb, 0xfd, 0x19, 0x90, 0xe2,
-	0x03, 0xa5, 0x9b, 0x5b, 0xd0, 0x2e, 0x6b, 0x8f, 0xe7, 0x8e, 0x47, 0x6d, 0xdf, 0x6f, 0x9d, 0x94,
-	0x26, 0xd3, 0x2f, 0xb7, 0xde, 0xe3, 0x44, 0xeb, 0x2f, 0xa1, 0x99, 0x3f, 0x3a, 0x5b, 0xa3, 0xc8,
-	0x05, 0x9c, 0x97, 0x45, 0x1c, 0x7f, 0xda, 0x3a, 0x29, 0xf9, 0xa0, 0xf6, 0xa7, 0xa5, 0xed, 0x2f,
-	0x10, 0xd7, 0xc8, 0x25, 0x5c, 0x1c, 0x71, 0xdf, 0x9b, 0xbb, 0xbe, 0x5a, 0xd0, 0xbf, 0xd6, 0xf0,
-	0x47, 0x7c, 0xfd, 0x27, 0x00, 0x00, 0xff, 0xff, 0x56, 0xc6, 0x6c, 0x66, 0x98, 0x03, 0x00, 0x00,
+	// 644 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x52, 0xcb, 0x6e, 0xd3, 0x40,
+	0x14, 0xad, 0xf3, 0xac, 0x6f, 0x12, 0x9a, 0x4e, 0x53, 0xea, 0xb6, 0x88, 0x86, 0xb2, 0x09, 0x59,
+	0xa4, 0x52, 0xd9, 0x20, 0x60, 0xe3, 0xba, 0x6e, 0x12, 0x68, 0x6c, 0x33, 0x4e, 0x90, 0x60, 0x63,
+	0x4d, 0x9d, 0xc1, 0x8d, 0x88, 0xed, 0x60, 0xbb, 0x95, 0xf2, 0x3b, 0xfc, 0x06, 0xe2, 0x8f, 0xd8,
+	0xf1, 0x03, 0x68, 0x66, 0x3c, 0xa9, 0x2b, 0x76, 0xf7, 0x9e, 0x73, 0xee, 0x99, 0xfb, 0x18, 0x68,
+	0xa6, 0x24, 0x4a, 0x56, 0xfe, 0x60, 0x95, 0xc4, 0x59, 0x8c, 0x6a, 0x22, 0x3b, 0x3a, 0x0c, 0xe2,
+	0x38, 0x58, 0xd2, 0x33, 0x8e, 0xde, 0xdc, 0x7d, 0x3b, 0x23, 0xd1, 0x5a, 0x48, 0x4e, 0xff, 0x96,
+	0x40, 0x1d, 0x51, 0x32, 0xa7, 0xc9, 0x24, 0x0d, 0x90, 0x06, 0xf5, 0x7b, 0x9a, 0xa4, 0x8b, 0x38,
+	0xd2, 0x94, 0xae, 0xd2, 0x6b, 0x61, 0x99, 0xa2, 0x63, 0x50, 0x7d, 0xb2, 0x5c, 0x7a, 0xd9, 0x7a,
+	0x45, 0xb5, 0x12, 0xe7, 0xb6, 0x19, 0x30, 0x5d, 0xaf, 0x28, 0x6a, 0x43, 0x39, 0xa5, 0x3f, 0xb4,
+	0x72, 0x57, 0xe9, 0x55, 0x30, 0x0b, 0x99, 0x51, 0xb6, 0x08, 0x69, 0x7c, 0x97, 0x69, 0x15, 0x61,
+	0x94, 0xa7, 0xe8, 0x05, 0x34, 0x53, 0x9a, 0xdc, 0x2f, 0x7c, 0xea, 0x45, 0x24, 0xa4, 0x5a, 0xb5,
+	0xab, 0xf4, 0x54, 0xdc, 0xc8, 0x31, 0x8b, 0x84, 0x14, 0x9d, 0x40, 0x23, 0xa4, 0xd9, 0x6d, 0x3c,
+	0x17, 0x8a, 0x1a, 0x57, 0x80, 0x80, 0xa4, 0x80, 0x46, 0x7e, 0x3c, 0xa7, 0xa2, 0x9d, 0x3a, 0x7f,
+	0x01, 0x04, 0xc4, 0x1b, 0x7a, 0x09, 0x2d, 0x3f, 0x0e, 0x57, 0x09, 0x4d, 0x53, 0x21, 0xd9, 0xe6,
+	0x92, 0xa6, 0x04, 0xb9, 0xe8, 0x3d, 0xa8, 0x21, 0xcd, 0x88, 0x37, 0x27, 0x19, 0xd1, 0xd4, 0x6e,
+	0xb9, 0xd7, 0x38, 0x3f, 0x19, 0xe4, 0xfb, 0xdb, 0xac, 0x64, 0x30, 0xa1, 0x19, 0xb9, 0x24, 0x19,
+	0x31, 0xa3, 0x2c, 0x59, 0xe3, 0xed, 0x30, 0x4f, 0x8f, 0xde, 0x41, 0xeb, 0x11, 0xc5, 0x96, 0xf0,
+	0x9d, 0xae, 0xf9, 0xde, 0x54, 0xcc, 0x42, 0xd4, 0x81, 0xea, 0x3d, 0x59, 0xde, 0x89, 0x7d, 0xa9,
+	0x58, 0x24, 0x6f, 0x4b, 0x6f, 0x94, 0xd3, 0x04, 0x6a, 0x66, 0xc2, 0x37, 0x8e, 0xa0, 0xc2, 0x1b,
+	0x64, 0x65, 0x55, 0xcc, 0x63, 0x86, 0xb1, 0x49, 0x78, 0x59, 0x15, 0xf3, 0x98, 0xb9, 0x87, 0x69,
+	0xc0, 0x57, 0xac, 0x62, 0x16, 0xa2, 0x01, 0xd4, 0xe7, 0x34, 0x23, 0x8b, 0x65, 0xaa, 0x55, 0x78,
+	0xf3, 0x9d, 0x81, 0x38, 0xf3, 0x40, 0x9e, 0x79, 0xa0, 0x47, 0x6b, 0x2c, 0x45, 0xa7, 0x09, 0xec,
+	0x4c, 0x68, 0x9a, 0x92, 0x80, 0x3a, 0x8c, 0xf7, 0xe3, 0x25, 0x7a, 0x05, 0xb5, 0x5b, 0x3e, 0x28,
+	0x7f, 0xbe, 0x71, 0xbe, 0xfb, 0xdf, 0xf8, 0x38, 0x17, 0xa0, 0x2e, 0x94, 0x69, 0x92, 0xf0, 0x96,
+	0x1a, 0xe7, 0x4f, 0xa4, 0x4e, 0x0c, 0x81, 0x19, 0xc5, 0xba, 0xe6, 0x9b, 0x64, 0xf7, 0x6e, 0x62,
+	0x1e, 0xf7, 0x47, 0xd0, 0x70, 0xb9, 0x72, 0x42, 0x82, 0x85, 0x8f, 0x9e, 0xc3, 0x91, 0xab, 0x5b,
+	0xd8, 0x31, 0xbc, 0x4b, 0xf3, 0x4a, 0x9f, 0x5d, 0x4f, 0xbd, 0x89, 0x3e, 0x1c, 0x1b, 0xde, 0x67,
+	0xfd, 0x7a, 0x66, 0xb6, 0xb7, 0xd0, 0x21, 0xa0, 0x9c, 0x2f, 0xe2, 0x3f, 0x7f, 0xfd, 0x2e, 0xf5,
+	0xff, 0x28, 0x70, 0x20, 0xac, 0x8c, 0x38, 0xca, 0x68, 0x94, 0x99, 0x0f, 0xd7, 0x7e, 0xba, 0x29,
+	0xb3, 0x6c, 0xcb, 0xf4, 0x4c, 0xcb, 0xb0, 0x2f, 0x99, 0x5d, 0x07, 0xda, 0x39, 0xee, 0x5c, 0x48,
+	0x54, 0x29, 0xa8, 0x3f, 0xb8, 0xb6, 0x25, 0xf1, 0x12, 0x3a, 0x84, 0x7d, 0xf9, 0xb8, 0x3b, 0x74,
+	0x74, 0xe3, 0xa3, 0xa4, 0xca, 0x68, 0x1f, 0x76, 0x73, 0x6a, 0x68, 0x6f, 0x9c, 0x2a, 0x05, 0x27,
+	0xe3, 0xc2, 0xc6, 0x12, 0xaf, 0xa2, 0x13, 0x38, 0xce, 0xf1, 0xe9, 0x08, 0x8f, 0xaf, 0xa6, 0xde,
+	0xc5, 0xd8, 0xd2, 0xf1, 0x17, 0x29, 0xa8, 0xa1, 0x2e, 0x3c, 0x7b, 0x2c, 0x30, 0xec, 0x89, 0xa3,
+	0x1b, 0x53, 0xa9, 0xa8, 0xf7, 0x47, 0x80, 0xe4, 0xb4, 0x85, 0x1f, 0xab, 0x41, 0xa7, 0x38, 0x28,
+	0xab, 0xc2, 0xa6, 0xeb, 0xb6, 0xb7, 0x0a, 0xcc, 0xf0, 0xeb, 0xd8, 0x79, 0x60, 0x94, 0xfe, 0x0c,
+	0x5a, 0xf9, 0x09, 0xd2, 0x80, 0x9b, 0xec, 0xc1, 0x4e, 0xd1, 0x64, 0xe2, 0x0e, 0xdb, 0x5b, 0x85,
+	0x51, 0xb0, 0xf9, 0x69, 0x66, 0xba, 0x53, 0x8e, 0x2b, 0xe8, 0x00, 0xf6, 0x36, 0xb8, 0xeb, 0xd8,
+	0x96, 0x2b, 0x0a, 0x4a, 0x37, 0x35, 0xfe, 0xc9, 0x5e, 0xff, 0x1b, 0x00, 0x1c, 0x9c, 0xed, 0xe3,
+	0x71, 0x04, 0x00, 0x00,
 }
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	log "github.com/hillguo/sanlog"
+	"github.com/hillguo/sanrpc/errs"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -145,3 +147,79 @@ func (s *service) Call(ctx context.Context, mtype *methodType, argv, replyv refl
 
 	return nil
 }
+
+// Invoke 查找已注册的 serviceName.methodName（不区分大小写），用 decode 解出请求后调用，返回响应。
+// 找不到服务或方法时返回 errs.ErrServerNoService、errs.ErrServerNoMethod；
+// decode 失败时返回 errs.ErrServerDecodeDataErr，decode 返回 *errs.Error 时原样返回
+func (p *BaseService) Invoke(ctx context.Context, serviceName, methodName string, decode func(argv interface{}) error) (interface{}, error) {
+	p.ServiceMapMu.RLock()
+	service := p.ServiceMap[strings.ToLower(serviceName)]
+	p.ServiceMapMu.RUnlock()
+	if service == nil {
+		return nil, errs.ErrServerNoService
+	}
+	mtype := service.GetMethod(strings.ToLower(methodName))
+	if mtype == nil {
+		return nil, errs.ErrServerNoMethod
+	}
+
+	// 参数不是指针时解码到新分配的指针，调用时传值
+	var argv reflect.Value
+	if mtype.ArgType.Kind() != reflect.Ptr {
+		argv = reflect.New(mtype.ArgType)
+	} else {
+		argv = reflect.New(mtype.ArgType.Elem())
+	}
+	if err := decode(argv.Interface()); err != nil {
+		if e, ok := err.(*errs.Error); ok {
+			return nil, e
+		}
+		return nil, errs.ErrServerDecodeDataErr
+	}
+	if mtype.ArgType.Kind() != reflect.Ptr {
+		argv = argv.Elem()
+	}
+
+	replyv := reflect.New(mtype.ReplyType.Elem())
+	log.Debugf("req:%+v", argv.Interface())
+	if err := service.Call(ctx, mtype, argv, replyv); err != nil {
+		return nil, err
+	}
+	log.Debugf("resp:%+v", replyv.Interface())
+	return replyv.Interface(), nil
+}
+
+// ServiceInfo 已注册服务的描述
+type ServiceInfo struct {
+	Name    string
+	Methods []MethodInfo
+}
+
+// MethodInfo 已注册方法的描述
+type MethodInfo struct {
+	Name      string
+	ArgType   reflect.Type
+	ReplyType reflect.Type
+}
+
+// Describer 可以列出已注册服务的 MsgProtocol
+type Describer interface {
+	Describe() []ServiceInfo
+}
+
+// Describe 返回所有已注册的服务和方法，按名字排序
+func (p *BaseService) Describe() []ServiceInfo {
+	p.ServiceMapMu.RLock()
+	defer p.ServiceMapMu.RUnlock()
+	infos := make([]ServiceInfo, 0, len(p.ServiceMap))
+	for name, s := range p.ServiceMap {
+		info := ServiceInfo{Name: name}
+		for mname, m := range s.method {
+			info.Methods = append(info.Methods, MethodInfo{Name: mname, ArgType: m.ArgType, ReplyType: m.ReplyType})
+		}
+		sort.Slice(info.Methods, func(i, j int) bool { return info.Methods[i].Name < info.Methods[j].Name })
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 )
 
@@ -28,6 +29,22 @@ func (s *Server) Serve() error{
 	ch := make(chan os.Signal)
 	signal.Notify(ch,syscall.SIGINT, syscall.SIGTERM,syscall.SIGSEGV)
 	<-ch
+	return s.Close()
+}
+
+// Close 优雅退出：各个service先从名字服务注销，再关闭监听和连接
+func (s *Server) Close() error {
+	var wg sync.WaitGroup
+	for name, svr := range s.services {
+		wg.Add(1)
+		go func(name string, svr service.Service) {
+			defer wg.Done()
+			if err := svr.Close(); err != nil {
+				log.Println("close service", name, "error:", err)
+			}
+		}(name, svr)
+	}
+	wg.Wait()
 	return nil
 }
 
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hillguo/sanrpc/protocol"
+	pb "github.com/hillguo/sanrpc/service/healthpb"
+)
+
+// MaxHealthWait Watch 长轮询的最长等待时间
+const MaxHealthWait = time.Minute
+
+// Health 健康检查服务，以 sanrpc 服务名 health 注册在每个 MsgProtocol 上。
+// 同一个 MsgProtocol 上的 service 共用一个 Health，各自登记自己的服务名。
+type Health struct {
+	mu       sync.Mutex
+	statuses map[string]pb.ServingStatus
+	changed  chan struct{} // 状态变化时关闭并替换
+}
+
+// NewHealth 创建 Health
+func NewHealth() *Health {
+	return &Health{
+		statuses: make(map[string]pb.ServingStatus),
+		changed:  make(chan struct{}),
+	}
+}
+
+var (
+	healths  = make(map[protocol.MsgProtocol]*Health)
+	healthMu sync.Mutex
+)
+
+// HealthFor 返回 MsgProtocol 上的 Health，首次调用时把 Health 注册到 MsgProtocol。
+// MsgProtocol 不支持注册服务时返回 nil。
+func HealthFor(p protocol.MsgProtocol) *Health {
+	rs, ok := p.(protocol.RegisterServicer)
+	if !ok {
+		return nil
+	}
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	if h, ok := healths[p]; ok {
+		return h
+	}
+	h := NewHealth()
+	if err := rs.RegisterService(h); err != nil {
+		return nil
+	}
+	healths[p] = h
+	return h
+}
+
+// SetServingStatus 设置服务名的状态
+func (h *Health) SetServingStatus(name string, status pb.ServingStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if old, ok := h.statuses[name]; ok && old == status {
+		return
+	}
+	h.statuses[name] = status
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+// status 服务名为空时，只要有一个服务 SERVING 就认为整个进程 SERVING
+func (h *Health) status(name string) (pb.ServingStatus, <-chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if name != "" {
+		status, ok := h.statuses[name]
+		if !ok {
+			return pb.ServingStatus_SERVICE_UNKNOWN, h.changed
+		}
+		return status, h.changed
+	}
+	for _, status := range h.statuses {
+		if status == pb.ServingStatus_SERVING {
+			return status, h.changed
+		}
+	}
+	return pb.ServingStatus_NOT_SERVING, h.changed
+}
+
+// Check 返回服务当前的状态
+func (h *Health) Check(ctx context.Context, req *pb.CheckReq, resp *pb.CheckResp) error {
+	resp.Status, _ = h.status(req.GetService())
+	return nil
+}
+
+// Watch 状态与 req.Status 相同时最多等待 req.WaitMs 直到状态变化，返回当前状态
+func (h *Health) Watch(ctx context.Context, req *pb.WatchReq, resp *pb.WatchResp) error {
+	wait := time.Duration(req.GetWaitMs()) * time.Millisecond
+	if wait > MaxHealthWait {
+		wait = MaxHealthWait
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		status, changed := h.status(req.GetService())
+		if status != req.GetStatus() || wait <= 0 {
+			resp.Status = status
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			resp.Status = status
+			return nil
+		case <-ctx.Done():
+			resp.Status = status
+			return nil
+		}
+	}
+}
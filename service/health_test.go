@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/naming/registry"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+	pb "github.com/hillguo/sanrpc/service/healthpb"
+)
+
+func TestHealthCheck(t *testing.T) {
+	h := NewHealth()
+	check := func(name string) pb.ServingStatus {
+		resp := &pb.CheckResp{}
+		if err := h.Check(context.Background(), &pb.CheckReq{Service: name}, resp); err != nil {
+			t.Fatalf("Check(%q) error = %v", name, err)
+		}
+		return resp.Status
+	}
+	if got := check(""); got != pb.ServingStatus_NOT_SERVING {
+		t.Errorf("Check(\"\") without services = %v, want NOT_SERVING", got)
+	}
+
+	h.SetServingStatus("greeter", pb.ServingStatus_SERVING)
+	h.SetServingStatus("user", pb.ServingStatus_NOT_SERVING)
+	tests := []struct {
+		name string
+		want pb.ServingStatus
+	}{
+		{name: "greeter", want: pb.ServingStatus_SERVING},
+		{name: "user", want: pb.ServingStatus_NOT_SERVING},
+		{name: "nope", want: pb.ServingStatus_SERVICE_UNKNOWN},
+		{name: "", want: pb.ServingStatus_SERVING},
+	}
+	for _, tt := range tests {
+		if got := check(tt.name); got != tt.want {
+			t.Errorf("Check(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// 所有服务都不是 SERVING 时整个进程为 NOT_SERVING
+	h.SetServingStatus("greeter", pb.ServingStatus_NOT_SERVING)
+	if got := check(""); got != pb.ServingStatus_NOT_SERVING {
+		t.Errorf("Check(\"\") = %v, want NOT_SERVING", got)
+	}
+}
+
+func TestHealthWatch(t *testing.T) {
+	h := NewHealth()
+	h.SetServingStatus("greeter", pb.ServingStatus_SERVING)
+	watch := func(ctx context.Context, status pb.ServingStatus, wait time.Duration) (pb.ServingStatus, time.Duration) {
+		start := time.Now()
+		resp := &pb.WatchResp{}
+		req := &pb.WatchReq{Service: "greeter", Status: status, WaitMs: int64(wait / time.Millisecond)}
+		if err := h.Watch(ctx, req, resp); err != nil {
+			t.Fatalf("Watch() error = %v", err)
+		}
+		return resp.Status, time.Since(start)
+	}
+
+	// 状态与调用方已知的不同时立即返回
+	if got, cost := watch(context.Background(), pb.ServingStatus_UNKNOWN, 5*time.Second); got != pb.ServingStatus_SERVING || cost > time.Second {
+		t.Errorf("Watch() = %v after %v, want SERVING at once", got, cost)
+	}
+
+	// 状态变化时唤醒，其他服务的变化不唤醒
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		h.SetServingStatus("user", pb.ServingStatus_SERVING)
+		time.Sleep(20 * time.Millisecond)
+		h.SetServingStatus("greeter", pb.ServingStatus_NOT_SERVING)
+	}()
+	if got, cost := watch(context.Background(), pb.ServingStatus_SERVING, 5*time.Second); got != pb.ServingStatus_NOT_SERVING || cost < 40*time.Millisecond || cost > time.Second {
+		t.Errorf("Watch() = %v after %v, want NOT_SERVING after the change", got, cost)
+	}
+
+	// 等待超时或调用方取消时返回当前状态
+	if got, cost := watch(context.Background(), pb.ServingStatus_NOT_SERVING, 30*time.Millisecond); got != pb.ServingStatus_NOT_SERVING || cost < 30*time.Millisecond || cost > time.Second {
+		t.Errorf("Watch() = %v after %v, want NOT_SERVING after the wait", got, cost)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if got, cost := watch(ctx, pb.ServingStatus_NOT_SERVING, 5*time.Second); got != pb.ServingStatus_NOT_SERVING || cost > time.Second {
+		t.Errorf("Watch() = %v after %v, want NOT_SERVING when ctx done", got, cost)
+	}
+}
+
+// Echo 登记到 Health 的测试服务
+type Echo struct{}
+
+func (e *Echo) Echo(ctx context.Context, req *pb.CheckReq, resp *pb.CheckResp) error {
+	resp.Status = pb.ServingStatus_SERVING
+	return nil
+}
+
+// statusRegistry 注销时记录 service 在 Health 中的状态
+type statusRegistry struct {
+	h      *Health
+	status chan pb.ServingStatus
+}
+
+func (r *statusRegistry) Register(serviceName string, opt ...registry.Option) error {
+	return nil
+}
+
+func (r *statusRegistry) Deregister(serviceName string, opt ...registry.Option) error {
+	resp := &pb.CheckResp{}
+	r.h.Check(context.Background(), &pb.CheckReq{Service: serviceName}, resp)
+	r.status <- resp.Status
+	return nil
+}
+
+func TestShutdownNotServing(t *testing.T) {
+	p := &sanrpc.SanRPCProtocol{}
+	reg := &statusRegistry{h: HealthFor(p), status: make(chan pb.ServingStatus, 1)}
+	s := New(WithServiceName("greeter"), WithMsgProtocol(p), WithRegistry(reg), WithShutdownGrace(0)).(*service)
+	if err := s.Register(&Echo{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"greeter", "echo", ""} {
+		resp := &pb.CheckResp{}
+		reg.h.Check(context.Background(), &pb.CheckReq{Service: name}, resp)
+		if resp.Status != pb.ServingStatus_SERVING {
+			t.Fatalf("Check(%q) before shutdown = %v, want SERVING", name, resp.Status)
+		}
+	}
+	if err := s.registerSelf(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 长轮询的调用方在 Shutdown 时被唤醒
+	watched := make(chan pb.ServingStatus, 1)
+	go func() {
+		resp := &pb.WatchResp{}
+		reg.h.Watch(context.Background(), &pb.WatchReq{Service: "greeter", Status: pb.ServingStatus_SERVING, WaitMs: 5000}, resp)
+		watched <- resp.Status
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := <-reg.status; got != pb.ServingStatus_NOT_SERVING {
+		t.Errorf("status at deregister = %v, want NOT_SERVING before leaving the registry", got)
+	}
+	select {
+	case got := <-watched:
+		if got != pb.ServingStatus_NOT_SERVING {
+			t.Errorf("Watch() = %v, want NOT_SERVING", got)
+		}
+	case <-time.After(time.Second):
+		t.Error("Watch() not woken by Shutdown")
+	}
+	for _, name := range []string{"greeter", "echo", ""} {
+		resp := &pb.CheckResp{}
+		reg.h.Check(context.Background(), &pb.CheckReq{Service: name}, resp)
+		if resp.Status != pb.ServingStatus_NOT_SERVING {
+			t.Errorf("Check(%q) after shutdown = %v, want NOT_SERVING", name, resp.Status)
+		}
+	}
+}
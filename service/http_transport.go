@@ -1,18 +1,25 @@
 package service
 
 import (
+	"context"
 	"errors"
 	log "github.com/hillguo/sanlog"
 	"github.com/hillguo/sanrpc/protocol"
+	"net"
 	"net/http"
+	"sync"
 )
 
 type httpTransport struct {
 	s *service
+
+	mu     sync.Mutex
+	server *http.Server
+	closed bool
 }
 
 func NewHTTPTransport(s *service) ServerTransport {
-	return &httpTransport{s}
+	return &httpTransport{s: s}
 }
 
 func (t *httpTransport) ListenAndServer() error{
@@ -23,16 +30,61 @@ func (t *httpTransport) ListenAndServer() error{
 		return errors.New("sanrpc: msg protocol is not http protocol")
 	}
 
-	log.Infof("http listening network:%s ,address:%s", t.s.opts.NetWork, t.s.opts.Address)
-	err := http.ListenAndServe(t.s.opts.Address, app)
+	ln, err := net.Listen("tcp", t.s.opts.Address)
 	if err != nil {
-		log.Errorf("ListenAndServe fail:", err)
+		log.Errorf("ListenAndServe fail: %v", err)
+		return err
+	}
+	log.Infof("http listening network:%s ,address:%s", t.s.opts.NetWork, t.s.opts.Address)
+
+	server := &http.Server{Handler: app}
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		ln.Close()
+		return nil
+	}
+	t.server = server
+	t.mu.Unlock()
+	if err := t.s.registerSelf(ln.Addr()); err != nil {
+		ln.Close()
+		return err
+	}
+
+	err = server.Serve(ln)
+	if err != nil && err != http.ErrServerClosed {
+		log.Errorf("ListenAndServe fail: %v", err)
 		return err
 	}
 	return  nil
 }
 
-func (t *httpTransport) Close() error{
-	return nil
+// Shutdown 停止监听并等待处理中的请求结束，ctx 结束时强制关闭剩余连接
+func (t *httpTransport) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	server := t.server
+	t.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	err := server.Shutdown(ctx)
+	if err == ctx.Err() && err != nil {
+		log.Warnf("http: shutdown timeout, force close connections")
+		server.Close()
+	}
+	return err
+}
+
+// Close 立即停止监听并关闭所有连接
+func (t *httpTransport) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	server := t.server
+	t.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Close()
 }
 
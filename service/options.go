@@ -1,11 +1,18 @@
 package service
 
 import (
+	"time"
+
+	"github.com/hillguo/sanrpc/naming/registry"
 	"github.com/hillguo/sanrpc/protocol"
 )
 
 type Options struct {
 	Name string
+	Namespace string // 命名空间 正式环境 Production 测试环境 Development
+	EnvName   string // 当前环境
+	SetName   string // set分组
+	Zone      string // 可用区
 	InMsgChanSize uint32
 	OutMsgChanSize uint32
 	ReadTimeout uint32
@@ -15,6 +22,13 @@ type Options struct {
 	NetWork        string
 
 	MsgProtocol    protocol.MsgProtocol
+
+	Registry registry.Registry // 监听成功后注册，关闭时注销
+
+	// ShutdownGrace 从名字服务注销后、停止监听前的等待时间，让调用方的服务发现缓存有时间更新
+	ShutdownGrace time.Duration
+	// ShutdownTimeout Close 时等待处理中的请求结束的最长时间，超时后强制关闭连接
+	ShutdownTimeout time.Duration
 }
 
 type Option func(options *Options)
@@ -25,6 +39,30 @@ func WithServiceName(s string) Option{
 	}
 }
 
+func WithNamespace(s string) Option{
+	return func(o *Options) {
+		o.Namespace = s
+	}
+}
+
+func WithEnvName(s string) Option{
+	return func(o *Options) {
+		o.EnvName = s
+	}
+}
+
+func WithSetName(s string) Option{
+	return func(o *Options) {
+		o.SetName = s
+	}
+}
+
+func WithZone(s string) Option{
+	return func(o *Options) {
+		o.Zone = s
+	}
+}
+
 func WithAddress(s string) Option{
 	return func(o *Options) {
 		o.Address = s
@@ -61,6 +99,24 @@ func WithOutMsgChanSize(size uint32) Option{
 	}
 }
 
+func WithRegistry(r registry.Registry) Option {
+	return func(o *Options){
+		o.Registry = r
+	}
+}
+
+func WithShutdownGrace(d time.Duration) Option {
+	return func(o *Options) {
+		o.ShutdownGrace = d
+	}
+}
+
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.ShutdownTimeout = d
+	}
+}
+
 func WithMsgProtocol(p protocol.MsgProtocol) Option {
 	return func(o *Options){
 		o.MsgProtocol = p
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"reflect"
+	"sync"
+
+	"github.com/golang/protobuf/descriptor"
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/protocol"
+	pb "github.com/hillguo/sanrpc/service/reflectionpb"
+)
+
+// Reflection 反射服务，列出 MsgProtocol 上注册的服务、方法和 protobuf 描述，
+// 以 sanrpc 服务名 reflection 注册在每个 MsgProtocol 上
+type Reflection struct {
+	d protocol.Describer
+}
+
+// NewReflection 创建 Reflection
+func NewReflection(d protocol.Describer) *Reflection {
+	return &Reflection{d: d}
+}
+
+var (
+	reflections  = make(map[protocol.MsgProtocol]*Reflection)
+	reflectionMu sync.Mutex
+)
+
+// ReflectionFor 返回 MsgProtocol 上的 Reflection，首次调用时把 Reflection 注册到 MsgProtocol。
+// MsgProtocol 不支持注册或列出服务时返回 nil。
+func ReflectionFor(p protocol.MsgProtocol) *Reflection {
+	rs, ok := p.(protocol.RegisterServicer)
+	if !ok {
+		return nil
+	}
+	d, ok := p.(protocol.Describer)
+	if !ok {
+		return nil
+	}
+	reflectionMu.Lock()
+	defer reflectionMu.Unlock()
+	if r, ok := reflections[p]; ok {
+		return r
+	}
+	r := NewReflection(d)
+	if err := rs.RegisterService(r); err != nil {
+		return nil
+	}
+	reflections[p] = r
+	return r
+}
+
+// ListServices 返回所有服务和方法
+func (r *Reflection) ListServices(ctx context.Context, req *pb.ListServicesReq, resp *pb.ListServicesResp) error {
+	for _, s := range r.d.Describe() {
+		info := &pb.ServiceInfo{Name: s.Name}
+		for _, m := range s.Methods {
+			info.Methods = append(info.Methods, &pb.MethodInfo{
+				Name:         m.Name,
+				ArgType:      m.ArgType.String(),
+				ReplyType:    m.ReplyType.String(),
+				ArgMessage:   messageName(m.ArgType),
+				ReplyMessage: messageName(m.ReplyType),
+			})
+		}
+		resp.Services = append(resp.Services, info)
+	}
+	return nil
+}
+
+// FileContainingMessage 返回定义 req.Message 的 proto 文件及其依赖
+func (r *Reflection) FileContainingMessage(ctx context.Context, req *pb.FileContainingMessageReq, resp *pb.FileResp) error {
+	typ := proto.MessageType(req.GetMessage())
+	if typ == nil {
+		return errs.NewStatusError(errs.StatusNotFound, "message not found: "+req.GetMessage())
+	}
+	msg, ok := reflect.New(typ.Elem()).Interface().(descriptor.Message)
+	if !ok {
+		return errs.NewStatusError(errs.StatusNotFound, "message has no descriptor: "+req.GetMessage())
+	}
+	fd, _ := descriptor.ForMessage(msg)
+
+	seen := make(map[string]bool)
+	var add func(fd *dpb.FileDescriptorProto) error
+	add = func(fd *dpb.FileDescriptorProto) error {
+		seen[fd.GetName()] = true
+		for _, dep := range fd.GetDependency() {
+			if seen[dep] {
+				continue
+			}
+			depfd, err := fileDescriptor(dep)
+			if err != nil {
+				return err
+			}
+			if err := add(depfd); err != nil {
+				return err
+			}
+		}
+		data, err := proto.Marshal(fd)
+		if err != nil {
+			return err
+		}
+		resp.FileDescriptors = append(resp.FileDescriptors, data)
+		return nil
+	}
+	return add(fd)
+}
+
+// messageName 返回 protobuf 消息全名，不是 protobuf 消息时返回空
+func messageName(t reflect.Type) string {
+	if t.Kind() != reflect.Ptr {
+		return ""
+	}
+	msg, ok := reflect.New(t.Elem()).Interface().(proto.Message)
+	if !ok {
+		return ""
+	}
+	return proto.MessageName(msg)
+}
+
+// fileDescriptor 解析 protoc-gen-go 注册的 proto 文件描述
+func fileDescriptor(name string) (*dpb.FileDescriptorProto, error) {
+	gz := proto.FileDescriptor(name)
+	if gz == nil {
+		return nil, errs.NewStatusError(errs.StatusNotFound, "file not found: "+name)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	fd := &dpb.FileDescriptorProto{}
+	if err := proto.Unmarshal(data, fd); err != nil {
+		return nil, err
+	}
+	return fd, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/protocol/sanrpc"
+	pb "github.com/hillguo/sanrpc/service/reflectionpb"
+)
+
+type sumReq struct {
+	A, B int
+}
+
+type sumResp struct {
+	Sum int
+}
+
+// Calc 参数不是 protobuf 消息的测试服务
+type Calc struct{}
+
+func (c *Calc) Sum(ctx context.Context, req *sumReq, resp *sumResp) error {
+	resp.Sum = req.A + req.B
+	return nil
+}
+
+func TestReflectionListServices(t *testing.T) {
+	p := &sanrpc.SanRPCProtocol{}
+	r := ReflectionFor(p)
+	if r == nil || ReflectionFor(p) != r {
+		t.Fatalf("ReflectionFor() = %v, want one Reflection per protocol", r)
+	}
+	for _, s := range []interface{}{&Echo{}, &Calc{}} {
+		if err := p.RegisterService(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp := &pb.ListServicesResp{}
+	if err := r.ListServices(context.Background(), &pb.ListServicesReq{}, resp); err != nil {
+		t.Fatalf("ListServices() error = %v", err)
+	}
+	var names []string
+	services := make(map[string]*pb.ServiceInfo)
+	for _, s := range resp.Services {
+		names = append(names, s.Name)
+		services[s.Name] = s
+	}
+	if want := []string{"calc", "echo", "reflection"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("services = %v, want %v", names, want)
+	}
+
+	tests := []struct {
+		service string
+		want    *pb.MethodInfo
+	}{
+		{service: "echo", want: &pb.MethodInfo{Name: "echo", ArgType: "*healthpb.CheckReq", ReplyType: "*healthpb.CheckResp",
+			ArgMessage: "healthpb.CheckReq", ReplyMessage: "healthpb.CheckResp"}},
+		{service: "calc", want: &pb.MethodInfo{Name: "sum", ArgType: "*service.sumReq", ReplyType: "*service.sumResp"}},
+	}
+	for _, tt := range tests {
+		methods := services[tt.service].Methods
+		if len(methods) != 1 || !proto.Equal(methods[0], tt.want) {
+			t.Errorf("%s methods = %v, want [%v]", tt.service, methods, tt.want)
+		}
+	}
+	var methods []string
+	for _, m := range services["reflection"].Methods {
+		methods = append(methods, m.Name)
+	}
+	if want := []string{"filecontainingmessage", "listservices"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("reflection methods = %v, want %v", methods, want)
+	}
+}
+
+func TestReflectionFileContainingMessage(t *testing.T) {
+	r := NewReflection(&sanrpc.SanRPCProtocol{})
+	tests := []struct {
+		message string
+		files   []string // 依赖在前，定义消息的文件在最后
+	}{
+		{message: "sanrpc.ErrMsg", files: []string{"google/protobuf/any.proto", "sanrpc.proto"}},
+		{message: "reflectionpb.FileResp", files: []string{"reflection.proto"}},
+		{message: "healthpb.CheckReq", files: []string{"health.proto"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			resp := &pb.FileResp{}
+			if err := r.FileContainingMessage(context.Background(), &pb.FileContainingMessageReq{Message: tt.message}, resp); err != nil {
+				t.Fatalf("FileContainingMessage() error = %v", err)
+			}
+			var files []string
+			for _, data := range resp.FileDescriptors {
+				fd := &dpb.FileDescriptorProto{}
+				if err := proto.Unmarshal(data, fd); err != nil {
+					t.Fatalf("invalid file descriptor: %v", err)
+				}
+				files = append(files, fd.GetName())
+			}
+			if !reflect.DeepEqual(files, tt.files) {
+				t.Errorf("files = %v, want %v", files, tt.files)
+			}
+		})
+	}
+
+	for _, name := range []string{"sanrpc.Nope", "", "sumReq"} {
+		err := r.FileContainingMessage(context.Background(), &pb.FileContainingMessageReq{Message: name}, &pb.FileResp{})
+		if errs.Status(err) != errs.StatusNotFound {
+			t.Errorf("FileContainingMessage(%q) error = %v, want NotFound", name, err)
+		}
+	}
+}
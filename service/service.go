@@ -1,22 +1,53 @@
 package service
 
 import (
+	"context"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	log "github.com/hillguo/sanlog"
 	"github.com/hillguo/sanrpc/config"
 	"github.com/hillguo/sanrpc/errs"
+	"github.com/hillguo/sanrpc/naming"
+	"github.com/hillguo/sanrpc/naming/registry"
 	"github.com/hillguo/sanrpc/protocol"
+	"github.com/hillguo/sanrpc/protocol/gateway"
 	"github.com/hillguo/sanrpc/protocol/sanrpc"
+	"github.com/hillguo/sanrpc/service/healthpb"
 )
 
 type Service interface {
 	Name() string
+	Metadata() map[string]string
 	Serve() error
 	Register(serviceDesc interface{}) error
+	// 从名字服务注销并停止服务，最多等待 ShutdownTimeout
+	Close() error
+	// Shutdown 优雅退出：从名字服务注销，等待 ShutdownGrace 后停止监听，
+	// 处理中的请求结束后关闭连接，ctx 结束时强制关闭
+	Shutdown(ctx context.Context) error
 }
 
+const (
+	// DefaultShutdownGrace 默认的注销后等待时间
+	DefaultShutdownGrace = 5 * time.Second
+	// DefaultShutdownTimeout 默认的 Close 等待处理中请求结束的最长时间
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
 type service struct {
 	opts           *Options
 	ServeTransport ServerTransport
+
+	mu         sync.Mutex
+	registered string // 已注册到名字服务的地址
+
+	health *Health  // 为 nil 表示 MsgProtocol 不支持健康检查
+	names  []string // 登记到 health 的服务名
 }
 
 func NewServicesWithConfig(server_config *config.ServerConfig) []Service {
@@ -29,22 +60,35 @@ func NewServicesWithConfig(server_config *config.ServerConfig) []Service {
 		log.Info(svr)
 		s := &service{
 			opts: &Options{
-				Name:           svr.Name,
-				InMsgChanSize:  svr.InMsgChanSize,
-				OutMsgChanSize: svr.OutMsgChanSize,
-				ReadTimeout:    svr.ReadTimeout,
-				WriteTimeout:   svr.OutMsgChanSize,
-				Address:        svr.Address,
-				NetWork:        svr.NetWork,
+				Name:            svr.Name,
+				Namespace:       svr.Namespace,
+				EnvName:         svr.EnvName,
+				SetName:         svr.SetName,
+				Zone:            svr.Zone,
+				InMsgChanSize:   svr.InMsgChanSize,
+				OutMsgChanSize:  svr.OutMsgChanSize,
+				ReadTimeout:     svr.ReadTimeout,
+				WriteTimeout:    svr.OutMsgChanSize,
+				Address:         svr.Address,
+				NetWork:         svr.NetWork,
+				ShutdownGrace:   DefaultShutdownGrace,
+				ShutdownTimeout: DefaultShutdownTimeout,
 			},
 		}
+		if svr.Registry != "" {
+			s.opts.Registry = registry.Get(svr.Registry)
+			if s.opts.Registry == nil {
+				log.Errorf("service:%s registry %s not found", svr.Name, svr.Registry)
+			}
+		}
 		if svr.Protocol == "http" {
 			s.ServeTransport = NewHTTPTransport(s)
-			//s.opts.MsgProtocol = sanhttp.Default()
+			s.opts.MsgProtocol = gateway.DefaultGatewayProtocol
 		} else {
 			s.ServeTransport = NewTCPTransport(s)
 			s.opts.MsgProtocol = sanrpc.DefaultSanRPCProtocol
 		}
+		s.initBuiltin()
 		ss = append(ss, s)
 	}
 	return ss
@@ -54,14 +98,15 @@ func New(opts ...Option) Service {
 	var s *service
 	s = &service{
 		opts: &Options{
-			Name:           "",
-			InMsgChanSize:  1024,
-			OutMsgChanSize: 1024,
-			ReadTimeout:    0,
-			WriteTimeout:   0,
-			Address:        "",
-			NetWork:        "tcp",
-			MsgProtocol:    sanrpc.DefaultSanRPCProtocol,
+			Name:            "",
+			InMsgChanSize:   1024,
+			OutMsgChanSize:  1024,
+			ReadTimeout:     0,
+			WriteTimeout:    0,
+			Address:         "",
+			NetWork:         "tcp",
+			ShutdownGrace:   DefaultShutdownGrace,
+			ShutdownTimeout: DefaultShutdownTimeout,
 		},
 	}
 	for _, o := range opts {
@@ -72,12 +117,39 @@ func New(opts ...Option) Service {
 	} else if s.opts.NetWork == "http" {
 		s.ServeTransport = NewHTTPTransport(s)
 	}
+	// 没有指定 MsgProtocol 时，http 使用 HTTP 网关，其他使用 sanrpc 协议
+	if s.opts.MsgProtocol == nil {
+		if s.opts.NetWork == "http" {
+			s.opts.MsgProtocol = gateway.DefaultGatewayProtocol
+		} else {
+			s.opts.MsgProtocol = sanrpc.DefaultSanRPCProtocol
+		}
+	}
+	s.initBuiltin()
 	return s
 }
 
 func (s *service) Name() string {
 	return s.opts.Name
 }
+
+// Metadata 服务发布到名字服务的节点元数据
+func (s *service) Metadata() map[string]string {
+	md := make(map[string]string)
+	if s.opts.Namespace != "" {
+		md[naming.MetaNamespace] = s.opts.Namespace
+	}
+	if s.opts.EnvName != "" {
+		md[naming.MetaEnvName] = s.opts.EnvName
+	}
+	if s.opts.SetName != "" {
+		md[naming.MetaSetName] = s.opts.SetName
+	}
+	if s.opts.Zone != "" {
+		md[naming.MetaZone] = s.opts.Zone
+	}
+	return md
+}
 func (s *service) Serve() error {
 	err := s.ServeTransport.ListenAndServer()
 	if err != nil {
@@ -86,13 +158,128 @@ func (s *service) Serve() error {
 	return err
 }
 
+// Close 以 ShutdownTimeout 为期限优雅退出
+func (s *service) Close() error {
+	timeout := s.opts.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownGrace+timeout)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+// Shutdown 先把健康状态置为 NOT_SERVING 并从名字服务注销，
+// 等待 ShutdownGrace 让调用方不再选到本节点，再停止监听并等待处理中的请求结束
+func (s *service) Shutdown(ctx context.Context) error {
+	s.setServingStatus(healthpb.ServingStatus_NOT_SERVING)
+	if s.deregisterSelf() && s.opts.ShutdownGrace > 0 {
+		log.Infof("service:%s wait %v for callers to drop this node", s.opts.Name, s.opts.ShutdownGrace)
+		timer := time.NewTimer(s.opts.ShutdownGrace)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+	return s.ServeTransport.Shutdown(ctx)
+}
+
+// registerSelf 监听成功后由 transport 调用，把监听地址注册到名字服务
+func (s *service) registerSelf(addr net.Addr) error {
+	if s.opts.Registry == nil {
+		return nil
+	}
+	address := advertiseAddress(addr)
+	err := s.opts.Registry.Register(s.opts.Name,
+		registry.WithNetwork(s.opts.NetWork),
+		registry.WithAddress(address),
+		registry.WithMetadata(s.Metadata()))
+	if err != nil {
+		log.Errorf("service:%s register %s fail:%v", s.opts.Name, address, err)
+		return err
+	}
+	s.mu.Lock()
+	s.registered = address
+	s.mu.Unlock()
+	log.Infof("service:%s register %s success", s.opts.Name, address)
+	return nil
+}
+
+// deregisterSelf 从名字服务注销，返回之前是否已注册
+func (s *service) deregisterSelf() bool {
+	s.mu.Lock()
+	address := s.registered
+	s.registered = ""
+	s.mu.Unlock()
+	if s.opts.Registry == nil || address == "" {
+		return false
+	}
+	if err := s.opts.Registry.Deregister(s.opts.Name, registry.WithAddress(address)); err != nil {
+		log.Errorf("service:%s deregister %s fail:%v", s.opts.Name, address, err)
+		return true
+	}
+	log.Infof("service:%s deregister %s success", s.opts.Name, address)
+	return true
+}
+
+// advertiseAddress 监听在未指定 ip 上时，使用本机第一个非回环地址
+func advertiseAddress(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok || !tcp.IP.IsUnspecified() {
+		return addr.String()
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return addr.String()
+	}
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			return net.JoinHostPort(ipnet.IP.String(), strconv.Itoa(tcp.Port))
+		}
+	}
+	return addr.String()
+}
+
 func (s *service) Register(serviceDesc interface{}) error {
 	p := s.opts.MsgProtocol
 	if p == nil {
 		return errs.ErrServerNoMsgProtocol
 	}
 	if rs, ok := p.(protocol.RegisterServicer); ok {
-		return rs.RegisterService(serviceDesc)
+		if err := rs.RegisterService(serviceDesc); err != nil {
+			return err
+		}
+		s.addHealthName(strings.ToLower(reflect.Indirect(reflect.ValueOf(serviceDesc)).Type().Name()))
 	}
 	return nil
 }
+
+// initBuiltin 把 health 和 reflection 服务注册到 MsgProtocol，并登记 service 名
+func (s *service) initBuiltin() {
+	ReflectionFor(s.opts.MsgProtocol)
+	s.health = HealthFor(s.opts.MsgProtocol)
+	s.addHealthName(s.opts.Name)
+}
+
+func (s *service) addHealthName(name string) {
+	if s.health == nil || name == "" {
+		return
+	}
+	s.mu.Lock()
+	s.names = append(s.names, name)
+	s.mu.Unlock()
+	s.health.SetServingStatus(name, healthpb.ServingStatus_SERVING)
+}
+
+func (s *service) setServingStatus(status healthpb.ServingStatus) {
+	if s.health == nil {
+		return
+	}
+	s.mu.Lock()
+	names := s.names
+	s.mu.Unlock()
+	for _, name := range names {
+		s.health.SetServingStatus(name, status)
+	}
+}
@@ -26,13 +26,62 @@ type tcpTransport struct {
 	s *service
 
 	mu         sync.RWMutex
-	activeConn map[net.Conn]struct{}
-	doneChan   chan struct{}
+	ln         net.Listener
+	activeConn map[net.Conn]context.CancelFunc
+	closed     bool
+	conns      sync.WaitGroup // 处理中的连接
 }
 
-
+// Close 停止监听并关闭所有连接
 func (t *tcpTransport) Close() error {
-	return nil
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	var err error
+	if t.ln != nil {
+		err = t.ln.Close()
+	}
+	for conn, cancel := range t.activeConn {
+		conn.Close()
+		cancel()
+	}
+	return err
+}
+
+// Shutdown 停止监听，并让所有连接停止读取新请求；每个连接上处理中的请求写回响应后关闭连接。
+// ctx 结束时强制关闭剩余的连接
+func (t *tcpTransport) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	var err error
+	if t.ln != nil {
+		err = t.ln.Close()
+	}
+	// 打断阻塞中的读取，读协程看到 closed 后不再读取新请求
+	for conn := range t.activeConn {
+		conn.SetReadDeadline(time.Now())
+	}
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.conns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		log.Warnf("sanrpc: shutdown timeout, force close connections")
+		t.Close()
+		return ctx.Err()
+	}
+}
+
+func (t *tcpTransport) isClosed() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.closed
 }
 
 func (t *tcpTransport) ListenAndServer() error {
@@ -45,6 +94,18 @@ func (t *tcpTransport) ListenAndServer() error {
 			return err
 		}
 		log.Infof("sanrpc listening network:%s ,address:%s", t.s.opts.NetWork, t.s.opts.Address)
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			ln.Close()
+			return nil
+		}
+		t.ln = ln
+		t.mu.Unlock()
+		if err := t.s.registerSelf(ln.Addr()); err != nil {
+			ln.Close()
+			return err
+		}
 		return t.server(ln)
 	default:
 		return fmt.Errorf("transport: not support network type %s", t.s.opts.NetWork)
@@ -56,7 +117,7 @@ func (t *tcpTransport) server(ln net.Listener) error {
 
 	t.mu.Lock()
 	if t.activeConn == nil {
-		t.activeConn = make(map[net.Conn]struct{})
+		t.activeConn = make(map[net.Conn]context.CancelFunc)
 	}
 	t.mu.Unlock()
 
@@ -78,6 +139,12 @@ func (t *tcpTransport) server(ln net.Listener) error {
 				time.Sleep(tempDelay)
 				continue
 			}
+			t.mu.RLock()
+			closed := t.closed
+			t.mu.RUnlock()
+			if closed {
+				return nil
+			}
 			return e
 		}
 		tempDelay = 0
@@ -89,16 +156,25 @@ func (t *tcpTransport) server(ln net.Listener) error {
 		}
 
 		t.mu.Lock()
-		t.activeConn[conn] = struct{}{}
+		if t.closed {
+			t.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		ctx, cancelCtx := context.WithCancel(context.Background())
+		t.activeConn[conn] = cancelCtx
+		t.conns.Add(1)
 		t.mu.Unlock()
 
 		log.Infof("rpc: receive a client1 conn, remote addr: %+v", conn.RemoteAddr())
 
-		go t.serveConn(conn)
+		go t.serveConn(ctx, cancelCtx, conn)
 	}
 }
 
-func (t *tcpTransport) serveConn(conn net.Conn) {
+func (t *tcpTransport) serveConn(ctx context.Context, cancelCtx context.CancelFunc, conn net.Conn) {
+	defer t.conns.Done()
+	defer cancelCtx()
 	defer func() {
 		if err := recover(); err != nil {
 			const size = 64 << 10
@@ -114,6 +190,9 @@ func (t *tcpTransport) serveConn(conn net.Conn) {
 		delete(t.activeConn, conn)
 		t.mu.Unlock()
 		conn.Close()
+		if c, ok := t.s.opts.MsgProtocol.(protocol.ConnCloser); ok {
+			c.CloseConn(conn)
+		}
 
 	}()
 
@@ -149,8 +228,8 @@ func (t *tcpTransport) serveConn(conn net.Conn) {
 		return
 	}
 
-	ctx := context.Background()
-	ctx, cancelCtx := context.WithCancel(ctx)
+	// 连接上已读取、还没有写回响应的请求
+	var inflight sync.WaitGroup
 
 	in := make(chan protocol.Message, t.s.opts.InMsgChanSize)
 	out := make(chan protocol.Message, t.s.opts.OutMsgChanSize)
@@ -172,6 +251,10 @@ func (t *tcpTransport) serveConn(conn net.Conn) {
 					buf = buf[:ss]
 					log.Errorf("serving %s panic error: %s, stack:\n %s", conn.RemoteAddr(), err, buf)
 				}
+				// 优雅退出时等处理中的请求写回响应后再结束连接
+				if t.isClosed() {
+					waitInflight(ctx, &inflight)
+				}
 				wg.Done()
 				cancelCtx()
 			}()
@@ -186,6 +269,11 @@ func (t *tcpTransport) serveConn(conn net.Conn) {
 					if t.s.opts.ReadTimeout != 0 {
 						conn.SetReadDeadline(t0.Add(time.Duration(t.s.opts.ReadTimeout)))
 					}
+					// 在设置读超时之后检查，保证 Shutdown 设置的读超时不会被覆盖
+					if t.isClosed() {
+						log.Infof("connection: %s stop reading for shutdown", conn.RemoteAddr().String())
+						return
+					}
 					rpc, ok := t.s.opts.MsgProtocol.(protocol.RpcMsgProtocol)
 					if !ok {
 						cancelCtx()
@@ -194,7 +282,9 @@ func (t *tcpTransport) serveConn(conn net.Conn) {
 					}
 					req, err := rpc.DecodeMessage(conn)
 					if err != nil {
-						if err == io.EOF {
+						if t.isClosed() {
+							log.Infof("connection: %s stop reading for shutdown", conn.RemoteAddr().String())
+						} else if err == io.EOF {
 							log.Infof("client1 has closed this connection: %s", conn.RemoteAddr().String())
 						} else if strings.Contains(err.Error(), "use of closed network connection") {
 							log.Infof("sanrpc: connection %s is closed", conn.RemoteAddr().String())
@@ -206,6 +296,7 @@ func (t *tcpTransport) serveConn(conn net.Conn) {
 					}
 
 					log.Infof("read a message from conn %v", conn.RemoteAddr())
+					inflight.Add(1)
 					in <- req
 				}
 
@@ -302,11 +393,18 @@ func (t *tcpTransport) serveConn(conn net.Conn) {
 					}
 					data,err := rpc.EncodeMessage(resp)
 					if err != nil{
+						inflight.Done()
 						log.Error(err)
 						return
 					}
+					// 没有需要写回的响应，如 JSON-RPC 通知
+					if len(data) == 0 {
+						inflight.Done()
+						continue
+					}
 					log.Infof("rpc: encode resp , writr into conn")
 					_, err = conn.Write(data)
+					inflight.Done()
 					if err != nil {
 						log.Error("connection: %s write routine context done %v", conn.RemoteAddr().String(), err)
 						return
@@ -318,3 +416,16 @@ func (t *tcpTransport) serveConn(conn net.Conn) {
 	wg.Wait()
 	log.Infof("connection %s destroyed", conn.RemoteAddr().String())
 }
+
+// waitInflight 等待连接上处理中的请求结束，连接被强制关闭时不再等待
+func waitInflight(ctx context.Context, inflight *sync.WaitGroup) {
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hillguo/sanrpc/protocol"
+)
+
+// lineProtocol 每行一个消息，原样写回。与 jsonrpc 一样按连接保存读缓冲，由 CloseConn 释放
+type lineProtocol struct {
+	mu      sync.Mutex
+	readers map[io.ReadWriter]*bufio.Reader
+}
+
+func (p *lineProtocol) Handshake(rw io.ReadWriter) error {
+	return nil
+}
+
+func (p *lineProtocol) DecodeMessage(rw io.ReadWriter) (protocol.Message, error) {
+	p.mu.Lock()
+	r, ok := p.readers[rw]
+	if !ok {
+		r = bufio.NewReader(rw)
+		p.readers[rw] = r
+	}
+	p.mu.Unlock()
+	return r.ReadString('\n')
+}
+
+func (p *lineProtocol) HandleMessage(ctx context.Context, req protocol.Message) (protocol.Message, error) {
+	return req, nil
+}
+
+func (p *lineProtocol) EncodeMessage(res protocol.Message) ([]byte, error) {
+	return []byte(res.(string)), nil
+}
+
+func (p *lineProtocol) CloseConn(rw io.ReadWriter) {
+	p.mu.Lock()
+	delete(p.readers, rw)
+	p.mu.Unlock()
+}
+
+func (p *lineProtocol) open() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.readers)
+}
+
+func TestTCPTransportCloseConn(t *testing.T) {
+	tests := []struct {
+		name  string
+		close func(tr *tcpTransport, conns []net.Conn)
+	}{
+		{name: "client close", close: func(tr *tcpTransport, conns []net.Conn) {
+			for _, c := range conns {
+				c.Close()
+			}
+		}},
+		{name: "transport close", close: func(tr *tcpTransport, conns []net.Conn) {
+			tr.Close()
+		}},
+		{name: "transport shutdown", close: func(tr *tcpTransport, conns []net.Conn) {
+			tr.Shutdown(context.Background())
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &lineProtocol{readers: make(map[io.ReadWriter]*bufio.Reader)}
+			s := &service{opts: &Options{InMsgChanSize: 16, OutMsgChanSize: 16, MsgProtocol: p}}
+			tr := NewTCPTransport(s).(*tcpTransport)
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr.ln = ln
+			go tr.server(ln)
+			defer tr.Close()
+
+			var conns []net.Conn
+			for i := 0; i < 3; i++ {
+				c, err := net.Dial("tcp", ln.Addr().String())
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer c.Close()
+				c.Write([]byte("ping\n"))
+				if line, err := bufio.NewReader(c).ReadString('\n'); err != nil || line != "ping\n" {
+					t.Fatalf("echo = %q, %v", line, err)
+				}
+				conns = append(conns, c)
+			}
+			if n := p.open(); n != len(conns) {
+				t.Fatalf("%d connections with state, want %d", n, len(conns))
+			}
+
+			tt.close(tr, conns)
+			deadline := time.Now().Add(time.Second)
+			for p.open() != 0 {
+				if time.Now().After(deadline) {
+					t.Fatalf("state of %d closed connections not released", p.open())
+				}
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package service
 
+import "context"
+
 // Transport 传输层接口
 type ServerTransport interface {
 	ListenAndServer() error
+	// Shutdown 停止监听，等待处理中的请求结束后关闭连接；ctx 结束时强制关闭剩余连接
+	Shutdown(ctx context.Context) error
+	// Close 立即停止监听并关闭所有连接
 	Close() error
 }
\ No newline at end of file
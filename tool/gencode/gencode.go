@@ -4,6 +4,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	pluginGo "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"github.com/hillguo/sanrpc/tool/gencode/include/sanrpc/api"
 	"io/ioutil"
 	"log"
 	"os"
@@ -15,6 +16,17 @@ type MethodInfo struct {
 	MethodName string
 	InputType  string
 	OutputType string
+
+	InputFullType  string      // .mmhelloworld.HelloReq，thrift 为空
+	OutputFullType string      // .mmhelloworld.HelloResp，thrift 为空
+	HTTPRules      []*HTTPRule // option (sanrpc.api.http) 或 option (google.api.http) 声明的 REST 路由
+}
+
+// HTTPRule 一条 REST 路由
+type HTTPRule struct {
+	Method string // GET
+	Path   string // /v1/users/{id}
+	Body   string // 请求体绑定到的字段，"*" 为整个请求消息
 }
 
 type ProtoFileInfo struct {
@@ -25,6 +37,10 @@ type ProtoFileInfo struct {
 	ServiceName   string // MMHelloWorld
 	ModuleName    string // mmhelloworld
 	Methods       []*MethodInfo
+
+	// Messages 和 Enums 为请求中所有 proto 文件定义的类型，key 为全名，如 .mmhelloworld.HelloReq
+	Messages map[string]*descriptor.DescriptorProto
+	Enums    map[string]*descriptor.EnumDescriptorProto
 }
 
 func NewProtoFileInfo(fd *descriptor.FileDescriptorProto) *ProtoFileInfo {
@@ -50,13 +66,29 @@ func NewProtoFileInfo(fd *descriptor.FileDescriptorProto) *ProtoFileInfo {
 		// TODO 需要适配input或output import自不同package的proto
 		methodInfo.InputType = filepath.Ext(md.GetInputType())[1:]
 		methodInfo.OutputType = filepath.Ext(md.GetOutputType())[1:]
+		methodInfo.InputFullType = md.GetInputType()
+		methodInfo.OutputFullType = md.GetOutputType()
+		methodInfo.HTTPRules = httpRules(md)
 		info.Methods[i] = methodInfo
 	}
 	return info
 }
 
+// GenOenProtoTypeFunc 返回生成的文件名和内容，文件名为空时不生成文件
 type GenOenProtoTypeFunc func(*ProtoFileInfo) (string, string)
 
+// Regenerated 以这些后缀结尾的文件完全由工具生成，不应手工修改，每次都覆盖；其他文件已存在时不覆盖
+var Regenerated = []string{".gw.go", ".openapi.json"}
+
+func regenerated(fileName string) bool {
+	for _, suffix := range Regenerated {
+		if strings.HasSuffix(fileName, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
 func Main(genOenProtoTypeFunc ...GenOenProtoTypeFunc) {
 	data, err := ioutil.ReadAll(os.Stdin)
 	if err != nil {
@@ -79,23 +111,16 @@ func Main(genOenProtoTypeFunc ...GenOenProtoTypeFunc) {
 			continue
 		}
 		protoInfo := NewProtoFileInfo(fd)
-		for _, gen := range genOenProtoTypeFunc {
-			fileName, fileData := gen(protoInfo)
-			if protoInfo.ProtoDir != "." {
-				fileName = protoInfo.ProtoDir + "/" + fileName
-			}
-			if _, err := os.Stat(fileName); err == nil {
-				log.Printf("ignore existed file:%s", fileName)
-			} else if os.IsNotExist(err) {
-				log.Printf("add new file:%s", fileName)
-				newFile := &pluginGo.CodeGeneratorResponse_File{}
-				newFile.Name = &fileName
-				newFile.Content = &fileData
-				resp.File = append(resp.File, newFile)
-			} else {
-				log.Fatalf("fail Stat,err:%v", err)
-			}
+		protoInfo.Messages, protoInfo.Enums = collectTypes(req.ProtoFile)
+		resp.File = append(resp.File, genFiles(protoInfo, genOenProtoTypeFunc)...)
+	}
+	// 同时读取参数中指定的 thrift IDL，如 --sanrpc-server_out=thrift=hello.thrift:./
+	for _, path := range thriftFiles(req.GetParameter()) {
+		thriftInfo, err := NewThriftFileInfo(path)
+		if err != nil {
+			log.Fatalf("fail parse thrift:%s,err:%v", path, err)
 		}
+		resp.File = append(resp.File, genFiles(thriftInfo, genOenProtoTypeFunc)...)
 	}
 	if data, err = proto.Marshal(resp); err != nil {
 		log.Fatalf("fail Marshal output proto,err:%v", err)
@@ -104,3 +129,102 @@ func Main(genOenProtoTypeFunc ...GenOenProtoTypeFunc) {
 		log.Fatalf("fail Write output proto,err:%v", err)
 	}
 }
+
+func genFiles(protoInfo *ProtoFileInfo, genOenProtoTypeFunc []GenOenProtoTypeFunc) []*pluginGo.CodeGeneratorResponse_File {
+	var files []*pluginGo.CodeGeneratorResponse_File
+	for _, gen := range genOenProtoTypeFunc {
+		fileName, fileData := gen(protoInfo)
+		if fileName == "" {
+			continue
+		}
+		if protoInfo.ProtoDir != "." {
+			fileName = protoInfo.ProtoDir + "/" + fileName
+		}
+		if _, err := os.Stat(fileName); err == nil && !regenerated(fileName) {
+			log.Printf("ignore existed file:%s", fileName)
+		} else if err == nil || os.IsNotExist(err) {
+			log.Printf("add new file:%s", fileName)
+			newFile := &pluginGo.CodeGeneratorResponse_File{}
+			newFile.Name = &fileName
+			newFile.Content = &fileData
+			files = append(files, newFile)
+		} else {
+			log.Fatalf("fail Stat,err:%v", err)
+		}
+	}
+	return files
+}
+
+// googleHTTP google.api.http 注解，与 api.HttpRule 的消息结构相同。
+// 不调用 proto.RegisterExtension，避免与链接了 genproto 的程序重复注册
+var googleHTTP = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*api.HttpRule)(nil),
+	Field:         72295728,
+	Name:          "google.api.http",
+	Tag:           "bytes,72295728,opt,name=http",
+}
+
+// httpRules 解析方法上的 REST 路由注解，sanrpc.api.http 优先于 google.api.http
+func httpRules(md *descriptor.MethodDescriptorProto) []*HTTPRule {
+	if md.GetOptions() == nil {
+		return nil
+	}
+	desc := api.E_Http
+	if !proto.HasExtension(md.GetOptions(), desc) {
+		desc = googleHTTP
+		if !proto.HasExtension(md.GetOptions(), desc) {
+			return nil
+		}
+	}
+	ext, err := proto.GetExtension(md.GetOptions(), desc)
+	if err != nil {
+		log.Fatalf("fail parse http option of method:%s,err:%v", md.GetName(), err)
+	}
+	rule := ext.(*api.HttpRule)
+	var rules []*HTTPRule
+	for _, r := range append([]*api.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		h := &HTTPRule{Body: r.GetBody()}
+		switch p := r.GetPattern().(type) {
+		case *api.HttpRule_Get:
+			h.Method, h.Path = "GET", p.Get
+		case *api.HttpRule_Put:
+			h.Method, h.Path = "PUT", p.Put
+		case *api.HttpRule_Post:
+			h.Method, h.Path = "POST", p.Post
+		case *api.HttpRule_Delete:
+			h.Method, h.Path = "DELETE", p.Delete
+		case *api.HttpRule_Patch:
+			h.Method, h.Path = "PATCH", p.Patch
+		default:
+			log.Fatalf("method:%s http option has no pattern", md.GetName())
+		}
+		rules = append(rules, h)
+	}
+	return rules
+}
+
+// collectTypes 收集所有消息和枚举，包括嵌套定义的类型
+func collectTypes(fds []*descriptor.FileDescriptorProto) (map[string]*descriptor.DescriptorProto, map[string]*descriptor.EnumDescriptorProto) {
+	messages := make(map[string]*descriptor.DescriptorProto)
+	enums := make(map[string]*descriptor.EnumDescriptorProto)
+	var walk func(prefix string, msgs []*descriptor.DescriptorProto, es []*descriptor.EnumDescriptorProto)
+	walk = func(prefix string, msgs []*descriptor.DescriptorProto, es []*descriptor.EnumDescriptorProto) {
+		for _, e := range es {
+			enums[prefix+"."+e.GetName()] = e
+		}
+		for _, m := range msgs {
+			name := prefix + "." + m.GetName()
+			messages[name] = m
+			walk(name, m.GetNestedType(), m.GetEnumType())
+		}
+	}
+	for _, fd := range fds {
+		prefix := ""
+		if fd.GetPackage() != "" {
+			prefix = "." + fd.GetPackage()
+		}
+		walk(prefix, fd.GetMessageType(), fd.GetEnumType())
+	}
+	return messages, enums
+}
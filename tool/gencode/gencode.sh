@@ -1,6 +1,12 @@
 mkdir pb
 mkdir client
-protoc -I ./  $1 --go_out=./pb --sanrpc-client_out=./client --sanrpc-server_out=./  --sanrpc-main_out=./
+# 第二个参数可选，指定 thrift IDL 时同时为其中的 service 生成代码，thrift 结构体需另行用 thrift --gen go 生成到 pb
+thrift=""
+if [ -n "$2" ]; then
+    thrift="thrift=$2:"
+fi
+# REST 路由注解 sanrpc/api/http.proto 在本目录的 include 下，有路由时生成 <service>.gw.go 和 <service>.openapi.json
+protoc -I ./ -I `dirname $0`/include $1 --go_out=./pb --sanrpc-client_out=${thrift}./client --sanrpc-server_out=${thrift}./  --sanrpc-main_out=${thrift}./ --sanrpc-gateway_out=./
 
 mod=$1
 modname=`cut -d '.' ${mod} -f 0`
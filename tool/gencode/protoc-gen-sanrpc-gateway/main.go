@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hillguo/sanrpc/tool/gencode"
+)
+
+var formatRoutesPart1 = `// Code generated by protoc-gen-sanrpc-gateway. DO NOT EDIT.
+
+package main
+
+import (
+	"github.com/hillguo/sanrpc/protocol/gateway"
+)
+
+func init() {
+	for _, r := range []gateway.Route{
+`
+var formatRoute = `		{HTTPMethod: %q, Pattern: %q, Service: %q, Method: %q, Body: %q},
+`
+var formatRoutesPart2 = `	} {
+		if err := gateway.DefaultGatewayProtocol.AddRoute(r); err != nil {
+			panic(err)
+		}
+	}
+}
+`
+
+// genRoutes 生成把 REST 路由注册到 gateway.DefaultGatewayProtocol 的代码，没有路由时不生成
+func genRoutes(protoInfo *gencode.ProtoFileInfo) (string, string) {
+	var routes strings.Builder
+	for _, methodInfo := range protoInfo.Methods {
+		for _, rule := range methodInfo.HTTPRules {
+			routes.WriteString(fmt.Sprintf(formatRoute, rule.Method, rule.Path, protoInfo.ModuleName, methodInfo.MethodName, rule.Body))
+		}
+	}
+	if routes.Len() == 0 {
+		return "", ""
+	}
+	return protoInfo.ModuleName + ".gw.go", formatRoutesPart1 + routes.String() + formatRoutesPart2
+}
+
+func main() {
+	gencode.Main(genRoutes, genOpenAPI)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/hillguo/sanrpc/tool/gencode"
+)
+
+// 与 gateway.ErrorBody 一致
+const errorSchemaName = "sanrpc.ErrorBody"
+
+var pathVariable = regexp.MustCompile(`\{([\w.]+)(=[^}]*)?\}`)
+
+type schema map[string]interface{}
+
+// openAPI 根据 REST 路由生成 OpenAPI 3.0 文档
+type openAPI struct {
+	info    *gencode.ProtoFileInfo
+	schemas map[string]schema
+}
+
+// genOpenAPI 生成 OpenAPI 文档，没有路由时不生成
+func genOpenAPI(protoInfo *gencode.ProtoFileInfo) (string, string) {
+	g := &openAPI{info: protoInfo, schemas: make(map[string]schema)}
+	paths := make(map[string]map[string]interface{})
+	for _, methodInfo := range protoInfo.Methods {
+		for i, rule := range methodInfo.HTTPRules {
+			path := pathVariable.ReplaceAllString(rule.Path, "{$1}")
+			if paths[path] == nil {
+				paths[path] = make(map[string]interface{})
+			}
+			op := g.operation(methodInfo, rule)
+			// additional_bindings 的 operationId 加上序号，保持唯一
+			if i > 0 {
+				op["operationId"] = fmt.Sprintf("%s_%d", op["operationId"], i)
+			}
+			paths[path][strings.ToLower(rule.Method)] = op
+		}
+	}
+	if len(paths) == 0 {
+		return "", ""
+	}
+	g.schemas[errorSchemaName] = schema{
+		"type": "object",
+		"properties": map[string]schema{
+			"type":    {"type": "integer", "format": "int32"},
+			"code":    {"type": "integer", "format": "int32"},
+			"msg":     {"type": "string"},
+			"status":  {"type": "string"},
+			"details": {"type": "array", "items": schema{"type": "object"}},
+		},
+	}
+	doc := map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]string{
+			"title":   protoInfo.ServiceName,
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.schemas},
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatalf("fail Marshal openapi,err:%v", err)
+	}
+	return protoInfo.ModuleName + ".openapi.json", string(data) + "\n"
+}
+
+func (g *openAPI) operation(methodInfo *gencode.MethodInfo, rule *gencode.HTTPRule) map[string]interface{} {
+	op := map[string]interface{}{
+		"operationId": g.info.ServiceName + "_" + methodInfo.MethodName,
+		"tags":        []string{g.info.ServiceName},
+		"responses": map[string]interface{}{
+			"200": jsonContent("OK", g.ref(methodInfo.OutputFullType)),
+			"default": jsonContent("error", schema{
+				"$ref": "#/components/schemas/" + errorSchemaName,
+			}),
+		},
+	}
+
+	input := g.info.Messages[methodInfo.InputFullType]
+	var params []map[string]interface{}
+	bound := make(map[string]bool)
+	for _, m := range pathVariable.FindAllStringSubmatch(rule.Path, -1) {
+		bound[strings.Split(m[1], ".")[0]] = true
+		params = append(params, map[string]interface{}{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   g.fieldPathSchema(input, m[1]),
+		})
+	}
+
+	switch rule.Body {
+	case "":
+	case "*":
+		op["requestBody"] = jsonContent("", g.ref(methodInfo.InputFullType))
+	default:
+		bound[strings.Split(rule.Body, ".")[0]] = true
+		op["requestBody"] = jsonContent("", g.fieldPathSchema(input, rule.Body))
+	}
+
+	// 没有绑定到路径和请求体的顶层标量字段可以通过查询参数设置
+	if rule.Body != "*" && input != nil {
+		for _, f := range input.GetField() {
+			if bound[f.GetName()] || f.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+				continue
+			}
+			params = append(params, map[string]interface{}{
+				"name":   f.GetName(),
+				"in":     "query",
+				"schema": g.fieldSchema(f),
+			})
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	return op
+}
+
+func jsonContent(description string, s schema) map[string]interface{} {
+	c := map[string]interface{}{
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": s},
+		},
+	}
+	if description != "" {
+		c["description"] = description
+	}
+	return c
+}
+
+// ref 返回消息的引用，并把消息加入 components.schemas；消息未知时为 object
+func (g *openAPI) ref(fullName string) schema {
+	m, ok := g.info.Messages[fullName]
+	if !ok {
+		return schema{"type": "object"}
+	}
+	name := strings.TrimPrefix(fullName, ".")
+	if _, ok := g.schemas[name]; !ok {
+		s := schema{"type": "object"}
+		g.schemas[name] = s // 先占位，处理递归引用
+		props := make(map[string]schema)
+		for _, f := range m.GetField() {
+			props[f.GetJsonName()] = g.fieldSchema(f)
+		}
+		if len(props) > 0 {
+			s["properties"] = props
+		}
+	}
+	return schema{"$ref": "#/components/schemas/" + name}
+}
+
+// fieldPathSchema 返回 . 分隔的嵌套字段的类型
+func (g *openAPI) fieldPathSchema(m *descriptor.DescriptorProto, path string) schema {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		var field *descriptor.FieldDescriptorProto
+		for _, f := range m.GetField() {
+			if f.GetName() == name || f.GetJsonName() == name {
+				field = f
+			}
+		}
+		if field == nil {
+			log.Fatalf("field %s not found in %s", path, m.GetName())
+		}
+		if i == len(names)-1 {
+			return g.fieldSchema(field)
+		}
+		m = g.info.Messages[field.GetTypeName()]
+	}
+	return schema{"type": "string"}
+}
+
+func (g *openAPI) fieldSchema(f *descriptor.FieldDescriptorProto) schema {
+	var s schema
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		s = schema{"type": "number", "format": "double"}
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		s = schema{"type": "number", "format": "float"}
+	case descriptor.FieldDescriptorProto_TYPE_INT32, descriptor.FieldDescriptorProto_TYPE_SINT32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		s = schema{"type": "integer", "format": "int32"}
+	case descriptor.FieldDescriptorProto_TYPE_UINT32, descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		s = schema{"type": "integer", "format": "int64"}
+	case descriptor.FieldDescriptorProto_TYPE_INT64, descriptor.FieldDescriptorProto_TYPE_SINT64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64, descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		// proto3 JSON 中 64 位整数为字符串
+		s = schema{"type": "string", "format": "int64"}
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		s = schema{"type": "boolean"}
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		s = schema{"type": "string"}
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		s = schema{"type": "string", "format": "byte"}
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		s = schema{"type": "string"}
+		if e, ok := g.info.Enums[f.GetTypeName()]; ok {
+			var values []string
+			for _, v := range e.GetValue() {
+				values = append(values, v.GetName())
+			}
+			s["enum"] = values
+		}
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		if m, ok := g.info.Messages[f.GetTypeName()]; ok && m.GetOptions().GetMapEntry() {
+			// map 字段，value 为第二个字段
+			return schema{"type": "object", "additionalProperties": g.fieldSchema(m.GetField()[1])}
+		}
+		switch f.GetTypeName() {
+		case ".google.protobuf.Timestamp":
+			s = schema{"type": "string", "format": "date-time"}
+		case ".google.protobuf.Duration":
+			s = schema{"type": "string"}
+		default:
+			s = g.ref(f.GetTypeName())
+		}
+	default:
+		s = schema{"type": "object"}
+	}
+	if f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		return schema{"type": "array", "items": s}
+	}
+	return s
+}
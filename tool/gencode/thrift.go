@@ -0,0 +1,138 @@
+package gencode
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 传给插件的参数，多个文件用逗号分隔，如 --sanrpc-server_out=thrift=a.thrift,thrift=b.thrift:./
+const thriftParam = "thrift="
+
+var (
+	thriftComment   = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*|#[^\n]*`)
+	thriftNamespace = regexp.MustCompile(`\bnamespace\s+go\s+([\w.]+)`)
+	thriftService   = regexp.MustCompile(`(?s)\bservice\s+(\w+)(?:\s+extends\s+[\w.]+)?\s*\{(.*?)\}`)
+	thriftFunction  = regexp.MustCompile(`(?s)(?:oneway\s+)?(\S[^(]*?)\s+(\w+)\s*\(([^)]*)\)\s*(?:throws\s*\([^)]*\))?`)
+	thriftField     = regexp.MustCompile(`(?s)^\s*(?:-?\d+\s*:)?\s*(?:required\s+|optional\s+)?(.+?)\s+(\w+)\s*(?:=.*)?$`)
+	thriftBaseTypes = map[string]bool{
+		"void": true, "bool": true, "byte": true, "i8": true, "i16": true, "i32": true,
+		"i64": true, "double": true, "string": true, "binary": true,
+	}
+)
+
+// thriftFiles 从插件参数中取出 thrift 文件列表
+func thriftFiles(parameter string) []string {
+	var files []string
+	for _, p := range strings.Split(parameter, ",") {
+		if f := strings.TrimPrefix(p, thriftParam); f != p && f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// NewThriftFileInfo 解析 thrift IDL 的 service 定义，生成代码使用 thrift 生成的 Go 结构体。
+// 方法只有一个结构体参数且返回结构体时直接使用这两个类型，
+// 否则使用 thrift 为每个方法生成的 <Service><Method>Args/<Service><Method>Result。
+func NewThriftFileInfo(path string) (*ProtoFileInfo, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src := thriftComment.ReplaceAllString(string(data), "")
+
+	info := &ProtoFileInfo{}
+	info.FullProtoName = path
+	info.ProtoDir = filepath.Dir(path)
+	if m := thriftNamespace.FindStringSubmatch(src); m != nil {
+		info.GoPackageName = strings.Replace(m[1], ".", "/", -1)
+		info.PackageName = filepath.Base(info.GoPackageName)
+	} else {
+		info.PackageName = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	services := thriftService.FindAllStringSubmatch(src, -1)
+	if len(services) != 1 {
+		return nil, fmt.Errorf("invalid service num%d in thrift:%s", len(services), path)
+	}
+	info.ServiceName = services[0][1]
+	info.ModuleName = strings.ToLower(info.ServiceName)
+	for _, fn := range thriftFunction.FindAllStringSubmatch(services[0][2], -1) {
+		ret := strings.TrimLeft(fn[1], ",; \t\r\n")
+		ret = strings.TrimSpace(strings.TrimPrefix(ret, "oneway "))
+		name, args := fn[2], thriftFields(fn[3])
+		methodInfo := &MethodInfo{MethodName: publicize(name)}
+		if len(args) == 1 && isThriftStruct(args[0]) && isThriftStruct(ret) {
+			methodInfo.InputType = thriftTypeName(args[0])
+			methodInfo.OutputType = thriftTypeName(ret)
+		} else {
+			prefix := publicize(info.ServiceName) + publicize(name)
+			methodInfo.InputType = prefix + "Args"
+			methodInfo.OutputType = prefix + "Result"
+		}
+		info.Methods = append(info.Methods, methodInfo)
+	}
+	return info, nil
+}
+
+// thriftFields 返回参数列表中每个参数的类型
+func thriftFields(s string) []string {
+	var types []string
+	depth, start := 0, 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			switch s[i] {
+			case '<':
+				depth++
+				continue
+			case '>':
+				depth--
+				continue
+			case ',', ';':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		if m := thriftField.FindStringSubmatch(s[start:i]); m != nil {
+			types = append(types, m[1])
+		}
+		start = i + 1
+	}
+	return types
+}
+
+func isThriftStruct(t string) bool {
+	return !thriftBaseTypes[t] && !strings.ContainsAny(t, "<>")
+}
+
+// thriftTypeName 去掉 include 的文件前缀，如 shared.SharedStruct => SharedStruct
+func thriftTypeName(t string) string {
+	if i := strings.LastIndex(t, "."); i >= 0 {
+		t = t[i+1:]
+	}
+	return publicize(t)
+}
+
+// publicize 与 thrift Go 生成器一致：首字母大写，"_" 加小写字母替换为大写字母
+func publicize(s string) string {
+	if s == "" {
+		return s
+	}
+	b := []byte(strings.ToUpper(s[:1]) + s[1:])
+	out := b[:1]
+	for i := 1; i < len(b); i++ {
+		if b[i] == '_' && i+1 < len(b) && b[i+1] >= 'a' && b[i+1] <= 'z' {
+			out = append(out, b[i+1]-'a'+'A')
+			i++
+			continue
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}